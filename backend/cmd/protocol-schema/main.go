@@ -0,0 +1,31 @@
+// Command protocol-schema writes the JSON Schema of the WebSocket protocol.
+//
+//	go run ./cmd/protocol-schema -o ../frontend/src/types/protocol.schema.json
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/yaroslav/elias/pkg/protocol"
+)
+
+func main() {
+	out := flag.String("o", "", "output file (default stdout)")
+	flag.Parse()
+
+	data, err := protocol.JSONSchema()
+	if err != nil {
+		log.Fatalf("Error generating schema: %v", err)
+	}
+	data = append(data, '\n')
+
+	if *out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatalf("Error writing schema: %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -22,8 +23,16 @@ import (
 	"github.com/yaroslav/elias/internal/ws"
 )
 
+// shutdownTimeout bounds how long open connections may delay shutdown.
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	cfg := config.Load()
+	if cfg.DevMode {
+		log.Println("DEV_MODE is on: Telegram init data is not verified")
+	} else if cfg.TelegramBotToken == "" {
+		log.Fatal("TELEGRAM_BOT_TOKEN is required unless DEV_MODE is on")
+	}
 
 	// Database connection
 	ctx := context.Background()
@@ -49,16 +58,28 @@ func main() {
 
 	// Services
 	roomService := services.NewRoomService(pool)
-	gameService := services.NewGameService(pool, rdb)
+	presenceService := services.NewPresenceService(rdb)
+	gameService := services.NewGameService(pool, rdb, presenceService)
 	wordService := services.NewWordService(pool)
+	matchmakingService := services.NewMatchmakingService(pool, rdb, roomService)
+	summaryService := services.NewSummaryService(pool, gameService, roomService)
+	userService := services.NewUserService(pool)
+	ratingService := services.NewRatingService(pool)
+	chatService := services.NewChatService(pool, cfg.TelegramBotToken)
+	achievementService := services.NewAchievementService(pool)
 
 	// WebSocket hub
-	hub := ws.NewHub(rdb, gameService, wordService, roomService)
+	hub := ws.NewHub(rdb, gameService, wordService, roomService, presenceService, matchmakingService, summaryService, achievementService)
+	recovered, err := hub.Recover(ctx)
+	if err != nil {
+		log.Printf("Error recovering games: %v", err)
+	}
+	log.Printf("Recovered %d in-flight games", recovered)
 	go hub.Run()
 
 	// Start Telegram bot
 	if cfg.TelegramBotToken != "" {
-		telegramBot := bot.New(cfg.TelegramBotToken, cfg.AppURL)
+		telegramBot := bot.New(cfg.TelegramBotToken, cfg.AppURL, chatService)
 		go telegramBot.Start()
 	}
 
@@ -84,17 +105,53 @@ func main() {
 	api := app.Group("/api")
 
 	// Auth middleware for API
-	authMiddleware := middleware.NewTelegramAuth(cfg.TelegramBotToken)
+	authMiddleware := middleware.NewTelegramAuth(cfg.TelegramBotToken, cfg.AuthMaxAge, cfg.DevMode, userService)
+
+	// User routes
+	userHandler := handlers.NewUserHandler(userService, achievementService)
+	api.Get("/me", authMiddleware.Validate, userHandler.GetMe)
+	api.Get("/users/:id/stats", authMiddleware.Validate, userHandler.GetStats)
+	api.Get("/users/:id/achievements", authMiddleware.Validate, userHandler.GetAchievements)
+	api.Get("/achievements", authMiddleware.Validate, userHandler.ListAchievements)
+
+	// Rating routes
+	ratingHandler := handlers.NewRatingHandler(ratingService)
+	api.Get("/leaderboard", authMiddleware.Validate, ratingHandler.GetLeaderboard)
+	api.Get("/users/:id/ratings", authMiddleware.Validate, ratingHandler.GetHistory)
+
+	// Chat routes
+	chatHandler := handlers.NewChatHandler(chatService)
+	chats := api.Group("/chats")
+	chats.Get("/:chat/leaderboard", authMiddleware.Validate, chatHandler.GetLeaderboard)
+	chats.Get("/:chat/seasons/:season", authMiddleware.Validate, chatHandler.GetSeason)
+	chats.Get("/:chat/head-to-head", authMiddleware.Validate, chatHandler.GetHeadToHead)
 
 	// Room routes
-	roomHandler := handlers.NewRoomHandler(roomService, gameService, wordService, hub)
+	roomHandler := handlers.NewRoomHandler(roomService, gameService, wordService, matchmakingService, presenceService, summaryService, chatService, hub)
+	matchmakingHandler := handlers.NewMatchmakingHandler(matchmakingService, hub)
 	rooms := api.Group("/rooms")
 	rooms.Post("/", authMiddleware.Validate, roomHandler.CreateRoom)
+	rooms.Get("/public", authMiddleware.Validate, matchmakingHandler.ListPublicRooms)
+	rooms.Get("/by-code/:code", authMiddleware.Validate, roomHandler.GetRoomByCode)
+	rooms.Post("/by-code/:code/join", authMiddleware.Validate, roomHandler.JoinRoomByCode)
 	rooms.Get("/:id", authMiddleware.Validate, roomHandler.GetRoom)
 	rooms.Post("/:id/join", authMiddleware.Validate, roomHandler.JoinRoom)
+	rooms.Post("/:id/leave", authMiddleware.Validate, roomHandler.LeaveRoom)
+	rooms.Post("/:id/visibility", authMiddleware.Validate, roomHandler.SetVisibility)
 	rooms.Post("/:id/team", authMiddleware.Validate, roomHandler.ChangeTeam)
+	rooms.Post("/:id/teams", authMiddleware.Validate, roomHandler.SetNumTeams)
+	rooms.Post("/:id/teams/balance", authMiddleware.Validate, roomHandler.BalanceTeams)
+	rooms.Post("/:id/teams/:team/name", authMiddleware.Validate, roomHandler.RenameTeam)
+	rooms.Post("/:id/teams/:team/reroll", authMiddleware.Validate, roomHandler.RerollTeamName)
 	rooms.Post("/:id/start", authMiddleware.Validate, roomHandler.StartGame)
+	rooms.Post("/:id/skip-explainer", authMiddleware.Validate, roomHandler.SkipExplainer)
 	rooms.Get("/:id/stats", authMiddleware.Validate, roomHandler.GetStats)
+	rooms.Get("/:id/replay", authMiddleware.Validate, roomHandler.GetReplay)
+	rooms.Post("/:id/rematch", authMiddleware.Validate, roomHandler.Rematch)
+
+	// Matchmaking routes
+	matchmaking := api.Group("/matchmaking")
+	matchmaking.Post("/quick", authMiddleware.Validate, matchmakingHandler.QuickMatch)
 
 	// WebSocket route
 	wsHandler := handlers.NewWSHandler(hub, authMiddleware)
@@ -114,7 +171,12 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
-	if err := app.Shutdown(); err != nil {
-		log.Fatalf("Server shutdown error: %v", err)
+	if err := app.ShutdownWithTimeout(shutdownTimeout); err != nil {
+		log.Printf("Server shutdown error: %v", err)
+	}
+	// Round deadlines are kept in Redis; releasing the timer leases lets
+	// another instance (or this one after restart) take the rooms over
+	if err := hub.Close(); err != nil {
+		log.Printf("Hub close error: %v", err)
 	}
 }
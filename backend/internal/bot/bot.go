@@ -2,22 +2,36 @@ package bot
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
+
+	"github.com/yaroslav/elias/internal/models"
+	"github.com/yaroslav/elias/internal/services"
 )
 
+// leaderboardSize is how many players /leaderboard lists.
+const leaderboardSize = 10
+
 type Bot struct {
 	token  string
 	appURL string
+	chats  *services.ChatService
+
+	// username is the bot's own username, for t.me links
+	username string
 }
 
-func New(token, appURL string) *Bot {
+func New(token, appURL string, chats *services.ChatService) *Bot {
 	return &Bot{
 		token:  token,
 		appURL: appURL,
+		chats:  chats,
 	}
 }
 
@@ -33,10 +47,22 @@ type Message struct {
 }
 
 type Chat struct {
-	ID int64 `json:"id"`
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+// isGroup reports whether the chat is a group rather than a private chat.
+func (c Chat) isGroup() bool {
+	return c.Type == "group" || c.Type == "supergroup"
 }
 
 func (b *Bot) Start() {
+	username, err := b.getMe()
+	if err != nil {
+		log.Printf("Error getting bot username: %v", err)
+	}
+	b.username = username
+
 	log.Println("Bot polling started...")
 	offset := 0
 
@@ -77,34 +103,169 @@ func (b *Bot) getUpdates(offset int) ([]Update, error) {
 	return result.Result, nil
 }
 
+func (b *Bot) getMe() (string, error) {
+	resp, err := http.Get(fmt.Sprintf("https://api.telegram.org/bot%s/getMe", b.token))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Ok     bool `json:"ok"`
+		Result struct {
+			Username string `json:"username"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.Result.Username, nil
+}
+
 func (b *Bot) handleUpdate(update Update) {
 	if update.Message == nil {
 		return
 	}
 
-	if update.Message.Text == "/start" {
-		b.sendWebAppButton(update.Message.Chat.ID)
+	chat := update.Message.Chat
+	command, arg := parseCommand(update.Message.Text)
+	switch {
+	case chat.isGroup() && (command == "/start" || command == "/play"):
+		b.sendChatInvite(chat.ID)
+	case command == "/start":
+		// "/start <code>" comes from t.me/<bot>?start=<code> invite links
+		b.sendWebAppButton(chat.ID, arg)
+	case command == "/leaderboard":
+		if !chat.isGroup() {
+			b.sendMessage(chat.ID, "Таблица лидеров есть только в групповых чатах.", nil)
+			return
+		}
+		b.sendLeaderboard(chat.ID, arg)
 	}
 }
 
-func (b *Bot) sendWebAppButton(chatID int64) {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", b.token)
+// parseCommand splits "/cmd@bot arg" into "/cmd" and "arg".
+func parseCommand(text string) (string, string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", ""
+	}
 
-	payload := map[string]interface{}{
-		"chat_id": chatID,
-		"text":    "🎮 Добро пожаловать в Alias!\n\nНажмите кнопку ниже, чтобы начать игру:",
-		"reply_markup": map[string]interface{}{
-			"inline_keyboard": [][]map[string]interface{}{
+	command := fields[0]
+	if i := strings.Index(command, "@"); i >= 0 {
+		command = command[:i]
+	}
+
+	var arg string
+	if len(fields) > 1 {
+		arg = fields[1]
+	}
+	return command, arg
+}
+
+func (b *Bot) sendWebAppButton(chatID int64, roomCode string) {
+	text := "🎮 Добро пожаловать в Alias!\n\nНажмите кнопку ниже, чтобы начать игру:"
+	appURL := b.appURL
+	// The code comes straight from the link; anything that is not a room code
+	// gets the generic welcome
+	if code, err := services.NormalizeRoomCode(roomCode); err == nil {
+		text = fmt.Sprintf("🎮 Вас пригласили в комнату %s!\n\nНажмите кнопку ниже, чтобы присоединиться:", code)
+		appURL = b.roomURL(code)
+	}
+
+	b.sendMessage(chatID, text, map[string]interface{}{
+		"inline_keyboard": [][]map[string]interface{}{
+			{
 				{
-					{
-						"text": "🎯 Играть",
-						"web_app": map[string]string{
-							"url": b.appURL,
-						},
+					"text": "🎯 Играть",
+					"web_app": map[string]string{
+						"url": appURL,
 					},
 				},
 			},
 		},
+	})
+}
+
+// sendChatInvite posts a link that opens the Mini App with the chat's token,
+// so the rooms created from it count towards the chat's leaderboard. Web app
+// buttons are not allowed in groups, hence the t.me link.
+func (b *Bot) sendChatInvite(chatID int64) {
+	if b.username == "" {
+		log.Printf("Cannot invite chat %d: bot username is unknown", chatID)
+		return
+	}
+
+	link := fmt.Sprintf("https://t.me/%s?startapp=%s", b.username, b.chats.Token(chatID))
+	b.sendMessage(chatID, "🎮 Игры из этой кнопки попадают в таблицу лидеров чата.\n\nСоздай комнату и позови остальных:", map[string]interface{}{
+		"inline_keyboard": [][]map[string]interface{}{
+			{
+				{
+					"text": "🎯 Играть",
+					"url":  link,
+				},
+			},
+		},
+	})
+}
+
+// sendLeaderboard posts the standings of the current season, or of all time
+// for "/leaderboard all".
+func (b *Bot) sendLeaderboard(chatID int64, arg string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var leaderboard *models.ChatLeaderboard
+	var err error
+	if arg == "all" {
+		leaderboard, err = b.chats.GetLeaderboard(ctx, chatID)
+	} else {
+		leaderboard, err = b.chats.GetSeason(ctx, chatID, "")
+	}
+	if err != nil {
+		log.Printf("Error getting leaderboard of chat %d: %v", chatID, err)
+		return
+	}
+
+	b.sendMessage(chatID, formatLeaderboard(leaderboard), nil)
+}
+
+// formatLeaderboard renders the top of the standings as a chat message.
+func formatLeaderboard(leaderboard *models.ChatLeaderboard) string {
+	var sb strings.Builder
+	if leaderboard.Season != "" {
+		fmt.Fprintf(&sb, "🏆 Сезон %s\n\n", leaderboard.Season)
+	} else {
+		sb.WriteString("🏆 За всё время\n\n")
+	}
+
+	if len(leaderboard.Standings) == 0 {
+		sb.WriteString("Пока ни одной сыгранной игры. Начни с /play!")
+		return sb.String()
+	}
+
+	for i, st := range leaderboard.Standings {
+		if i == leaderboardSize {
+			break
+		}
+		name := st.FirstName
+		if name == "" {
+			name = st.Username
+		}
+		fmt.Fprintf(&sb, "%d. %s — побед: %d из %d, слов: %d\n", st.Rank, name, st.Wins, st.Games, st.WordsGuessed)
+	}
+	return sb.String()
+}
+
+func (b *Bot) sendMessage(chatID int64, text string, replyMarkup interface{}) {
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", b.token)
+
+	payload := map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
+	}
+	if replyMarkup != nil {
+		payload["reply_markup"] = replyMarkup
 	}
 
 	data, _ := json.Marshal(payload)
@@ -115,3 +276,12 @@ func (b *Bot) sendWebAppButton(chatID int64) {
 	}
 	defer resp.Body.Close()
 }
+
+// roomURL opens the Mini App straight into the room with the given code.
+func (b *Bot) roomURL(roomCode string) string {
+	sep := "?"
+	if strings.Contains(b.appURL, "?") {
+		sep = "&"
+	}
+	return b.appURL + sep + "room=" + url.QueryEscape(roomCode)
+}
@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -11,6 +14,12 @@ type Config struct {
 	PostgresDSN      string
 	RedisAddr        string
 	ServerPort       string
+
+	// AuthMaxAge is how old Telegram init data may be; zero accepts any age
+	AuthMaxAge time.Duration
+	// DevMode accepts init data without checking its signature, so the app
+	// can be used outside Telegram. Never enable it in production.
+	DevMode bool
 }
 
 func Load() *Config {
@@ -27,6 +36,8 @@ func Load() *Config {
 		),
 		RedisAddr:  fmt.Sprintf("%s:%s", getEnv("REDIS_HOST", "localhost"), getEnv("REDIS_PORT", "6379")),
 		ServerPort: getEnv("SERVER_PORT", "8080"),
+		AuthMaxAge: getEnvDuration("AUTH_MAX_AGE", 24*time.Hour),
+		DevMode:    getEnvBool("DEV_MODE", false),
 	}
 }
 
@@ -36,3 +47,29 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return b
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Printf("Invalid %s=%q, using %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
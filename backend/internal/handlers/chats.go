@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yaroslav/elias/internal/middleware"
+	"github.com/yaroslav/elias/internal/services"
+)
+
+// ChatHandler serves the leaderboards of Telegram chats. Only players of a
+// chat's games can see them.
+type ChatHandler struct {
+	chatService *services.ChatService
+}
+
+func NewChatHandler(chatService *services.ChatService) *ChatHandler {
+	return &ChatHandler{chatService: chatService}
+}
+
+func (h *ChatHandler) GetLeaderboard(c *fiber.Ctx) error {
+	chatID, ok, err := h.memberChat(c)
+	if !ok {
+		return err
+	}
+
+	leaderboard, err := h.chatService.GetLeaderboard(c.Context(), chatID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(leaderboard)
+}
+
+// GetSeason returns the standings of a season like "2024-Q2", or of the
+// running one for "current".
+func (h *ChatHandler) GetSeason(c *fiber.Ctx) error {
+	chatID, ok, err := h.memberChat(c)
+	if !ok {
+		return err
+	}
+
+	season := c.Params("season")
+	if season == "current" {
+		season = ""
+	}
+
+	leaderboard, err := h.chatService.GetSeason(c.Context(), chatID, season)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSeason) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(leaderboard)
+}
+
+// GetHeadToHead compares user_a (the caller by default) with user_b.
+func (h *ChatHandler) GetHeadToHead(c *fiber.Ctx) error {
+	chatID, ok, err := h.memberChat(c)
+	if !ok {
+		return err
+	}
+
+	userA := middleware.GetUser(c).ID
+	if a := c.Query("user_a"); a != "" {
+		if userA, err = strconv.ParseInt(a, 10, 64); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+		}
+	}
+	userB, err := strconv.ParseInt(c.Query("user_b"), 10, 64)
+	if err != nil || userB == userA {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+
+	h2h, err := h.chatService.GetHeadToHead(c.Context(), chatID, userA, userB)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(h2h)
+}
+
+// memberChat parses the chat id and checks that the caller played in the
+// chat. When ok is false the response is already written and the handler
+// should return err.
+func (h *ChatHandler) memberChat(c *fiber.Ctx) (chatID int64, ok bool, err error) {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return 0, false, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	chatID, err = strconv.ParseInt(c.Params("chat"), 10, 64)
+	if err != nil {
+		return 0, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid chat id"})
+	}
+
+	member, err := h.chatService.IsMember(c.Context(), chatID, user.ID)
+	if err != nil {
+		return 0, false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !member {
+		return 0, false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": services.ErrNotChatMember.Error()})
+	}
+	return chatID, true, nil
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yaroslav/elias/internal/middleware"
+	"github.com/yaroslav/elias/internal/models"
+	"github.com/yaroslav/elias/internal/services"
+	"github.com/yaroslav/elias/internal/ws"
+	"github.com/yaroslav/elias/pkg/protocol"
+)
+
+type MatchmakingHandler struct {
+	matchmakingService *services.MatchmakingService
+	hub                *ws.Hub
+}
+
+func NewMatchmakingHandler(matchmakingService *services.MatchmakingService, hub *ws.Hub) *MatchmakingHandler {
+	return &MatchmakingHandler{
+		matchmakingService: matchmakingService,
+		hub:                hub,
+	}
+}
+
+func (h *MatchmakingHandler) ListPublicRooms(c *fiber.Ctx) error {
+	var filter models.PublicRoomFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid filter"})
+	}
+
+	rooms, err := h.matchmakingService.ListPublicRooms(c.Context(), filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"rooms": rooms})
+}
+
+func (h *MatchmakingHandler) QuickMatch(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req models.QuickMatchRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+	}
+
+	room, player, created, err := h.matchmakingService.QuickMatch(c.Context(), user, req)
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedLang) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "unsupported language"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if !created {
+		msg, err := protocol.Encode(protocol.MsgTypePlayerJoined, protocol.PlayerJoinedPayload{Player: ws.WirePlayer(player)})
+		if err == nil {
+			h.hub.BroadcastToRoom(room.ID, msg)
+		}
+	}
+
+	status := fiber.StatusOK
+	if created {
+		status = fiber.StatusCreated
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"room":    room,
+		"player":  player,
+		"created": created,
+	})
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yaroslav/elias/internal/services"
+)
+
+type RatingHandler struct {
+	ratingService *services.RatingService
+}
+
+func NewRatingHandler(ratingService *services.RatingService) *RatingHandler {
+	return &RatingHandler{ratingService: ratingService}
+}
+
+// GetLeaderboard returns the leaderboard of a language over a time window
+// (all, month, week or day).
+func (h *RatingHandler) GetLeaderboard(c *fiber.Ctx) error {
+	lang := c.Query("lang", services.DefaultLang)
+	window := c.Query("window", "all")
+	limit := c.QueryInt("limit", 0)
+
+	entries, err := h.ratingService.GetLeaderboard(c.Context(), lang, window, limit)
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedLang) || errors.Is(err, services.ErrInvalidWindow) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"lang": lang, "window": window, "entries": entries})
+}
+
+// GetHistory returns the rating changes of a user, optionally in one language.
+func (h *RatingHandler) GetHistory(c *fiber.Ctx) error {
+	userID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+
+	history, err := h.ratingService.GetHistory(c.Context(), userID, c.Query("lang"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"user_id": userID, "history": history})
+}
@@ -1,10 +1,8 @@
 package handlers
 
 import (
-	"encoding/json"
 	"errors"
 	"log"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -12,21 +10,30 @@ import (
 	"github.com/yaroslav/elias/internal/models"
 	"github.com/yaroslav/elias/internal/services"
 	"github.com/yaroslav/elias/internal/ws"
+	"github.com/yaroslav/elias/pkg/protocol"
 )
 
 type RoomHandler struct {
-	roomService *services.RoomService
-	gameService *services.GameService
-	wordService *services.WordService
-	hub         *ws.Hub
+	roomService        *services.RoomService
+	gameService        *services.GameService
+	wordService        *services.WordService
+	matchmakingService *services.MatchmakingService
+	presenceService    *services.PresenceService
+	summaryService     *services.SummaryService
+	chatService        *services.ChatService
+	hub                *ws.Hub
 }
 
-func NewRoomHandler(roomService *services.RoomService, gameService *services.GameService, wordService *services.WordService, hub *ws.Hub) *RoomHandler {
+func NewRoomHandler(roomService *services.RoomService, gameService *services.GameService, wordService *services.WordService, matchmakingService *services.MatchmakingService, presenceService *services.PresenceService, summaryService *services.SummaryService, chatService *services.ChatService, hub *ws.Hub) *RoomHandler {
 	return &RoomHandler{
-		roomService: roomService,
-		gameService: gameService,
-		wordService: wordService,
-		hub:         hub,
+		roomService:        roomService,
+		gameService:        gameService,
+		wordService:        wordService,
+		matchmakingService: matchmakingService,
+		presenceService:    presenceService,
+		summaryService:     summaryService,
+		chatService:        chatService,
+		hub:                hub,
 	}
 }
 
@@ -51,10 +58,22 @@ func (h *RoomHandler) CreateRoom(c *fiber.Ctx) error {
 		req.NumTeams = 2
 	}
 
-	room, player, err := h.roomService.CreateRoom(c.Context(), user, req.Category, req.NumTeams)
+	if req.ChatToken != "" {
+		chatID, err := h.chatService.ParseToken(req.ChatToken)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		req.ChatID = &chatID
+	}
+
+	room, player, err := h.roomService.CreateRoom(c.Context(), user, req)
 	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedLang) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "unsupported language"})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
+	h.matchmakingService.TrySyncRoom(room.ID)
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"room":   room,
@@ -80,6 +99,7 @@ func (h *RoomHandler) GetRoom(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
+	h.markOnline(c, roomID, players)
 
 	return c.JSON(models.RoomResponse{
 		Room:    room,
@@ -98,6 +118,61 @@ func (h *RoomHandler) JoinRoom(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid room id"})
 	}
 
+	return h.joinRoom(c, roomID, user)
+}
+
+func (h *RoomHandler) GetRoomByCode(c *fiber.Ctx) error {
+	room, err := h.roomService.GetRoomByCode(c.Context(), c.Params("code"))
+	if err != nil {
+		return roomCodeErrorResponse(c, err)
+	}
+
+	players, err := h.roomService.GetRoomPlayers(c.Context(), room.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	h.markOnline(c, room.ID, players)
+
+	return c.JSON(models.RoomResponse{
+		Room:    room,
+		Players: players,
+	})
+}
+
+func (h *RoomHandler) JoinRoomByCode(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	room, err := h.roomService.GetRoomByCode(c.Context(), c.Params("code"))
+	if err != nil {
+		return roomCodeErrorResponse(c, err)
+	}
+
+	return h.joinRoom(c, room.ID, user)
+}
+
+func roomCodeErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrInvalidRoomCode) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid room code"})
+	}
+	if errors.Is(err, services.ErrRoomNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "room not found"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// markOnline fills in the players' online flags. Presence is best effort, so
+// errors only leave everybody offline.
+func (h *RoomHandler) markOnline(c *fiber.Ctx, roomID uuid.UUID, players []*models.Player) {
+	if err := h.presenceService.MarkOnline(c.Context(), roomID, players); err != nil {
+		log.Printf("Error getting presence in room %s: %v", roomID, err)
+	}
+}
+
+// joinRoom adds the user to the room and notifies the other players.
+func (h *RoomHandler) joinRoom(c *fiber.Ctx, roomID uuid.UUID, user *models.TelegramUser) error {
 	player, err := h.roomService.JoinRoom(c.Context(), roomID, user)
 	if err != nil {
 		if errors.Is(err, services.ErrRoomNotFound) {
@@ -111,19 +186,82 @@ func (h *RoomHandler) JoinRoom(c *fiber.Ctx) error {
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
+	h.matchmakingService.TrySyncRoom(roomID)
 
 	// Broadcast player joined to all clients in the room
-	message := map[string]interface{}{
-		"type": "player_joined",
-		"payload": map[string]interface{}{
-			"player": player,
-		},
-	}
-	if msgBytes, err := json.Marshal(message); err == nil {
+	msgBytes, err := protocol.Encode(protocol.MsgTypePlayerJoined, protocol.PlayerJoinedPayload{Player: ws.WirePlayer(player)})
+	if err == nil {
 		h.hub.BroadcastToRoom(roomID, msgBytes)
 	}
 
-	return c.JSON(fiber.Map{"player": player})
+	return c.JSON(fiber.Map{"player": player, "room_id": roomID})
+}
+
+func (h *RoomHandler) LeaveRoom(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	roomID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid room id"})
+	}
+
+	newHostID, err := h.roomService.LeaveRoom(c.Context(), roomID, user.ID)
+	if err != nil {
+		if errors.Is(err, services.ErrRoomNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "room not found"})
+		}
+		if errors.Is(err, services.ErrPlayerNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "player not found"})
+		}
+		if errors.Is(err, services.ErrGameInProgress) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "game already in progress"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	h.matchmakingService.TrySyncRoom(roomID)
+
+	msg, err := protocol.Encode(protocol.MsgTypePlayerLeft, protocol.PlayerLeftPayload{
+		UserID:    user.ID,
+		NewHostID: newHostID,
+	})
+	if err == nil {
+		h.hub.BroadcastToRoom(roomID, msg)
+	}
+
+	return c.JSON(fiber.Map{"status": "left"})
+}
+
+func (h *RoomHandler) SetVisibility(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	roomID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid room id"})
+	}
+
+	var req models.SetVisibilityRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	if err := h.roomService.SetVisibility(c.Context(), roomID, user.ID, req.IsPublic); err != nil {
+		if errors.Is(err, services.ErrRoomNotFound) || errors.Is(err, services.ErrPlayerNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, services.ErrNotHost) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "only host can change visibility"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	h.matchmakingService.TrySyncRoom(roomID)
+
+	return c.JSON(fiber.Map{"is_public": req.IsPublic})
 }
 
 func (h *RoomHandler) ChangeTeam(c *fiber.Ctx) error {
@@ -142,30 +280,47 @@ func (h *RoomHandler) ChangeTeam(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
 	}
 
-	player, err := h.roomService.ChangeTeam(c.Context(), roomID, user.ID, req.Team)
+	player, err := h.hub.ChangeTeam(c.Context(), roomID, user.ID, req.Team)
 	if err != nil {
+		if errors.Is(err, services.ErrRoomNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "room not found"})
+		}
 		if errors.Is(err, services.ErrPlayerNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "player not found"})
 		}
+		if errors.Is(err, services.ErrTeamNotFound) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid team"})
+		}
+		if errors.Is(err, services.ErrGameInProgress) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "game already in progress"})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Broadcast team change to all clients in the room
-	message := map[string]interface{}{
-		"type": "team_changed",
-		"payload": map[string]interface{}{
-			"user_id": user.ID,
-			"team":    req.Team,
-		},
+	return c.JSON(fiber.Map{"player": player})
+}
+
+func (h *RoomHandler) RerollTeamName(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
 	}
-	if msgBytes, err := json.Marshal(message); err == nil {
-		h.hub.BroadcastToRoom(roomID, msgBytes)
+
+	roomID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid room id"})
 	}
 
-	return c.JSON(fiber.Map{"player": player})
+	teams, err := h.roomService.RerollTeamName(c.Context(), roomID, user.ID, c.Params("team"))
+	if err != nil {
+		return teamErrorResponse(c, err)
+	}
+
+	h.broadcastTeams(roomID, teams, nil)
+	return c.JSON(fiber.Map{"teams": teams})
 }
 
-func (h *RoomHandler) StartGame(c *fiber.Ctx) error {
+func (h *RoomHandler) RenameTeam(c *fiber.Ctx) error {
 	user := middleware.GetUser(c)
 	if user == nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
@@ -176,78 +331,230 @@ func (h *RoomHandler) StartGame(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid room id"})
 	}
 
-	// Check if user is host
-	isHost, err := h.roomService.IsHost(c.Context(), roomID, user.ID)
+	var req models.RenameTeamRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	teams, err := h.roomService.RenameTeam(c.Context(), roomID, user.ID, c.Params("team"), req.Name)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return teamErrorResponse(c, err)
 	}
-	if !isHost {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "only host can start game"})
+
+	h.broadcastTeams(roomID, teams, nil)
+	return c.JSON(fiber.Map{"teams": teams})
+}
+
+func (h *RoomHandler) SetNumTeams(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	roomID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid room id"})
+	}
+
+	var req models.SetNumTeamsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	teams, unassigned, err := h.roomService.SetNumTeams(c.Context(), roomID, user.ID, req.NumTeams)
+	if err != nil {
+		return teamErrorResponse(c, err)
+	}
+
+	h.broadcastTeams(roomID, teams, unassigned)
+	return c.JSON(fiber.Map{"teams": teams, "unassigned": unassigned})
+}
+
+// BalanceTeams splits the players of the lobby into teams of even rating.
+func (h *RoomHandler) BalanceTeams(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	roomID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid room id"})
+	}
+
+	moved, err := h.hub.BalanceTeams(c.Context(), roomID, user.ID)
+	if err != nil {
+		return teamErrorResponse(c, err)
 	}
 
-	// Get players for the game
 	players, err := h.roomService.GetRoomPlayers(c.Context(), roomID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Start game using GameService
-	gameState, err := h.gameService.StartGame(c.Context(), roomID, players)
+	return c.JSON(fiber.Map{"players": players, "moved": len(moved)})
+}
+
+// broadcastTeams notifies the room about the new list of teams and the players
+// that lost their team.
+func (h *RoomHandler) broadcastTeams(roomID uuid.UUID, teams []models.Team, unassigned []int64) {
+	msg, err := protocol.Encode(protocol.MsgTypeTeamsUpdated, protocol.TeamsUpdatedPayload{
+		Teams:      ws.WireTeams(teams),
+		TeamNames:  services.TeamNamesOf(teams),
+		Unassigned: unassigned,
+	})
+	if err == nil {
+		h.hub.BroadcastToRoom(roomID, msg)
+	}
+}
+
+func teamErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrRoomNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrTeamNotFound), errors.Is(err, services.ErrPlayerNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrNotHost), errors.Is(err, services.ErrNotTeamEditor):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrTeamNameTaken), errors.Is(err, services.ErrGameInProgress):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrTeamNameTooShort),
+		errors.Is(err, services.ErrTeamNameTooLong),
+		errors.Is(err, services.ErrTeamNameProfane),
+		errors.Is(err, services.ErrInvalidNumTeams):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+func (h *RoomHandler) StartGame(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	roomID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid room id"})
+	}
+
+	if err := h.hub.StartGame(c.Context(), roomID, user.ID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrRoomNotFound), errors.Is(err, services.ErrPlayerNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, services.ErrNotHost):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "only host can start game"})
+		case errors.Is(err, services.ErrGameInProgress):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "game already in progress"})
+		case errors.Is(err, services.ErrStateConflict):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Printf("Failed to start game in room %s: %v", roomID, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Get room to know category
-	room, err := h.roomService.GetRoom(c.Context(), roomID)
+	return c.JSON(fiber.Map{"status": "started"})
+}
+
+// SkipExplainer ends a round paused for a disconnected explainer once the
+// reconnect grace window is over.
+func (h *RoomHandler) SkipExplainer(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	roomID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid room id"})
+	}
+
+	if err := h.hub.SkipExplainer(c.Context(), roomID, user.ID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrRoomNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "game not found"})
+		case errors.Is(err, services.ErrNotHost), errors.Is(err, services.ErrPlayerNotFound):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "only host can skip the explainer"})
+		case errors.Is(err, services.ErrRoundNotPaused), errors.Is(err, services.ErrSkipTooEarly), errors.Is(err, services.ErrStateConflict):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Get first word
-	firstWord, err := h.wordService.GetRandomWord(c.Context(), roomID, "ru", room.Category)
+	return c.JSON(fiber.Map{"status": "skipped"})
+}
+
+func (h *RoomHandler) Rematch(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	roomID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		log.Printf("Failed to get random word: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get first word"})
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid room id"})
 	}
-	log.Printf("Got first word: %s (id=%d) category=%s", firstWord.Word, firstWord.ID, room.Category)
 
-	// Set current word in game state
-	if err := h.gameService.SetCurrentWord(c.Context(), roomID, firstWord); err != nil {
-		log.Printf("Failed to set current word: %v", err)
+	room, created, err := h.roomService.Rematch(c.Context(), roomID, user.ID)
+	if err != nil {
+		if errors.Is(err, services.ErrRoomNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "room not found"})
+		}
+		if errors.Is(err, services.ErrPlayerNotFound) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not a player of this room"})
+		}
+		if errors.Is(err, services.ErrGameNotFinished) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "game is not finished yet"})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Broadcast game started to all clients in the room
-	gameStartedMsg := map[string]interface{}{
-		"type": "game_started",
-		"payload": map[string]interface{}{
-			"explainer_id": gameState.CurrentExplainer,
-			"round_end_at": gameState.RoundEndAt.Unix(),
-		},
+	if created {
+		h.matchmakingService.TrySyncRoom(room.ID)
+
+		// Move everybody still connected to the finished room into the new one
+		msg, err := protocol.Encode(protocol.MsgTypeRematch, protocol.RematchPayload{
+			RoomID:      room.ID,
+			Code:        room.Code,
+			RequestedBy: user.ID,
+		})
+		if err == nil {
+			h.hub.BroadcastToRoom(roomID, msg)
+		}
 	}
-	if msgBytes, err := json.Marshal(gameStartedMsg); err == nil {
-		log.Printf("Broadcasting game_started to room %s, explainer=%d", roomID, gameState.CurrentExplainer)
-		h.hub.BroadcastToRoom(roomID, msgBytes)
+
+	status := fiber.StatusOK
+	if created {
+		status = fiber.StatusCreated
 	}
+	return c.Status(status).JSON(fiber.Map{"room": room, "created": created})
+}
 
-	// Broadcast first word to the room
-	newWordMsg := map[string]interface{}{
-		"type": "new_word",
-		"payload": map[string]interface{}{
-			"word_id": firstWord.ID,
-			"word":    firstWord.Word,
-		},
+// GetReplay returns the game log of a finished game in order, for replaying
+// it. The log of a running game would show the words to the guessers.
+func (h *RoomHandler) GetReplay(c *fiber.Ctx) error {
+	roomID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid room id"})
 	}
-	if msgBytes, err := json.Marshal(newWordMsg); err == nil {
-		log.Printf("Broadcasting new_word to room %s: %s", roomID, firstWord.Word)
-		h.hub.BroadcastToRoom(roomID, msgBytes)
+
+	room, err := h.roomService.GetRoom(c.Context(), roomID)
+	if err != nil {
+		if errors.Is(err, services.ErrRoomNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "room not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if room.Status != models.RoomStatusFinished {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "game is not finished yet"})
 	}
 
-	// Start the round timer (60 seconds)
-	log.Printf("Starting timer for room %s", roomID)
-	h.hub.StartTimer(roomID, 60*time.Second)
+	events, err := h.gameService.GetGameEvents(c.Context(), roomID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
 
-	return c.JSON(fiber.Map{"status": "started"})
+	return c.JSON(fiber.Map{"room_id": room.ID, "events": events})
 }
 
 func (h *RoomHandler) GetStats(c *fiber.Ctx) error {
@@ -274,19 +581,33 @@ func (h *RoomHandler) GetStats(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Calculate team scores
-	teamScores := map[string]int{"A": 0, "B": 0}
+	summary, err := h.summaryService.GetSummary(c.Context(), roomID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	teamScores := make(map[string]int, len(summary.Standings))
+	for _, st := range summary.Standings {
+		teamScores[st.Team] = st.Score
+	}
+	explained := make(map[int64]*models.PlayerSummary, len(summary.Players))
+	for _, ps := range summary.Players {
+		explained[ps.UserID] = ps
+	}
+
 	var playerStats []*models.PlayerStats
 	for _, p := range players {
-		if p.Team != "" {
-			teamScores[p.Team] += p.Score
-		}
-		playerStats = append(playerStats, &models.PlayerStats{
+		stats := &models.PlayerStats{
 			UserID:    p.UserID,
 			FirstName: p.FirstName,
 			Team:      p.Team,
 			Score:     p.Score,
-		})
+		}
+		if ps, ok := explained[p.UserID]; ok {
+			stats.WordsGuessed = ps.WordsGuessed
+			stats.WordsMissed = ps.WordsMissed
+		}
+		playerStats = append(playerStats, stats)
 	}
 
 	return c.JSON(models.GameStats{
@@ -294,5 +615,6 @@ func (h *RoomHandler) GetStats(c *fiber.Ctx) error {
 		TeamScores: teamScores,
 		Players:    playerStats,
 		Rounds:     roundStats,
+		Summary:    summary,
 	})
 }
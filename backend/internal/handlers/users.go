@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yaroslav/elias/internal/middleware"
+	"github.com/yaroslav/elias/internal/services"
+)
+
+type UserHandler struct {
+	userService        *services.UserService
+	achievementService *services.AchievementService
+}
+
+func NewUserHandler(userService *services.UserService, achievementService *services.AchievementService) *UserHandler {
+	return &UserHandler{
+		userService:        userService,
+		achievementService: achievementService,
+	}
+}
+
+// GetMe returns the profile and lifetime stats of the calling user.
+func (h *UserHandler) GetMe(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	profile, err := h.userService.GetUser(c.Context(), user.ID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	stats, err := h.userService.GetStats(c.Context(), user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"user": profile, "stats": stats})
+}
+
+func (h *UserHandler) GetStats(c *fiber.Ctx) error {
+	userID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+
+	stats, err := h.userService.GetStats(c.Context(), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(stats)
+}
+
+// GetAchievements returns the achievements the user unlocked.
+func (h *UserHandler) GetAchievements(c *fiber.Ctx) error {
+	userID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+
+	achievements, err := h.achievementService.GetUserAchievements(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"user_id": userID, "achievements": achievements})
+}
+
+// ListAchievements returns every achievement that can be unlocked.
+func (h *UserHandler) ListAchievements(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"achievements": h.achievementService.Rules()})
+}
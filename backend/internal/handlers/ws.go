@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"strconv"
+
 	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/yaroslav/elias/internal/middleware"
 	"github.com/yaroslav/elias/internal/ws"
+	"github.com/yaroslav/elias/pkg/protocol"
 )
 
 type WSHandler struct {
@@ -34,13 +37,30 @@ func (h *WSHandler) HandleWebSocket(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "missing init data"})
 	}
 
-	user, err := h.auth.ParseAndValidate(initData)
+	user, err := h.auth.Authenticate(c.Context(), initData)
+	if err != nil {
+		return c.Status(middleware.AuthErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Clients ask for a protocol version with v; without it they speak version 1
+	requested, _ := strconv.Atoi(c.Query("v"))
+	version, err := protocol.Negotiate(requested)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":                err.Error(),
+			"min_protocol_version": protocol.MinProtocolVersion,
+			"max_protocol_version": protocol.ProtocolVersion,
+		})
+	}
+
+	// Reconnecting clients pass the last event they saw to resume from it
+	lastSeq, _ := strconv.ParseInt(c.Query("last_seq"), 10, 64)
+	if lastSeq < 0 {
+		lastSeq = 0
 	}
 
 	return websocket.New(func(conn *websocket.Conn) {
-		client := ws.NewClient(h.hub, conn, roomID, user)
+		client := ws.NewClient(h.hub, conn, roomID, user, lastSeq, version)
 		h.hub.Register(client)
 
 		go client.WritePump()
@@ -1,24 +1,63 @@
 package middleware
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/url"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/yaroslav/elias/internal/models"
+	"github.com/yaroslav/elias/internal/services"
 )
 
+// authClockSkew is how far in the future auth_date may be, for servers whose
+// clock is behind Telegram's.
+const authClockSkew = time.Minute
+
+var (
+	ErrMissingHash     = errors.New("missing hash")
+	ErrInvalidHash     = errors.New("invalid hash")
+	ErrMissingAuthDate = errors.New("missing auth_date")
+	ErrInitDataExpired = errors.New("init data expired")
+	ErrMissingUser     = errors.New("missing user data")
+	ErrNoBotToken      = errors.New("bot token is not configured")
+	// ErrInitDataFromFuture means auth_date is ahead of the server's clock by
+	// more than authClockSkew: the data is forged or a clock is wrong.
+	ErrInitDataFromFuture = errors.New("init data signed in the future")
+	// ErrSaveUser means the init data was valid but the user could not be
+	// recorded. Players reference users, so the request cannot go on.
+	ErrSaveUser = errors.New("could not save user")
+)
+
+// TelegramAuth authenticates requests by the init data Telegram passes to the
+// Mini App: its hash must be signed with the bot token and its auth_date be
+// at most maxAge old. In dev mode neither is checked.
 type TelegramAuth struct {
 	botToken string
+	maxAge   time.Duration
+	devMode  bool
+	users    *services.UserService
+
+	now func() time.Time
 }
 
-func NewTelegramAuth(botToken string) *TelegramAuth {
-	return &TelegramAuth{botToken: botToken}
+func NewTelegramAuth(botToken string, maxAge time.Duration, devMode bool, users *services.UserService) *TelegramAuth {
+	return &TelegramAuth{
+		botToken: botToken,
+		maxAge:   maxAge,
+		devMode:  devMode,
+		users:    users,
+		now:      time.Now,
+	}
 }
 
 func (a *TelegramAuth) Validate(c *fiber.Ctx) error {
@@ -41,9 +80,9 @@ func (a *TelegramAuth) Validate(c *fiber.Ctx) error {
 		})
 	}
 
-	user, err := a.ParseAndValidate(initData)
+	user, err := a.Authenticate(c.Context(), initData)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		return c.Status(AuthErrorStatus(err)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
@@ -52,58 +91,119 @@ func (a *TelegramAuth) Validate(c *fiber.Ctx) error {
 	return c.Next()
 }
 
-func (a *TelegramAuth) ParseAndValidate(initData string) (*models.TelegramUser, error) {
-	// Parse init data
-	values, err := url.ParseQuery(initData)
+// Authenticate validates initData and records the user it belongs to. It
+// returns ErrSaveUser if the user could not be recorded.
+func (a *TelegramAuth) Authenticate(ctx context.Context, initData string) (*models.TelegramUser, error) {
+	user, err := a.ParseAndValidate(initData)
 	if err != nil {
 		return nil, err
 	}
 
-	// Extract hash
-	hash := values.Get("hash")
-	values.Del("hash")
-
-	// Create data check string (sorted alphabetically)
-	var keys []string
-	for k := range values {
-		keys = append(keys, k)
+	if a.users != nil {
+		if err := a.users.Upsert(ctx, user); err != nil {
+			log.Printf("Error saving user %d: %v", user.ID, err)
+			return nil, ErrSaveUser
+		}
 	}
-	sort.Strings(keys)
+	return user, nil
+}
 
-	var parts []string
-	for _, k := range keys {
-		parts = append(parts, k+"="+values.Get(k))
+// AuthErrorStatus is the HTTP status for an error of Authenticate: the init
+// data was rejected, or the server failed to record the user.
+func AuthErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrSaveUser):
+		return fiber.StatusInternalServerError
+	case errors.Is(err, ErrInitDataExpired), errors.Is(err, ErrInitDataFromFuture):
+		return fiber.StatusUnauthorized
 	}
-	dataCheckString := strings.Join(parts, "\n")
+	return fiber.StatusUnauthorized
+}
 
-	// Validate HMAC
-	if a.botToken != "" {
-		secretKey := hmacSHA256([]byte("WebAppData"), []byte(a.botToken))
-		expectedHash := hex.EncodeToString(hmacSHA256(secretKey, []byte(dataCheckString)))
+// ParseAndValidate checks the signature and the age of initData and returns
+// the user it belongs to.
+func (a *TelegramAuth) ParseAndValidate(initData string) (*models.TelegramUser, error) {
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, err
+	}
 
-		if hash != expectedHash {
-			// For development, allow bypass if token is empty
-			if a.botToken != "" && hash != "" {
-				// In production, uncomment this:
-				// return nil, errors.New("invalid hash")
-			}
+	if !a.devMode {
+		if err := a.verify(values); err != nil {
+			return nil, err
 		}
 	}
 
-	// Parse user
 	userJSON := values.Get("user")
 	if userJSON == "" {
-		return nil, fiber.NewError(fiber.StatusBadRequest, "missing user data")
+		return nil, ErrMissingUser
 	}
 
 	var user models.TelegramUser
 	if err := json.Unmarshal([]byte(userJSON), &user); err != nil {
 		return nil, err
 	}
+	if user.ID == 0 {
+		return nil, ErrMissingUser
+	}
 
 	return &user, nil
 }
 
+// verify checks the hash and auth_date of init data as described in
+// https://core.telegram.org/bots/webapps#validating-data-received-via-the-mini-app
+func (a *TelegramAuth) verify(values url.Values) error {
+	if a.botToken == "" {
+		return ErrNoBotToken
+	}
+
+	hash, err := hex.DecodeString(values.Get("hash"))
+	if err != nil {
+		return ErrInvalidHash
+	}
+	if len(hash) == 0 {
+		return ErrMissingHash
+	}
+
+	secretKey := hmacSHA256([]byte("WebAppData"), []byte(a.botToken))
+	expected := hmacSHA256(secretKey, []byte(dataCheckString(values)))
+	if !hmac.Equal(hash, expected) {
+		return ErrInvalidHash
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return ErrMissingAuthDate
+	}
+	signedAt := time.Unix(authDate, 0)
+	now := a.now()
+	if signedAt.After(now.Add(authClockSkew)) {
+		return ErrInitDataFromFuture
+	}
+	if a.maxAge > 0 && now.Sub(signedAt) > a.maxAge {
+		return ErrInitDataExpired
+	}
+	return nil
+}
+
+// dataCheckString is every field but hash as key=value, sorted by key and
+// joined by newlines.
+func dataCheckString(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if k != "hash" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+values.Get(k))
+	}
+	return strings.Join(parts, "\n")
+}
+
 func hmacSHA256(key, data []byte) []byte {
 	h := hmac.New(sha256.New, key)
 	h.Write(data)
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// The fixture was signed independently of this package with testBotToken,
+// the way Telegram signs init data.
+const (
+	testBotToken = "123456:TEST-TOKEN-for-fixtures"
+
+	signedInitData = "auth_date=1700000000&query_id=AAHdF6IQAAAAAN0XohDhrOrc" +
+		"&user=%7B%22id%22%3A1001%2C%22first_name%22%3A%22Anna%22%2C%22username%22%3A%22anna_test%22%2C%22language_code%22%3A%22ru%22%7D" +
+		"&hash=a8f65b06891b206634120bac17fbde4ee527fbe9b9063b6cc62658759e729bcb"
+
+	// signedUserForged is the fixture with the user swapped for another one
+	signedUserForged = "auth_date=1700000000&query_id=AAHdF6IQAAAAAN0XohDhrOrc" +
+		"&user=%7B%22id%22%3A1002%2C%22first_name%22%3A%22Anna%22%2C%22username%22%3A%22anna_test%22%2C%22language_code%22%3A%22ru%22%7D" +
+		"&hash=a8f65b06891b206634120bac17fbde4ee527fbe9b9063b6cc62658759e729bcb"
+)
+
+var signedAt = time.Unix(1700000000, 0)
+
+func newTestAuth(botToken string, maxAge time.Duration, devMode bool, now time.Time) *TelegramAuth {
+	a := NewTelegramAuth(botToken, maxAge, devMode, nil)
+	a.now = func() time.Time { return now }
+	return a
+}
+
+func TestParseAndValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		botToken string
+		maxAge   time.Duration
+		devMode  bool
+		now      time.Time
+		initData string
+		wantID   int64
+		wantErr  error
+	}{
+		{
+			name:     "valid",
+			botToken: testBotToken,
+			maxAge:   24 * time.Hour,
+			now:      signedAt.Add(time.Hour),
+			initData: signedInitData,
+			wantID:   1001,
+		},
+		{
+			name:     "no max age",
+			botToken: testBotToken,
+			now:      signedAt.AddDate(1, 0, 0),
+			initData: signedInitData,
+			wantID:   1001,
+		},
+		{
+			name:     "expired",
+			botToken: testBotToken,
+			maxAge:   24 * time.Hour,
+			now:      signedAt.Add(25 * time.Hour),
+			initData: signedInitData,
+			wantErr:  ErrInitDataExpired,
+		},
+		{
+			name:     "signed in the future",
+			botToken: testBotToken,
+			maxAge:   24 * time.Hour,
+			now:      signedAt.Add(-time.Hour),
+			initData: signedInitData,
+			wantErr:  ErrInitDataFromFuture,
+		},
+		{
+			name:     "clock skew tolerated",
+			botToken: testBotToken,
+			maxAge:   24 * time.Hour,
+			now:      signedAt.Add(-30 * time.Second),
+			initData: signedInitData,
+			wantID:   1001,
+		},
+		{
+			name:     "other bot token",
+			botToken: "654321:OTHER-TOKEN",
+			now:      signedAt,
+			initData: signedInitData,
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "forged user",
+			botToken: testBotToken,
+			now:      signedAt,
+			initData: signedUserForged,
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "forged auth_date",
+			botToken: testBotToken,
+			now:      signedAt,
+			initData: strings.Replace(signedInitData, "auth_date=1700000000", "auth_date=1700000001", 1),
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "missing hash",
+			botToken: testBotToken,
+			now:      signedAt,
+			initData: signedInitData[:strings.Index(signedInitData, "&hash=")],
+			wantErr:  ErrMissingHash,
+		},
+		{
+			name:     "hash not hex",
+			botToken: testBotToken,
+			now:      signedAt,
+			initData: signedInitData[:strings.Index(signedInitData, "&hash=")] + "&hash=zz",
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "no bot token",
+			now:      signedAt,
+			initData: signedInitData,
+			wantErr:  ErrNoBotToken,
+		},
+		{
+			name:     "dev mode skips the signature",
+			devMode:  true,
+			now:      signedAt.AddDate(1, 0, 0),
+			initData: signedUserForged,
+			wantID:   1002,
+		},
+		{
+			name:     "dev mode still needs a user",
+			devMode:  true,
+			now:      signedAt,
+			initData: "auth_date=1700000000",
+			wantErr:  ErrMissingUser,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAuth(tt.botToken, tt.maxAge, tt.devMode, tt.now)
+			user, err := a.ParseAndValidate(tt.initData)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if user.ID != tt.wantID {
+				t.Errorf("Expected user %d, got %d", tt.wantID, user.ID)
+			}
+		})
+	}
+}
+
+func TestParseAndValidateUser(t *testing.T) {
+	a := newTestAuth(testBotToken, 0, false, signedAt)
+	user, err := a.ParseAndValidate(signedInitData)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user.FirstName != "Anna" || user.Username != "anna_test" {
+		t.Errorf("Expected Anna (anna_test), got %s (%s)", user.FirstName, user.Username)
+	}
+}
+
+func TestAuthErrorStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"Invalid hash", ErrInvalidHash, fiber.StatusUnauthorized},
+		{"Expired", ErrInitDataExpired, fiber.StatusUnauthorized},
+		{"From the future", ErrInitDataFromFuture, fiber.StatusUnauthorized},
+		{"User not saved", ErrSaveUser, fiber.StatusInternalServerError},
+		{"Wrapped", fmt.Errorf("ws: %w", ErrSaveUser), fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AuthErrorStatus(tt.err); got != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,15 +15,30 @@ const (
 	RoomStatusFinished RoomStatus = "finished"
 )
 
+// Team is a room team. ID is stable for the lifetime of the room and is what
+// players.team references, so renaming a team never touches player rows.
+type Team struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
 type Room struct {
 	ID                 uuid.UUID  `json:"id"`
+	Code               string     `json:"code,omitempty"`
 	Status             RoomStatus `json:"status"`
 	CurrentRound       int        `json:"current_round"`
 	CurrentExplainerID *int64     `json:"current_explainer_id,omitempty"`
 	RoundEndAt         *time.Time `json:"round_end_at,omitempty"`
 	Category           string     `json:"category"`
+	Lang               string     `json:"lang"`
+	IsPublic           bool       `json:"is_public"`
+	RoundSeconds       int        `json:"round_seconds"`
+	WinningScore       int        `json:"winning_score"`
 	NumTeams           int        `json:"num_teams"`
+	Teams              []Team     `json:"teams"`
 	TeamNames          []string   `json:"team_names"`
+	RematchRoomID      *uuid.UUID `json:"rematch_room_id,omitempty"`
+	ChatID             *int64     `json:"chat_id,omitempty"`
 	CreatedAt          time.Time  `json:"created_at"`
 }
 
@@ -35,6 +51,8 @@ type Player struct {
 	Team      string    `json:"team,omitempty"`
 	Score     int       `json:"score"`
 	IsHost    bool      `json:"is_host"`
+	Ready     bool      `json:"ready"`
+	Online    bool      `json:"online"`
 	JoinedAt  time.Time `json:"joined_at"`
 }
 
@@ -63,6 +81,99 @@ type TelegramUser struct {
 	PhotoURL  string `json:"photo_url,omitempty"`
 }
 
+// User is a Telegram user who has used the app.
+type User struct {
+	ID         int64     `json:"id"`
+	Username   string    `json:"username,omitempty"`
+	FirstName  string    `json:"first_name,omitempty"`
+	LastName   string    `json:"last_name,omitempty"`
+	PhotoURL   string    `json:"photo_url,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// UserStats are a user's lifetime stats over finished games.
+type UserStats struct {
+	UserID      int64 `json:"user_id"`
+	GamesPlayed int   `json:"games_played"`
+	Wins        int   `json:"wins"`
+	// WordsExplained and WordsGuessed count the words the user explained
+	WordsExplained    int     `json:"words_explained"`
+	WordsGuessed      int     `json:"words_guessed"`
+	GuessRate         float64 `json:"guess_rate"`
+	FavouriteCategory string  `json:"favourite_category,omitempty"`
+}
+
+// RatingChange is how one rated game changed a user's rating.
+type RatingChange struct {
+	RoomID       uuid.UUID `json:"room_id"`
+	Lang         string    `json:"lang"`
+	RatingBefore int       `json:"rating_before"`
+	RatingAfter  int       `json:"rating_after"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Achievement is a rule that unlocks once a metric of a user reaches the
+// threshold. The rules are data, see services/achievements.json.
+type Achievement struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Metric      string `json:"metric"`
+	Threshold   int    `json:"threshold"`
+}
+
+// UserAchievement is an achievement a user unlocked, in the game RoomID.
+type UserAchievement struct {
+	Achievement
+	RoomID     *uuid.UUID `json:"room_id,omitempty"`
+	UnlockedAt time.Time  `json:"unlocked_at"`
+}
+
+// ChatStanding is a player's place among the players of a Telegram chat,
+// counted over the finished games started from the chat.
+type ChatStanding struct {
+	Rank         int    `json:"rank"`
+	UserID       int64  `json:"user_id"`
+	Username     string `json:"username,omitempty"`
+	FirstName    string `json:"first_name,omitempty"`
+	Games        int    `json:"games"`
+	Wins         int    `json:"wins"`
+	WordsGuessed int    `json:"words_guessed"`
+}
+
+// ChatLeaderboard ranks the players of a chat. Season is empty for all time.
+type ChatLeaderboard struct {
+	ChatID    int64           `json:"chat_id"`
+	Season    string          `json:"season,omitempty"`
+	Standings []*ChatStanding `json:"standings"`
+}
+
+// HeadToHead compares two players over the chat's finished games. Draws are
+// games they played against each other that neither of them won.
+type HeadToHead struct {
+	ChatID        int64 `json:"chat_id"`
+	UserA         int64 `json:"user_a"`
+	UserB         int64 `json:"user_b"`
+	WinsA         int   `json:"wins_a"`
+	WinsB         int   `json:"wins_b"`
+	Draws         int   `json:"draws"`
+	GamesTogether int   `json:"games_together"`
+	WinsTogether  int   `json:"wins_together"`
+}
+
+// LeaderboardEntry is a user's place on the leaderboard of a language. Games
+// and Change cover the time window the leaderboard was asked for.
+type LeaderboardEntry struct {
+	Rank      int    `json:"rank"`
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username,omitempty"`
+	FirstName string `json:"first_name,omitempty"`
+	Rating    int    `json:"rating"`
+	Games     int    `json:"games"`
+	Change    int    `json:"change"`
+}
+
 // API responses
 type RoomResponse struct {
 	Room    *Room     `json:"room"`
@@ -71,7 +182,41 @@ type RoomResponse struct {
 
 type CreateRoomRequest struct {
 	Category string `json:"category"`
+	Lang     string `json:"lang"`
 	NumTeams int    `json:"num_teams"`
+	IsPublic bool   `json:"is_public"`
+	// ChatToken links the room to the Telegram chat the bot issued it for;
+	// ChatID is the chat it was verified to belong to
+	ChatToken string `json:"chat_token,omitempty"`
+	ChatID    *int64 `json:"-"`
+}
+
+type SetVisibilityRequest struct {
+	IsPublic bool `json:"is_public"`
+}
+
+// PublicRoom is a lobby listed in the public room browser.
+type PublicRoom struct {
+	ID        uuid.UUID `json:"id"`
+	Code      string    `json:"code"`
+	Category  string    `json:"category"`
+	Lang      string    `json:"lang"`
+	NumTeams  int       `json:"num_teams"`
+	Players   int       `json:"players"`
+	FreeSeats int       `json:"free_seats"`
+	HostName  string    `json:"host_name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PublicRoomFilter struct {
+	Lang         string `query:"lang" json:"lang"`
+	Category     string `query:"category" json:"category"`
+	MinFreeSeats int    `query:"min_free_seats" json:"min_free_seats"`
+}
+
+type QuickMatchRequest struct {
+	Lang     string `json:"lang"`
+	Category string `json:"category"`
 }
 
 type JoinRoomRequest struct{}
@@ -80,11 +225,29 @@ type ChangeTeamRequest struct {
 	Team string `json:"team"`
 }
 
+// RoomRules changes the rules of a room in the lobby. Zero fields keep the
+// current value.
+type RoomRules struct {
+	Category     string `json:"category,omitempty"`
+	Lang         string `json:"lang,omitempty"`
+	RoundSeconds int    `json:"round_seconds,omitempty"`
+	WinningScore int    `json:"winning_score,omitempty"`
+}
+
+type RenameTeamRequest struct {
+	Name string `json:"name"`
+}
+
+type SetNumTeamsRequest struct {
+	NumTeams int `json:"num_teams"`
+}
+
 type GameStats struct {
 	RoomID     uuid.UUID            `json:"room_id"`
 	TeamScores map[string]int       `json:"team_scores"`
 	Players    []*PlayerStats       `json:"players"`
 	Rounds     []*RoundStats        `json:"rounds"`
+	Summary    *GameSummary         `json:"summary,omitempty"`
 }
 
 type PlayerStats struct {
@@ -102,3 +265,84 @@ type RoundStats struct {
 	WordsGuessed int   `json:"words_guessed"`
 	WordsMissed  int   `json:"words_missed"`
 }
+
+// RoundSummary lists the words played in one round.
+type RoundSummary struct {
+	RoundNum     int           `json:"round_num"`
+	Words        []*RoundEntry `json:"words"`
+	WordsGuessed int           `json:"words_guessed"`
+	WordsMissed  int           `json:"words_missed"`
+}
+
+type RoundEntry struct {
+	WordID  int    `json:"word_id"`
+	Word    string `json:"word"`
+	Guessed bool   `json:"guessed"`
+}
+
+// GameEvent is an entry of a room's append-only game log.
+type GameEvent struct {
+	ID        int64           `json:"id"`
+	RoomID    uuid.UUID       `json:"room_id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// GameSummary sums up a finished game. Word counts of a player are the words
+// they explained.
+type GameSummary struct {
+	RoomID    uuid.UUID        `json:"room_id"`
+	Winner    string           `json:"winner,omitempty"`
+	Standings []*TeamStanding  `json:"standings"`
+	Players   []*PlayerSummary `json:"players"`
+	// MVPID is the player who explained the most guessed words; 0 if nobody
+	// got any word guessed
+	MVPID         int64            `json:"mvp_id,omitempty"`
+	BestRound     *RoundHighlight  `json:"best_round,omitempty"`
+	LongestStreak *StreakHighlight `json:"longest_streak,omitempty"`
+	HardestWord   *WordHighlight   `json:"hardest_word,omitempty"`
+}
+
+// TeamStanding is a team's final place. Teams with equal scores share it.
+type TeamStanding struct {
+	Team  string `json:"team"`
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+	Place int    `json:"place"`
+}
+
+type PlayerSummary struct {
+	UserID          int64  `json:"user_id"`
+	FirstName       string `json:"first_name"`
+	Team            string `json:"team"`
+	WordsGuessed    int    `json:"words_guessed"`
+	WordsMissed     int    `json:"words_missed"`
+	RoundsExplained int    `json:"rounds_explained"`
+	// Efficiency is the share of explained words that were guessed
+	Efficiency    float64 `json:"efficiency"`
+	LongestStreak int     `json:"longest_streak"`
+}
+
+// RoundHighlight is the round with the most guessed words.
+type RoundHighlight struct {
+	RoundNum     int   `json:"round_num"`
+	ExplainerID  int64 `json:"explainer_id"`
+	WordsGuessed int   `json:"words_guessed"`
+}
+
+// StreakHighlight is the longest run of guessed words within a round.
+type StreakHighlight struct {
+	RoundNum    int   `json:"round_num"`
+	ExplainerID int64 `json:"explainer_id"`
+	Length      int   `json:"length"`
+}
+
+// WordHighlight is the guessed word other games guess the least.
+type WordHighlight struct {
+	WordID      int     `json:"word_id"`
+	Word        string  `json:"word"`
+	RoundNum    int     `json:"round_num"`
+	ExplainerID int64   `json:"explainer_id"`
+	GuessRate   float64 `json:"guess_rate"`
+}
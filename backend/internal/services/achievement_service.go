@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yaroslav/elias/internal/models"
+)
+
+// Achievements are declared in achievements.json: each one names a metric and
+// the threshold at which it unlocks. The engine below only computes metrics,
+// after a swipe for the explainer and at the end of a game for every player,
+// so adding an achievement over an existing metric needs no code.
+
+//go:embed achievements.json
+var achievementsJSON []byte
+
+// Achievement metrics.
+const (
+	// After a swipe, for the explainer
+	MetricRoundGuessed = "round_guessed" // words guessed in the current round
+	MetricWordsGuessed = "words_guessed" // words guessed over all games
+
+	// At the end of a game, for every player with a team
+	MetricGamesPlayed = "games_played" // finished games over all games
+	MetricGamesWon    = "games_won"    // won games over all games
+	MetricCleanWin    = "clean_win"    // 1 if the team won without skipping a word
+	MetricComeback    = "comeback"     // largest deficit of a team that went on to win
+)
+
+const (
+	achievementTriggerSwipe   = "swipe"
+	achievementTriggerGameEnd = "game_end"
+)
+
+// achievementMetrics maps every metric to the trigger that computes it.
+var achievementMetrics = map[string]string{
+	MetricRoundGuessed: achievementTriggerSwipe,
+	MetricWordsGuessed: achievementTriggerSwipe,
+	MetricGamesPlayed:  achievementTriggerGameEnd,
+	MetricGamesWon:     achievementTriggerGameEnd,
+	MetricCleanWin:     achievementTriggerGameEnd,
+	MetricComeback:     achievementTriggerGameEnd,
+}
+
+var ErrInvalidAchievement = errors.New("invalid achievement")
+
+var achievementRules = mustParseAchievements(achievementsJSON)
+
+// UnlockedAchievement is an achievement a user has just unlocked.
+type UnlockedAchievement struct {
+	UserID      int64
+	Achievement models.Achievement
+}
+
+type AchievementService struct {
+	pool  *pgxpool.Pool
+	rules []models.Achievement
+}
+
+func NewAchievementService(pool *pgxpool.Pool) *AchievementService {
+	return &AchievementService{pool: pool, rules: achievementRules}
+}
+
+func mustParseAchievements(data []byte) []models.Achievement {
+	rules, err := parseAchievements(data)
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+// parseAchievements reads and checks achievement rules: ids are unique, the
+// metrics known and the thresholds positive.
+func parseAchievements(data []byte) ([]models.Achievement, error) {
+	var rules []models.Achievement
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		switch {
+		case r.ID == "" || len(r.ID) > 64:
+			return nil, fmt.Errorf("%w: bad id %q", ErrInvalidAchievement, r.ID)
+		case seen[r.ID]:
+			return nil, fmt.Errorf("%w: duplicate id %q", ErrInvalidAchievement, r.ID)
+		case achievementMetrics[r.Metric] == "":
+			return nil, fmt.Errorf("%w: %s has unknown metric %q", ErrInvalidAchievement, r.ID, r.Metric)
+		case r.Threshold <= 0:
+			return nil, fmt.Errorf("%w: %s needs a positive threshold", ErrInvalidAchievement, r.ID)
+		}
+		seen[r.ID] = true
+	}
+	return rules, nil
+}
+
+// Rules returns every achievement.
+func (s *AchievementService) Rules() []models.Achievement {
+	return s.rules
+}
+
+// evaluateAchievements returns the rules over the given metrics that are not
+// unlocked yet and whose threshold is reached.
+func evaluateAchievements(rules []models.Achievement, metrics map[string]int, unlocked map[string]bool) []models.Achievement {
+	var reached []models.Achievement
+	for _, r := range rules {
+		value, ok := metrics[r.Metric]
+		if !ok || unlocked[r.ID] || value < r.Threshold {
+			continue
+		}
+		reached = append(reached, r)
+	}
+	return reached
+}
+
+// pending returns whether any rule of trigger is still locked for the user.
+func (s *AchievementService) pending(trigger string, unlocked map[string]bool) bool {
+	for _, r := range s.rules {
+		if achievementMetrics[r.Metric] == trigger && !unlocked[r.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+// OnSwipe evaluates the achievements of the explainer after a swipe.
+func (s *AchievementService) OnSwipe(ctx context.Context, roomID uuid.UUID, explainerID int64) ([]UnlockedAchievement, error) {
+	unlocked, err := s.unlockedBy(ctx, explainerID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.pending(achievementTriggerSwipe, unlocked) {
+		return nil, nil
+	}
+
+	var roundGuessed, wordsGuessed int
+	err = s.pool.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM round_words
+			 WHERE room_id = $1 AND explainer_id = $2 AND guessed
+			   AND round_num = (SELECT MAX(round_num) FROM round_words WHERE room_id = $1)),
+			(SELECT COUNT(*) FROM round_words WHERE explainer_id = $2 AND guessed)
+	`, roomID, explainerID).Scan(&roundGuessed, &wordsGuessed)
+	if err != nil {
+		return nil, err
+	}
+
+	reached := evaluateAchievements(s.rules, map[string]int{
+		MetricRoundGuessed: roundGuessed,
+		MetricWordsGuessed: wordsGuessed,
+	}, unlocked)
+	return s.unlock(ctx, explainerID, roomID, reached)
+}
+
+// OnGameEnd evaluates the achievements of every player of a finished game.
+// Running it again for the same game unlocks nothing new.
+func (s *AchievementService) OnGameEnd(ctx context.Context, roomID uuid.UUID) ([]UnlockedAchievement, error) {
+	var winner *string
+	err := s.pool.QueryRow(ctx, `SELECT winner FROM rooms WHERE id = $1`, roomID).Scan(&winner)
+	if err != nil {
+		return nil, err
+	}
+
+	words, err := s.teamWords(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	skips, deficits := teamWordStats(words)
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT p.user_id, p.team,
+			   (SELECT COUNT(*) FROM players lp JOIN rooms lr ON lr.id = lp.room_id
+			    WHERE lp.user_id = p.user_id AND lp.team <> '' AND lr.status = $2),
+			   (SELECT COUNT(*) FROM players lp JOIN rooms lr ON lr.id = lp.room_id
+			    WHERE lp.user_id = p.user_id AND lr.status = $2 AND lr.winner = lp.team)
+		FROM players p
+		WHERE p.room_id = $1 AND p.team <> ''
+	`, roomID, models.RoomStatusFinished)
+	if err != nil {
+		return nil, err
+	}
+	type playerMetrics struct {
+		userID  int64
+		metrics map[string]int
+	}
+	var players []playerMetrics
+	for rows.Next() {
+		var userID int64
+		var team string
+		var played, won int
+		if err := rows.Scan(&userID, &team, &played, &won); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		metrics := map[string]int{
+			MetricGamesPlayed: played,
+			MetricGamesWon:    won,
+			MetricCleanWin:    0,
+			MetricComeback:    0,
+		}
+		if winner != nil && *winner == team {
+			if skips[team] == 0 {
+				metrics[MetricCleanWin] = 1
+			}
+			metrics[MetricComeback] = deficits[team]
+		}
+		players = append(players, playerMetrics{userID: userID, metrics: metrics})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var all []UnlockedAchievement
+	for _, p := range players {
+		unlocked, err := s.unlockedBy(ctx, p.userID)
+		if err != nil {
+			return nil, err
+		}
+		reached := evaluateAchievements(s.rules, p.metrics, unlocked)
+		newly, err := s.unlock(ctx, p.userID, roomID, reached)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, newly...)
+	}
+	return all, nil
+}
+
+// teamWord is a word of a game in the order it was swiped.
+type teamWord struct {
+	Team    string
+	Guessed bool
+}
+
+func (s *AchievementService) teamWords(ctx context.Context, roomID uuid.UUID) ([]teamWord, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT team, guessed FROM round_words
+		WHERE room_id = $1 AND team IS NOT NULL
+		ORDER BY id
+	`, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var words []teamWord
+	for rows.Next() {
+		var w teamWord
+		if err := rows.Scan(&w.Team, &w.Guessed); err != nil {
+			return nil, err
+		}
+		words = append(words, w)
+	}
+	return words, rows.Err()
+}
+
+// teamWordStats replays the words of a game and returns the words every team
+// skipped and the largest number of points it trailed the leading team by.
+func teamWordStats(words []teamWord) (skips map[string]int, deficits map[string]int) {
+	skips = make(map[string]int)
+	deficits = make(map[string]int)
+	scores := make(map[string]int)
+	for _, w := range words {
+		scores[w.Team] = 0
+	}
+	for _, w := range words {
+		if !w.Guessed {
+			skips[w.Team]++
+			continue
+		}
+		scores[w.Team]++
+
+		for team, score := range scores {
+			for other, otherScore := range scores {
+				if other != team && otherScore-score > deficits[team] {
+					deficits[team] = otherScore - score
+				}
+			}
+		}
+	}
+	return skips, deficits
+}
+
+func (s *AchievementService) unlockedBy(ctx context.Context, userID int64) (map[string]bool, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT achievement_id FROM user_achievements WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	unlocked := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		unlocked[id] = true
+	}
+	return unlocked, rows.Err()
+}
+
+// unlock stores the achievements for the user and returns those that were
+// not unlocked before, so concurrent evaluations announce each one once.
+func (s *AchievementService) unlock(ctx context.Context, userID int64, roomID uuid.UUID, reached []models.Achievement) ([]UnlockedAchievement, error) {
+	if len(reached) == 0 {
+		return nil, nil
+	}
+
+	byID := make(map[string]models.Achievement, len(reached))
+	ids := make([]string, 0, len(reached))
+	for _, a := range reached {
+		byID[a.ID] = a
+		ids = append(ids, a.ID)
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		INSERT INTO user_achievements (user_id, achievement_id, room_id)
+		SELECT $1, id, $3 FROM unnest($2::text[]) AS id
+		ON CONFLICT (user_id, achievement_id) DO NOTHING
+		RETURNING achievement_id
+	`, userID, ids, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var unlocked []UnlockedAchievement
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		unlocked = append(unlocked, UnlockedAchievement{UserID: userID, Achievement: byID[id]})
+	}
+	return unlocked, rows.Err()
+}
+
+// GetUserAchievements returns the achievements the user unlocked, newest
+// first. Unlocks of achievements that were since removed are left out.
+func (s *AchievementService) GetUserAchievements(ctx context.Context, userID int64) ([]*models.UserAchievement, error) {
+	byID := make(map[string]models.Achievement, len(s.rules))
+	for _, r := range s.rules {
+		byID[r.ID] = r
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT achievement_id, room_id, unlocked_at
+		FROM user_achievements
+		WHERE user_id = $1
+		ORDER BY unlocked_at DESC, achievement_id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	achievements := []*models.UserAchievement{}
+	for rows.Next() {
+		var id string
+		var ua models.UserAchievement
+		if err := rows.Scan(&id, &ua.RoomID, &ua.UnlockedAt); err != nil {
+			return nil, err
+		}
+		rule, ok := byID[id]
+		if !ok {
+			continue
+		}
+		ua.Achievement = rule
+		achievements = append(achievements, &ua)
+	}
+	return achievements, rows.Err()
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/yaroslav/elias/internal/models"
+)
+
+func TestParseAchievements(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{"valid", `[{"id": "a", "metric": "games_won", "threshold": 1}]`, nil},
+		{"unknown metric", `[{"id": "a", "metric": "height", "threshold": 1}]`, ErrInvalidAchievement},
+		{"zero threshold", `[{"id": "a", "metric": "games_won"}]`, ErrInvalidAchievement},
+		{"duplicate id", `[{"id": "a", "metric": "games_won", "threshold": 1}, {"id": "a", "metric": "clean_win", "threshold": 1}]`, ErrInvalidAchievement},
+		{"missing id", `[{"metric": "games_won", "threshold": 1}]`, ErrInvalidAchievement},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseAchievements([]byte(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("embedded rules", func(t *testing.T) {
+		rules, err := parseAchievements(achievementsJSON)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(rules) == 0 {
+			t.Error("Expected embedded achievements")
+		}
+	})
+}
+
+func TestEvaluateAchievements(t *testing.T) {
+	rules := []models.Achievement{
+		{ID: "round_10", Metric: MetricRoundGuessed, Threshold: 10},
+		{ID: "round_15", Metric: MetricRoundGuessed, Threshold: 15},
+		{ID: "first_win", Metric: MetricGamesWon, Threshold: 1},
+	}
+
+	tests := []struct {
+		name     string
+		metrics  map[string]int
+		unlocked map[string]bool
+		want     []string
+	}{
+		{"below threshold", map[string]int{MetricRoundGuessed: 9}, nil, nil},
+		{"at threshold", map[string]int{MetricRoundGuessed: 10}, nil, []string{"round_10"}},
+		{"above both thresholds", map[string]int{MetricRoundGuessed: 16}, nil, []string{"round_10", "round_15"}},
+		{"already unlocked", map[string]int{MetricRoundGuessed: 16}, map[string]bool{"round_10": true}, []string{"round_15"}},
+		{"other trigger not evaluated", map[string]int{MetricRoundGuessed: 0}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, a := range evaluateAchievements(rules, tt.metrics, tt.unlocked) {
+				got = append(got, a.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTeamWordStats(t *testing.T) {
+	guess := func(team string, n int) []teamWord {
+		words := make([]teamWord, n)
+		for i := range words {
+			words[i] = teamWord{Team: team, Guessed: true}
+		}
+		return words
+	}
+
+	tests := []struct {
+		name         string
+		words        []teamWord
+		wantSkips    map[string]int
+		wantDeficits map[string]int
+	}{
+		{
+			name:         "no words",
+			wantSkips:    map[string]int{},
+			wantDeficits: map[string]int{},
+		},
+		{
+			name:         "comeback from ten behind",
+			words:        append(guess("t1", 10), append([]teamWord{{Team: "t2"}}, guess("t2", 12)...)...),
+			wantSkips:    map[string]int{"t2": 1},
+			wantDeficits: map[string]int{"t1": 2, "t2": 10},
+		},
+		{
+			name:         "skips only",
+			words:        []teamWord{{Team: "t1"}, {Team: "t1"}, {Team: "t2"}},
+			wantSkips:    map[string]int{"t1": 2, "t2": 1},
+			wantDeficits: map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skips, deficits := teamWordStats(tt.words)
+			if !maps.Equal(skips, tt.wantSkips) {
+				t.Errorf("Expected skips %v, got %v", tt.wantSkips, skips)
+			}
+			if !maps.Equal(deficits, tt.wantDeficits) {
+				t.Errorf("Expected deficits %v, got %v", tt.wantDeficits, deficits)
+			}
+		})
+	}
+}
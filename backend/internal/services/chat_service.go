@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yaroslav/elias/internal/models"
+)
+
+// Rooms started from a Telegram group are linked to the chat through a token
+// the bot puts into the startapp link it posts there. The token is signed with
+// the bot token, so a chat id cannot be made up by a client.
+const (
+	ChatTokenPrefix = "chat_"
+
+	chatTokenSigLength = 16
+	chatStandingsLimit = 50
+)
+
+var (
+	ErrInvalidChatToken = errors.New("invalid chat token")
+	ErrInvalidSeason    = errors.New("invalid season")
+	ErrNotChatMember    = errors.New("not a player of this chat")
+)
+
+type ChatService struct {
+	pool   *pgxpool.Pool
+	secret string
+}
+
+func NewChatService(pool *pgxpool.Pool, secret string) *ChatService {
+	return &ChatService{pool: pool, secret: secret}
+}
+
+// Token returns the startapp token that links rooms to the chat.
+func (s *ChatService) Token(chatID int64) string {
+	return chatToken(s.secret, chatID)
+}
+
+// ParseToken returns the chat a token issued by Token belongs to.
+func (s *ChatService) ParseToken(token string) (int64, error) {
+	return parseChatToken(s.secret, token)
+}
+
+// chatToken is "chat_<chat id>_<signature>", within the 64 characters and the
+// alphabet Telegram allows in start parameters.
+func chatToken(secret string, chatID int64) string {
+	id := strconv.FormatInt(chatID, 10)
+	return ChatTokenPrefix + id + "_" + chatTokenSig(secret, id)
+}
+
+func parseChatToken(secret, token string) (int64, error) {
+	rest, ok := strings.CutPrefix(token, ChatTokenPrefix)
+	if !ok {
+		return 0, ErrInvalidChatToken
+	}
+	id, sig, ok := strings.Cut(rest, "_")
+	if !ok {
+		return 0, ErrInvalidChatToken
+	}
+	chatID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidChatToken
+	}
+	if !hmac.Equal([]byte(sig), []byte(chatTokenSig(secret, id))) {
+		return 0, ErrInvalidChatToken
+	}
+	return chatID, nil
+}
+
+func chatTokenSig(secret, id string) string {
+	mac := hmac.New(sha256.New, []byte("ChatLink"+secret))
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))[:chatTokenSigLength]
+}
+
+// Seasons are calendar quarters in UTC, named like "2024-Q2".
+
+// SeasonOf returns the season t falls into.
+func SeasonOf(t time.Time) string {
+	t = t.UTC()
+	return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+}
+
+// seasonRange returns the start and the end (exclusive) of a season.
+func seasonRange(season string) (time.Time, time.Time, error) {
+	year, quarter, ok := strings.Cut(season, "-Q")
+	if !ok {
+		return time.Time{}, time.Time{}, ErrInvalidSeason
+	}
+	y, err := strconv.Atoi(year)
+	if err != nil || y < 2000 || y > 9999 {
+		return time.Time{}, time.Time{}, ErrInvalidSeason
+	}
+	q, err := strconv.Atoi(quarter)
+	if err != nil || q < 1 || q > 4 {
+		return time.Time{}, time.Time{}, ErrInvalidSeason
+	}
+
+	from := time.Date(y, time.Month(3*(q-1)+1), 1, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(0, 3, 0), nil
+}
+
+// IsMember reports whether the user played a game started from the chat.
+func (s *ChatService) IsMember(ctx context.Context, chatID, userID int64) (bool, error) {
+	var member bool
+	err := s.pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM rooms r JOIN players p ON p.room_id = r.id
+			WHERE r.chat_id = $1 AND p.user_id = $2
+		)
+	`, chatID, userID).Scan(&member)
+	return member, err
+}
+
+// GetLeaderboard ranks the players of the chat over all its finished games.
+func (s *ChatService) GetLeaderboard(ctx context.Context, chatID int64) (*models.ChatLeaderboard, error) {
+	standings, err := s.standings(ctx, chatID, time.Time{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ChatLeaderboard{ChatID: chatID, Standings: standings}, nil
+}
+
+// GetSeason ranks the players of the chat over the finished games of a
+// season. An empty season is the current one.
+func (s *ChatService) GetSeason(ctx context.Context, chatID int64, season string) (*models.ChatLeaderboard, error) {
+	if season == "" {
+		season = SeasonOf(time.Now())
+	}
+	from, to, err := seasonRange(season)
+	if err != nil {
+		return nil, err
+	}
+
+	standings, err := s.standings(ctx, chatID, from, &to)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ChatLeaderboard{ChatID: chatID, Season: season, Standings: standings}, nil
+}
+
+// standings ranks players by wins, then by fewer games for the same wins and
+// then by words guessed as explainer, over the chat's games created from
+// from and before to, if to is set.
+func (s *ChatService) standings(ctx context.Context, chatID int64, from time.Time, to *time.Time) ([]*models.ChatStanding, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT p.user_id, COALESCE(u.username, ''), COALESCE(u.first_name, ''),
+			   COUNT(*) AS games,
+			   COUNT(*) FILTER (WHERE r.winner = p.team) AS wins,
+			   COALESCE(SUM(w.guessed), 0)::int AS guessed
+		FROM rooms r
+		JOIN players p ON p.room_id = r.id AND p.team <> ''
+		LEFT JOIN users u ON u.id = p.user_id
+		LEFT JOIN (
+			SELECT room_id, explainer_id, COUNT(*) FILTER (WHERE guessed) AS guessed
+			FROM round_words
+			WHERE room_id IN (SELECT id FROM rooms WHERE chat_id = $1)
+			GROUP BY room_id, explainer_id
+		) w ON w.room_id = r.id AND w.explainer_id = p.user_id
+		WHERE r.chat_id = $1 AND r.status = $2 AND r.created_at >= $3
+			AND ($4::timestamptz IS NULL OR r.created_at < $4)
+		GROUP BY p.user_id, u.username, u.first_name
+		ORDER BY wins DESC, games, guessed DESC, p.user_id
+		LIMIT $5
+	`, chatID, models.RoomStatusFinished, from, to, chatStandingsLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	standings := []*models.ChatStanding{}
+	for rows.Next() {
+		st := &models.ChatStanding{Rank: len(standings) + 1}
+		if err := rows.Scan(&st.UserID, &st.Username, &st.FirstName, &st.Games, &st.Wins, &st.WordsGuessed); err != nil {
+			return nil, err
+		}
+		standings = append(standings, st)
+	}
+	return standings, rows.Err()
+}
+
+// GetHeadToHead compares two players over the finished games of the chat
+// they both played in.
+func (s *ChatService) GetHeadToHead(ctx context.Context, chatID, userA, userB int64) (*models.HeadToHead, error) {
+	h2h := &models.HeadToHead{ChatID: chatID, UserA: userA, UserB: userB}
+	err := s.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE pa.team <> pb.team AND r.winner = pa.team),
+			   COUNT(*) FILTER (WHERE pa.team <> pb.team AND r.winner = pb.team),
+			   COUNT(*) FILTER (WHERE pa.team <> pb.team AND (r.winner IS NULL OR r.winner NOT IN (pa.team, pb.team))),
+			   COUNT(*) FILTER (WHERE pa.team = pb.team),
+			   COUNT(*) FILTER (WHERE pa.team = pb.team AND r.winner = pa.team)
+		FROM rooms r
+		JOIN players pa ON pa.room_id = r.id AND pa.user_id = $2 AND pa.team <> ''
+		JOIN players pb ON pb.room_id = r.id AND pb.user_id = $3 AND pb.team <> ''
+		WHERE r.chat_id = $1 AND r.status = $4
+	`, chatID, userA, userB, models.RoomStatusFinished).Scan(&h2h.WinsA, &h2h.WinsB, &h2h.Draws, &h2h.GamesTogether, &h2h.WinsTogether)
+	if err != nil {
+		return nil, err
+	}
+	return h2h, nil
+}
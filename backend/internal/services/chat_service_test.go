@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChatToken(t *testing.T) {
+	token := chatToken("secret", -1001234567890)
+	if len(token) > 64 {
+		t.Errorf("Expected token of at most 64 characters, got %d", len(token))
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    int64
+		wantErr error
+	}{
+		{"valid", token, -1001234567890, nil},
+		{"other secret", chatToken("other", -1001234567890), 0, ErrInvalidChatToken},
+		{"other chat", strings.Replace(token, "-1001234567890", "-1001234567891", 1), 0, ErrInvalidChatToken},
+		{"room code", "ABC123", 0, ErrInvalidChatToken},
+		{"no signature", "chat_-1001234567890", 0, ErrInvalidChatToken},
+		{"bad chat id", "chat_abc_0123456789abcdef", 0, ErrInvalidChatToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChatToken("secret", tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected chat %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSeasonOf(t *testing.T) {
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "2024-Q1"},
+		{time.Date(2024, 3, 31, 23, 59, 0, 0, time.UTC), "2024-Q1"},
+		{time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), "2024-Q2"},
+		{time.Date(2024, 12, 31, 12, 0, 0, 0, time.UTC), "2024-Q4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := SeasonOf(tt.t); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSeasonRange(t *testing.T) {
+	tests := []struct {
+		season   string
+		wantFrom time.Time
+		wantTo   time.Time
+		wantErr  error
+	}{
+		{"2024-Q2", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), nil},
+		{"2024-Q4", time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), nil},
+		{"2024-Q5", time.Time{}, time.Time{}, ErrInvalidSeason},
+		{"2024", time.Time{}, time.Time{}, ErrInvalidSeason},
+		{"spring", time.Time{}, time.Time{}, ErrInvalidSeason},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.season, func(t *testing.T) {
+			from, to, err := seasonRange(tt.season)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) {
+				t.Errorf("Expected [%v, %v), got [%v, %v)", tt.wantFrom, tt.wantTo, from, to)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/yaroslav/elias/internal/models"
+)
+
+// Game event types. Every transition of a game is logged, so a finished game
+// can be replayed and its state rebuilt from the log.
+const (
+	GameEventStarted      = "game_started"
+	GameEventWordShown    = "word_shown"
+	GameEventSwipe        = "swipe"
+	GameEventRoundPaused  = "round_paused"
+	GameEventRoundResumed = "round_resumed"
+	GameEventRoundEnded   = "round_ended"
+	GameEventTeamChanged  = "team_changed"
+	GameEventEnded        = "game_ended"
+)
+
+var ErrInvalidGameLog = errors.New("invalid game log")
+
+type GameStartedEvent struct {
+	ExplainerID  int64          `json:"explainer_id"`
+	RoundEndAt   time.Time      `json:"round_end_at"`
+	RoundSeconds int            `json:"round_seconds"`
+	WinningScore int            `json:"winning_score"`
+	TeamScores   map[string]int `json:"team_scores"`
+}
+
+type WordShownEvent struct {
+	Round  int    `json:"round"`
+	WordID int    `json:"word_id"`
+	Word   string `json:"word"`
+}
+
+type SwipeEvent struct {
+	Round       int    `json:"round"`
+	WordID      int    `json:"word_id"`
+	Word        string `json:"word"`
+	Guessed     bool   `json:"guessed"`
+	ExplainerID int64  `json:"explainer_id"`
+	// Team that scored the word; empty if the explainer had none
+	Team string `json:"team,omitempty"`
+}
+
+type RoundPausedEvent struct {
+	ExplainerID int64     `json:"explainer_id"`
+	RemainingMs int64     `json:"remaining_ms"`
+	PausedUntil time.Time `json:"paused_until"`
+}
+
+type RoundResumedEvent struct {
+	RoundEndAt time.Time `json:"round_end_at"`
+}
+
+type RoundEndedEvent struct {
+	Round         int       `json:"round"`
+	NextExplainer int64     `json:"next_explainer"`
+	RoundEndAt    time.Time `json:"round_end_at"`
+}
+
+type TeamChangedEvent struct {
+	UserID int64  `json:"user_id"`
+	Team   string `json:"team"`
+}
+
+type GameEndedEvent struct {
+	// Winner is empty for a draw
+	Winner     string         `json:"winner,omitempty"`
+	TeamScores map[string]int `json:"team_scores"`
+}
+
+// execer is satisfied by both the pool and a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+}
+
+func appendGameEvent(ctx context.Context, db execer, roomID uuid.UUID, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(ctx, `
+		INSERT INTO game_events (room_id, type, payload) VALUES ($1, $2, $3)
+	`, roomID, eventType, data)
+	return err
+}
+
+// noteGameEvent appends an event about a change that only lives in the game
+// state. The change is already saved and stands either way, so a failure is
+// logged rather than reported to the caller.
+func noteGameEvent(ctx context.Context, db execer, roomID uuid.UUID, eventType string, payload interface{}) {
+	if err := appendGameEvent(ctx, db, roomID, eventType, payload); err != nil {
+		log.Printf("Error logging %s event of room %s: %v", eventType, roomID, err)
+	}
+}
+
+// GetGameEvents returns the game log of the room in the order it was written.
+func (s *GameService) GetGameEvents(ctx context.Context, roomID uuid.UUID) ([]*models.GameEvent, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, room_id, type, payload, created_at
+		FROM game_events
+		WHERE room_id = $1
+		ORDER BY id
+	`, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*models.GameEvent{}
+	for rows.Next() {
+		var e models.GameEvent
+		if err := rows.Scan(&e.ID, &e.RoomID, &e.Type, &e.Payload, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+// RebuildGameState replays the room's game log into the state the game had
+// after its last event.
+func (s *GameService) RebuildGameState(ctx context.Context, roomID uuid.UUID) (*GameState, error) {
+	events, err := s.GetGameEvents(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	return ReplayGameState(roomID, events)
+}
+
+// ReplayGameState applies events in order and returns the resulting state, or
+// nil if no game was started. A later game_started starts over.
+func ReplayGameState(roomID uuid.UUID, events []*models.GameEvent) (*GameState, error) {
+	var state *GameState
+	for _, e := range events {
+		// Team changes happen in the lobby and are kept for the replay only
+		if e.Type == GameEventTeamChanged {
+			continue
+		}
+		if state == nil && e.Type != GameEventStarted {
+			return nil, fmt.Errorf("%w: %s before %s", ErrInvalidGameLog, e.Type, GameEventStarted)
+		}
+
+		switch e.Type {
+		case GameEventStarted:
+			var p GameStartedEvent
+			if err := decodeGameEvent(e, &p); err != nil {
+				return nil, err
+			}
+			teamScores := make(map[string]int, len(p.TeamScores))
+			for team, score := range p.TeamScores {
+				teamScores[team] = score
+			}
+			state = &GameState{
+				RoomID:           roomID,
+				Status:           string(models.RoomStatusPlaying),
+				CurrentRound:     1,
+				CurrentExplainer: p.ExplainerID,
+				RoundEndAt:       p.RoundEndAt,
+				TeamScores:       teamScores,
+				RoundDuration:    time.Duration(p.RoundSeconds) * time.Second,
+				WinningScore:     p.WinningScore,
+			}
+
+		case GameEventWordShown:
+			var p WordShownEvent
+			if err := decodeGameEvent(e, &p); err != nil {
+				return nil, err
+			}
+			state.CurrentWord = &WordState{ID: p.WordID, Word: p.Word}
+
+		case GameEventSwipe:
+			var p SwipeEvent
+			if err := decodeGameEvent(e, &p); err != nil {
+				return nil, err
+			}
+			state.CurrentWord = nil
+			state.WordsThisRound++
+			if _, exists := state.TeamScores[p.Team]; p.Guessed && exists {
+				state.TeamScores[p.Team]++
+			}
+
+		case GameEventRoundPaused:
+			var p RoundPausedEvent
+			if err := decodeGameEvent(e, &p); err != nil {
+				return nil, err
+			}
+			state.Paused = true
+			state.Remaining = time.Duration(p.RemainingMs) * time.Millisecond
+			state.PausedUntil = p.PausedUntil
+
+		case GameEventRoundResumed:
+			var p RoundResumedEvent
+			if err := decodeGameEvent(e, &p); err != nil {
+				return nil, err
+			}
+			state.Paused = false
+			state.RoundEndAt = p.RoundEndAt
+			state.Remaining = 0
+			state.PausedUntil = time.Time{}
+
+		case GameEventRoundEnded:
+			var p RoundEndedEvent
+			if err := decodeGameEvent(e, &p); err != nil {
+				return nil, err
+			}
+			state.CurrentRound = p.Round + 1
+			state.CurrentExplainer = p.NextExplainer
+			state.RoundEndAt = p.RoundEndAt
+			state.WordsThisRound = 0
+			state.CurrentWord = nil
+			state.Paused = false
+			state.Remaining = 0
+			state.PausedUntil = time.Time{}
+
+		case GameEventEnded:
+			state.Status = string(models.RoomStatusFinished)
+
+		default:
+			return nil, fmt.Errorf("%w: unknown event %s", ErrInvalidGameLog, e.Type)
+		}
+	}
+	return state, nil
+}
+
+func decodeGameEvent(e *models.GameEvent, v interface{}) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("%w: event %d (%s): %v", ErrInvalidGameLog, e.ID, e.Type, err)
+	}
+	return nil
+}
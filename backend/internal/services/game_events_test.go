@@ -0,0 +1,126 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yaroslav/elias/internal/models"
+)
+
+func gameEvent(t *testing.T, eventType string, payload interface{}) *models.GameEvent {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return &models.GameEvent{Type: eventType, Payload: data}
+}
+
+func TestReplayGameState(t *testing.T) {
+	roomID := uuid.New()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	pausedUntil := start.Add(ExplainerReconnectGrace)
+	resumedEnd := start.Add(2 * time.Minute)
+	round2End := start.Add(3 * time.Minute)
+
+	events := []*models.GameEvent{
+		gameEvent(t, GameEventTeamChanged, TeamChangedEvent{UserID: 1, Team: "a"}),
+		gameEvent(t, GameEventStarted, GameStartedEvent{
+			ExplainerID:  1,
+			RoundEndAt:   start.Add(time.Minute),
+			RoundSeconds: 60,
+			WinningScore: 10,
+			TeamScores:   map[string]int{"a": 0, "b": 0},
+		}),
+		gameEvent(t, GameEventWordShown, WordShownEvent{Round: 1, WordID: 5, Word: "cat"}),
+		gameEvent(t, GameEventSwipe, SwipeEvent{Round: 1, WordID: 5, Word: "cat", Guessed: true, ExplainerID: 1, Team: "a"}),
+		gameEvent(t, GameEventWordShown, WordShownEvent{Round: 1, WordID: 6, Word: "dog"}),
+		gameEvent(t, GameEventSwipe, SwipeEvent{Round: 1, WordID: 6, Word: "dog", ExplainerID: 1, Team: "a"}),
+		gameEvent(t, GameEventWordShown, WordShownEvent{Round: 1, WordID: 7, Word: "sun"}),
+		gameEvent(t, GameEventRoundPaused, RoundPausedEvent{ExplainerID: 1, RemainingMs: 20000, PausedUntil: pausedUntil}),
+		gameEvent(t, GameEventRoundResumed, RoundResumedEvent{RoundEndAt: resumedEnd}),
+		gameEvent(t, GameEventSwipe, SwipeEvent{Round: 1, WordID: 7, Word: "sun", Guessed: true, ExplainerID: 1, Team: "a"}),
+		gameEvent(t, GameEventRoundEnded, RoundEndedEvent{Round: 1, NextExplainer: 2, RoundEndAt: round2End}),
+		gameEvent(t, GameEventWordShown, WordShownEvent{Round: 2, WordID: 8, Word: "sea"}),
+	}
+
+	state, err := ReplayGameState(roomID, events)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if state.RoomID != roomID {
+		t.Errorf("Expected room %s, got %s", roomID, state.RoomID)
+	}
+	if state.Status != string(models.RoomStatusPlaying) {
+		t.Errorf("Expected status playing, got %s", state.Status)
+	}
+	if state.CurrentRound != 2 || state.CurrentExplainer != 2 {
+		t.Errorf("Expected round 2 explained by 2, got round %d by %d", state.CurrentRound, state.CurrentExplainer)
+	}
+	if !state.RoundEndAt.Equal(round2End) {
+		t.Errorf("Expected round end %v, got %v", round2End, state.RoundEndAt)
+	}
+	if state.CurrentWord == nil || state.CurrentWord.ID != 8 {
+		t.Errorf("Expected current word 8, got %v", state.CurrentWord)
+	}
+	if state.WordsThisRound != 0 || state.Paused {
+		t.Errorf("Expected fresh round, got %d words, paused %v", state.WordsThisRound, state.Paused)
+	}
+	if want := map[string]int{"a": 2, "b": 0}; !maps.Equal(state.TeamScores, want) {
+		t.Errorf("Expected scores %v, got %v", want, state.TeamScores)
+	}
+	if state.roundDuration() != time.Minute || state.winningScore() != 10 {
+		t.Errorf("Expected rules 1m/10, got %v/%d", state.roundDuration(), state.winningScore())
+	}
+
+	// Paused in the middle of the log
+	state, err = ReplayGameState(roomID, events[:8])
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !state.Paused || state.Remaining != 20*time.Second || !state.PausedUntil.Equal(pausedUntil) {
+		t.Errorf("Expected paused with 20s left until %v, got %v %v %v", pausedUntil, state.Paused, state.Remaining, state.PausedUntil)
+	}
+
+	// Finished
+	ended := append(events, gameEvent(t, GameEventEnded, GameEndedEvent{TeamScores: map[string]int{"a": 2, "b": 0}}))
+	state, err = ReplayGameState(roomID, ended)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if state.Status != string(models.RoomStatusFinished) {
+		t.Errorf("Expected status finished, got %s", state.Status)
+	}
+}
+
+func TestReplayGameStateInvalid(t *testing.T) {
+	started := gameEvent(t, GameEventStarted, GameStartedEvent{ExplainerID: 1, TeamScores: map[string]int{"a": 0}})
+
+	tests := []struct {
+		name   string
+		events []*models.GameEvent
+	}{
+		{"event before start", []*models.GameEvent{gameEvent(t, GameEventWordShown, WordShownEvent{WordID: 1})}},
+		{"unknown event", []*models.GameEvent{started, gameEvent(t, "teleport", struct{}{})}},
+		{"bad payload", []*models.GameEvent{started, {Type: GameEventSwipe, Payload: json.RawMessage(`{"guessed":"yes"}`)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReplayGameState(uuid.New(), tt.events)
+			if !errors.Is(err, ErrInvalidGameLog) {
+				t.Errorf("Expected %v, got %v", ErrInvalidGameLog, err)
+			}
+		})
+	}
+
+	state, err := ReplayGameState(uuid.New(), []*models.GameEvent{gameEvent(t, GameEventTeamChanged, TeamChangedEvent{UserID: 1, Team: "a"})})
+	if err != nil || state != nil {
+		t.Errorf("Expected no game without game_started, got %v (%v)", state, err)
+	}
+}
@@ -3,12 +3,17 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
 	"github.com/yaroslav/elias/internal/models"
+	"github.com/yaroslav/elias/pkg/protocol"
 )
 
 const (
@@ -17,6 +22,55 @@ const (
 	WinningScore     = 20
 )
 
+// roundDeadlinesKey is a sorted set of rooms with a running round, scored by
+// the round end time in unix milliseconds. Any instance can pick up a room from
+// it when the timer owner goes away.
+const roundDeadlinesKey = "round_deadlines"
+
+const roundEndClaimTTL = 30 * time.Second
+
+const gameStateTTL = 24 * time.Hour
+
+// stateUpdateAttempts is how many times a game state update is tried before
+// giving up with ErrStateConflict.
+const stateUpdateAttempts = 5
+
+// saveStateScript writes the game state only if the stored one still has the
+// version the caller read. A missing state counts as version 0.
+var saveStateScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+local version = 0
+if current then
+	version = cjson.decode(current).version or 0
+end
+if version ~= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+return 1
+`)
+
+// ExplainerReconnectGrace is how long a round stays paused for a disconnected
+// explainer before the host may skip them.
+const ExplainerReconnectGrace = 30 * time.Second
+
+var (
+	// ErrRoundAlreadyEnded is returned by NextRound when the round it was
+	// asked to end is no longer the current one.
+	ErrRoundAlreadyEnded = errors.New("round already ended")
+	ErrRoundNotPaused    = errors.New("round is not paused")
+	ErrRoundPaused       = errors.New("round is paused")
+	ErrSkipTooEarly      = errors.New("explainer may still reconnect")
+	ErrNotExplainer      = errors.New("only the explainer can swipe")
+	ErrNoCurrentWord     = errors.New("no word is being explained")
+	// ErrStateConflict is returned when the game state kept changing under
+	// an update until it ran out of attempts.
+	ErrStateConflict = errors.New("game state changed concurrently")
+
+	// errUnchanged tells updateGameState that there is nothing to save
+	errUnchanged = errors.New("game state unchanged")
+)
+
 // GetTeamNames returns team names for given number of teams (A, B, C, D, E)
 func GetTeamNames(numTeams int) []string {
 	allTeams := []string{"A", "B", "C", "D", "E"}
@@ -29,7 +83,29 @@ func GetTeamNames(numTeams int) []string {
 	return allTeams[:numTeams]
 }
 
+// CanSeeWord reports whether userID may see the word being explained: the
+// explainer and players of other teams do, the explainer's teammates, who are
+// guessing, do not.
+func CanSeeWord(players []*models.Player, explainerID, userID int64) bool {
+	if userID == explainerID {
+		return true
+	}
+
+	var explainerTeam, userTeam string
+	for _, p := range players {
+		switch p.UserID {
+		case explainerID:
+			explainerTeam = p.Team
+		case userID:
+			userTeam = p.Team
+		}
+	}
+	return explainerTeam == "" || userTeam != explainerTeam
+}
+
 type GameState struct {
+	// Version is bumped on every save and guards against lost updates
+	Version          int64          `json:"version"`
 	RoomID           uuid.UUID      `json:"room_id"`
 	Status           string         `json:"status"`
 	CurrentRound     int            `json:"current_round"`
@@ -38,6 +114,40 @@ type GameState struct {
 	RoundEndAt       time.Time      `json:"round_end_at"`
 	WordsThisRound   int            `json:"words_this_round"`
 	TeamScores       map[string]int `json:"team_scores"`
+
+	// Rules of the room when the game started; zero in states saved before
+	// rules were configurable
+	RoundDuration time.Duration `json:"round_duration,omitempty"`
+	WinningScore  int           `json:"winning_score,omitempty"`
+
+	// While the explainer is disconnected the round is paused: Remaining is
+	// the round time left and PausedUntil ends the reconnect grace window
+	Paused      bool          `json:"paused,omitempty"`
+	Remaining   time.Duration `json:"remaining,omitempty"`
+	PausedUntil time.Time     `json:"paused_until,omitempty"`
+}
+
+// deadline is when the timer owner has to act next: the end of the round, or
+// of the reconnect grace window when paused.
+func (s *GameState) deadline() time.Time {
+	if s.Paused {
+		return s.PausedUntil
+	}
+	return s.RoundEndAt
+}
+
+func (s *GameState) roundDuration() time.Duration {
+	if s.RoundDuration > 0 {
+		return s.RoundDuration
+	}
+	return RoundDuration
+}
+
+func (s *GameState) winningScore() int {
+	if s.WinningScore > 0 {
+		return s.WinningScore
+	}
+	return WinningScore
 }
 
 type WordState struct {
@@ -46,12 +156,13 @@ type WordState struct {
 }
 
 type GameService struct {
-	pool *pgxpool.Pool
-	rdb  *redis.Client
+	pool     *pgxpool.Pool
+	rdb      *redis.Client
+	presence *PresenceService
 }
 
-func NewGameService(pool *pgxpool.Pool, rdb *redis.Client) *GameService {
-	return &GameService{pool: pool, rdb: rdb}
+func NewGameService(pool *pgxpool.Pool, rdb *redis.Client, presence *PresenceService) *GameService {
+	return &GameService{pool: pool, rdb: rdb, presence: presence}
 }
 
 func (s *GameService) GetGameState(ctx context.Context, roomID uuid.UUID) (*GameState, error) {
@@ -71,34 +182,91 @@ func (s *GameService) GetGameState(ctx context.Context, roomID uuid.UUID) (*Game
 	return &state, nil
 }
 
+// SaveGameState stores the state if the stored one is still at state.Version
+// and bumps the version. It returns ErrStateConflict when someone else saved
+// the state in between.
 func (s *GameService) SaveGameState(ctx context.Context, state *GameState) error {
 	key := "game:" + state.RoomID.String()
-	data, err := json.Marshal(state)
+	next := *state
+	next.Version++
+	data, err := json.Marshal(&next)
 	if err != nil {
 		return err
 	}
-	return s.rdb.Set(ctx, key, data, 24*time.Hour).Err()
+
+	saved, err := saveStateScript.Run(ctx, s.rdb, []string{key}, state.Version, data, gameStateTTL.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if saved == 0 {
+		return ErrStateConflict
+	}
+	state.Version = next.Version
+	return nil
 }
 
-func (s *GameService) StartGame(ctx context.Context, roomID uuid.UUID, players []*models.Player) (*GameState, error) {
-	// Get room to know team_names
-	var teamNamesJSON []byte
-	err := s.pool.QueryRow(ctx, "SELECT team_names FROM rooms WHERE id = $1", roomID).Scan(&teamNamesJSON)
+// updateGameState loads the room's game state, applies mutate and saves it.
+// When someone else saved the state in between, it is reloaded and mutate
+// runs again, so mutate must not have side effects outside the state. mutate
+// may return errUnchanged to skip saving.
+func (s *GameService) updateGameState(ctx context.Context, roomID uuid.UUID, mutate func(state *GameState) error) (*GameState, error) {
+	var state *GameState
+	err := retryOnConflict(stateUpdateAttempts, func() error {
+		var err error
+		state, err = s.GetGameState(ctx, roomID)
+		if err != nil {
+			return err
+		}
+		if state == nil {
+			return ErrRoomNotFound
+		}
+
+		if err := mutate(state); err != nil {
+			if errors.Is(err, errUnchanged) {
+				return nil
+			}
+			return err
+		}
+		return s.SaveGameState(ctx, state)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// retryOnConflict runs fn until it returns something other than
+// ErrStateConflict, at most attempts times.
+func retryOnConflict(attempts int, fn func() error) error {
+	for i := 0; i < attempts; i++ {
+		if err := fn(); !errors.Is(err, ErrStateConflict) {
+			return err
+		}
+	}
+	return ErrStateConflict
+}
+
+// StartGame starts the game with firstWord as the first word of the round.
+func (s *GameService) StartGame(ctx context.Context, roomID uuid.UUID, players []*models.Player, firstWord *models.Word) (*GameState, error) {
+	// Get room to know its teams and rules
+	var teamsJSON []byte
+	var roundSeconds, winningScore int
+	err := s.pool.QueryRow(ctx, "SELECT teams, round_seconds, winning_score FROM rooms WHERE id = $1", roomID).Scan(&teamsJSON, &roundSeconds, &winningScore)
 	if err != nil {
 		return nil, err
 	}
 
-	var teamNames []string
-	if len(teamNamesJSON) > 0 {
-		if err := json.Unmarshal(teamNamesJSON, &teamNames); err != nil {
+	var teams []models.Team
+	if len(teamsJSON) > 0 {
+		if err := json.Unmarshal(teamsJSON, &teams); err != nil {
 			return nil, err
 		}
 	}
 
-	// Initialize team scores
+	// Initialize team scores, keyed by team id
 	teamScores := make(map[string]int)
-	for _, teamName := range teamNames {
-		teamScores[teamName] = 0
+	for _, team := range teams {
+		teamScores[team.ID] = 0
 	}
 
 	// Calculate initial scores from players
@@ -127,17 +295,37 @@ func (s *GameService) StartGame(ctx context.Context, roomID uuid.UUID, players [
 		Status:           string(models.RoomStatusPlaying),
 		CurrentRound:     1,
 		CurrentExplainer: firstExplainer,
-		RoundEndAt:       time.Now().Add(RoundDuration),
 		WordsThisRound:   0,
 		TeamScores:       teamScores,
+		RoundDuration:    time.Duration(roundSeconds) * time.Second,
+		WinningScore:     winningScore,
+		CurrentWord:      &WordState{ID: firstWord.ID, Word: firstWord.Word},
 	}
+	state.RoundEndAt = time.Now().Add(state.roundDuration())
 
+	// Replace the state of an earlier game in the room, if any
+	prev, err := s.GetGameState(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil {
+		state.Version = prev.Version
+	}
 	if err := s.SaveGameState(ctx, state); err != nil {
 		return nil, err
 	}
+	if err := s.scheduleRoundEnd(ctx, roomID, state.RoundEndAt); err != nil {
+		return nil, err
+	}
 
-	// Update room status in DB
-	_, err = s.pool.Exec(ctx, `
+	// Update room status in DB, with the game log in the same transaction
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
 		UPDATE rooms
 		SET status = $1, current_round = $2, current_explainer_id = $3, round_end_at = $4
 		WHERE id = $5
@@ -146,25 +334,56 @@ func (s *GameService) StartGame(ctx context.Context, roomID uuid.UUID, players [
 		return nil, err
 	}
 
-	return state, nil
+	err = appendGameEvent(ctx, tx, roomID, GameEventStarted, GameStartedEvent{
+		ExplainerID:  state.CurrentExplainer,
+		RoundEndAt:   state.RoundEndAt,
+		RoundSeconds: int(state.roundDuration() / time.Second),
+		WinningScore: state.winningScore(),
+		TeamScores:   state.TeamScores,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = appendGameEvent(ctx, tx, roomID, GameEventWordShown, WordShownEvent{
+		Round:  state.CurrentRound,
+		WordID: firstWord.ID,
+		Word:   firstWord.Word,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return state, tx.Commit(ctx)
 }
 
 func (s *GameService) SetCurrentWord(ctx context.Context, roomID uuid.UUID, word *models.Word) error {
-	state, err := s.GetGameState(ctx, roomID)
+	state, err := s.updateGameState(ctx, roomID, func(state *GameState) error {
+		state.CurrentWord = &WordState{
+			ID:   word.ID,
+			Word: word.Word,
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	if state == nil {
-		return ErrRoomNotFound
-	}
 
-	state.CurrentWord = &WordState{
-		ID:   word.ID,
-		Word: word.Word,
-	}
-	return s.SaveGameState(ctx, state)
+	noteGameEvent(ctx, s.pool, roomID, GameEventWordShown, WordShownEvent{
+		Round:  state.CurrentRound,
+		WordID: word.ID,
+		Word:   word.Word,
+	})
+	return nil
 }
 
+// ProcessSwipe scores the current word. The result, the player's score and
+// the word_result and score_update events are written in one Postgres
+// transaction, which only commits once the game state has taken the word off:
+// a swipe that loses the race to the end of the round, or comes after its
+// deadline, writes nothing. A swipe that was already recorded (a concurrent
+// swipe of the same word won) is not counted again and succeeds with the
+// stored result. Should the commit fail after the state took the word off,
+// the word is lost and the round's end reconciles the team scores.
 func (s *GameService) ProcessSwipe(ctx context.Context, roomID uuid.UUID, userID int64, action string) (bool, *models.Word, error) {
 	state, err := s.GetGameState(ctx, roomID)
 	if err != nil {
@@ -176,125 +395,382 @@ func (s *GameService) ProcessSwipe(ctx context.Context, roomID uuid.UUID, userID
 
 	// Only explainer can swipe
 	if state.CurrentExplainer != userID {
-		return false, nil, nil
+		return false, nil, ErrNotExplainer
+	}
+	word := state.CurrentWord
+	if word == nil {
+		return false, nil, ErrNoCurrentWord
+	}
+	if err := state.canTakeWord(word.ID, time.Now()); err != nil {
+		return false, nil, err
 	}
 
-	if state.CurrentWord == nil {
-		return false, nil, nil
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, nil, err
 	}
+	defer tx.Rollback(ctx)
 
-	guessed := action == "up"
-	word := state.CurrentWord
+	guessed, teamScores, recorded, err := s.recordSwipe(ctx, tx, state, userID, action == "up")
+	if err != nil {
+		return false, nil, err
+	}
 
-	// Record result
-	_, err = s.pool.Exec(ctx, `
-		INSERT INTO round_words (room_id, word_id, round_num, guessed)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT DO NOTHING
-	`, roomID, word.ID, state.CurrentRound, guessed)
+	// Of two swipes racing for the same word only the one that still finds
+	// it on the state counts
+	_, err = s.updateGameState(ctx, roomID, func(state *GameState) error {
+		if err := state.canTakeWord(word.ID, time.Now()); err != nil {
+			return err
+		}
+		state.CurrentWord = nil
+		state.WordsThisRound++
+		state.TeamScores = teamScores
+		return nil
+	})
 	if err != nil {
+		if recorded && errors.Is(err, ErrNoCurrentWord) {
+			return guessed, &models.Word{ID: word.ID, Word: word.Word}, nil
+		}
+		return false, nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
 		return false, nil, err
 	}
 
+	return guessed, &models.Word{ID: word.ID, Word: word.Word}, nil
+}
+
+// canTakeWord checks that the word wordID is still being explained and its
+// round is still running at now.
+func (s *GameState) canTakeWord(wordID int, now time.Time) error {
+	if s.Paused {
+		return ErrRoundPaused
+	}
+	if s.Status != string(models.RoomStatusPlaying) || s.CurrentWord == nil || s.CurrentWord.ID != wordID {
+		return ErrNoCurrentWord
+	}
+	if !now.Before(s.RoundEndAt) {
+		return ErrNoCurrentWord
+	}
+	return nil
+}
+
+// recordSwipe writes the result of the current word in tx and returns it with
+// the team scores that follow from it. If the word was already recorded, the
+// stored result is returned, recorded is true and nothing is written.
+func (s *GameService) recordSwipe(ctx context.Context, tx pgx.Tx, state *GameState, userID int64, guessed bool) (_ bool, _ map[string]int, recorded bool, err error) {
+	var team string
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(team, '') FROM players WHERE room_id = $1 AND user_id = $2
+	`, state.RoomID, userID).Scan(&team)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return false, nil, false, err
+	}
+	var scoredTeam *string
+	if _, exists := state.TeamScores[team]; team != "" && exists {
+		scoredTeam = &team
+	}
+
+	word := state.CurrentWord
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO round_words (room_id, word_id, round_num, guessed, explainer_id, team)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (room_id, word_id) DO NOTHING
+	`, state.RoomID, word.ID, state.CurrentRound, guessed, userID, scoredTeam)
+	if err != nil {
+		return false, nil, false, err
+	}
+	if tag.RowsAffected() == 0 {
+		err := tx.QueryRow(ctx, `
+			SELECT guessed FROM round_words WHERE room_id = $1 AND word_id = $2
+		`, state.RoomID, word.ID).Scan(&guessed)
+		if err != nil {
+			return false, nil, false, err
+		}
+		scores, err := countTeamScores(ctx, tx, state.RoomID, state.TeamScores)
+		return guessed, scores, true, err
+	}
+
 	// Update player score if guessed
 	if guessed {
-		_, err = s.pool.Exec(ctx, `
+		_, err = tx.Exec(ctx, `
 			UPDATE players SET score = score + 1
 			WHERE room_id = $1 AND user_id = $2
-		`, roomID, userID)
+		`, state.RoomID, userID)
 		if err != nil {
-			return false, nil, err
+			return false, nil, false, err
 		}
+	}
 
-		// Update team score
-		var team string
-		err = s.pool.QueryRow(ctx, `
-			SELECT team FROM players WHERE room_id = $1 AND user_id = $2
-		`, roomID, userID).Scan(&team)
-		if err == nil && team != "" {
-			if _, exists := state.TeamScores[team]; exists {
-				state.TeamScores[team]++
-			}
-		}
+	scores, err := countTeamScores(ctx, tx, state.RoomID, state.TeamScores)
+	if err != nil {
+		return false, nil, false, err
 	}
 
-	state.WordsThisRound++
-	state.CurrentWord = nil
+	swipe := SwipeEvent{
+		Round:       state.CurrentRound,
+		WordID:      word.ID,
+		Word:        word.Word,
+		Guessed:     guessed,
+		ExplainerID: userID,
+	}
+	if scoredTeam != nil {
+		swipe.Team = *scoredTeam
+	}
+	if err := appendGameEvent(ctx, tx, state.RoomID, GameEventSwipe, swipe); err != nil {
+		return false, nil, false, err
+	}
 
-	if err := s.SaveGameState(ctx, state); err != nil {
-		return false, nil, err
+	err = insertOutbox(ctx, tx, state.RoomID, protocol.MsgTypeWordResult, protocol.WordResultPayload{
+		WordID:  word.ID,
+		Word:    word.Word,
+		Guessed: guessed,
+	})
+	if err != nil {
+		return false, nil, false, err
+	}
+	err = insertOutbox(ctx, tx, state.RoomID, protocol.MsgTypeScoreUpdate, protocol.ScoreUpdatePayload{
+		TeamScores: scores,
+	})
+	if err != nil {
+		return false, nil, false, err
 	}
 
-	return guessed, &models.Word{ID: word.ID, Word: word.Word}, nil
+	return guessed, scores, false, nil
 }
 
-func (s *GameService) NextRound(ctx context.Context, roomID uuid.UUID, players []*models.Player) (*GameState, error) {
-	state, err := s.GetGameState(ctx, roomID)
+// querier is satisfied by both the pool and a transaction.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+// countTeamScores counts the guessed words of each team in round_words. Only
+// the teams in teams are counted.
+func countTeamScores(ctx context.Context, q querier, roomID uuid.UUID, teams map[string]int) (map[string]int, error) {
+	rows, err := q.Query(ctx, `
+		SELECT team, COUNT(*) FROM round_words
+		WHERE room_id = $1 AND guessed AND team IS NOT NULL
+		GROUP BY team
+	`, roomID)
 	if err != nil {
 		return nil, err
 	}
-	if state == nil {
-		return nil, ErrRoomNotFound
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var team string
+		var count int
+		if err := rows.Scan(&team, &count); err != nil {
+			return nil, err
+		}
+		counts[team] = count
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return teamScoresFromCounts(teams, counts), nil
+}
 
-	// Find next explainer (rotate through players)
-	var nextExplainer int64
-	foundCurrent := false
-	for _, p := range players {
-		if foundCurrent && p.Team != "" {
-			nextExplainer = p.UserID
-			break
+// teamScoresFromCounts returns the score of every team in teams from the
+// guessed word counts, ignoring counts of teams that are not playing.
+func teamScoresFromCounts(teams map[string]int, counts map[string]int) map[string]int {
+	scores := make(map[string]int, len(teams))
+	for team := range teams {
+		scores[team] = counts[team]
+	}
+	return scores
+}
+
+// ReconcileTeamScores recomputes the team scores from round_words and writes
+// them to the game state when the two disagree, e.g. after a state update
+// failed behind a committed swipe. It returns whether the state was fixed.
+func (s *GameService) ReconcileTeamScores(ctx context.Context, roomID uuid.UUID) (bool, error) {
+	fixed := false
+	_, err := s.updateGameState(ctx, roomID, func(state *GameState) error {
+		fixed = false
+		scores, err := countTeamScores(ctx, s.pool, roomID, state.TeamScores)
+		if err != nil {
+			return err
 		}
-		if p.UserID == state.CurrentExplainer {
-			foundCurrent = true
+		if maps.Equal(scores, state.TeamScores) {
+			return errUnchanged
 		}
+		state.TeamScores = scores
+		fixed = true
+		return nil
+	})
+	if errors.Is(err, ErrRoomNotFound) {
+		return false, nil
 	}
-	// Wrap around if needed
-	if nextExplainer == 0 {
-		for _, p := range players {
-			if p.Team != "" {
-				nextExplainer = p.UserID
-				break
-			}
-		}
+	return fixed, err
+}
+
+// NextRound ends round endingRound and starts the next one. It returns
+// ErrRoundAlreadyEnded if endingRound is not the current round, so a round
+// can only be ended once.
+func (s *GameService) NextRound(ctx context.Context, roomID uuid.UUID, endingRound int, players []*models.Player) (*GameState, error) {
+	userIDs := make([]int64, len(players))
+	for i, p := range players {
+		userIDs[i] = p.UserID
+	}
+	available, err := s.presence.Available(ctx, roomID, userIDs)
+	if err != nil {
+		return nil, err
 	}
 
-	state.CurrentRound++
-	state.CurrentExplainer = nextExplainer
-	state.RoundEndAt = time.Now().Add(RoundDuration)
-	state.WordsThisRound = 0
-	state.CurrentWord = nil
+	state, err := s.updateGameState(ctx, roomID, func(state *GameState) error {
+		if state.CurrentRound != endingRound || state.Status != string(models.RoomStatusPlaying) {
+			return ErrRoundAlreadyEnded
+		}
 
-	if err := s.SaveGameState(ctx, state); err != nil {
+		state.CurrentRound++
+		state.CurrentExplainer = nextExplainer(players, state.CurrentExplainer, available)
+		state.RoundEndAt = time.Now().Add(state.roundDuration())
+		state.WordsThisRound = 0
+		state.CurrentWord = nil
+		state.Paused = false
+		state.Remaining = 0
+		state.PausedUntil = time.Time{}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.scheduleRoundEnd(ctx, roomID, state.RoundEndAt); err != nil {
+		return nil, err
+	}
+
+	// Update DB, with the game log in the same transaction
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback(ctx)
 
-	// Update DB
-	_, err = s.pool.Exec(ctx, `
+	_, err = tx.Exec(ctx, `
 		UPDATE rooms
 		SET current_round = $1, current_explainer_id = $2, round_end_at = $3
 		WHERE id = $4
 	`, state.CurrentRound, state.CurrentExplainer, state.RoundEndAt, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = appendGameEvent(ctx, tx, roomID, GameEventRoundEnded, RoundEndedEvent{
+		Round:         endingRound,
+		NextExplainer: state.CurrentExplainer,
+		RoundEndAt:    state.RoundEndAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return state, tx.Commit(ctx)
+}
 
-	return state, err
+// nextExplainer picks the next player with a team after current, wrapping
+// around. Players that are not available are skipped unless nobody is.
+func nextExplainer(players []*models.Player, current int64, available map[int64]bool) int64 {
+	start := 0
+	for i, p := range players {
+		if p.UserID == current {
+			start = i + 1
+			break
+		}
+	}
+
+	var fallback int64
+	for i := range players {
+		p := players[(start+i)%len(players)]
+		if p.Team == "" {
+			continue
+		}
+		if available[p.UserID] {
+			return p.UserID
+		}
+		if fallback == 0 {
+			fallback = p.UserID
+		}
+	}
+	return fallback
 }
 
 func (s *GameService) EndGame(ctx context.Context, roomID uuid.UUID) error {
-	state, err := s.GetGameState(ctx, roomID)
+	ended := false
+	state, err := s.updateGameState(ctx, roomID, func(state *GameState) error {
+		ended = false
+		if state.Status == string(models.RoomStatusFinished) {
+			return errUnchanged
+		}
+		state.Status = string(models.RoomStatusFinished)
+		ended = true
+		return nil
+	})
+	// A game whose state is gone is still finished in Postgres
+	if err != nil && !errors.Is(err, ErrRoomNotFound) {
+		return err
+	}
+	if err := s.rdb.ZRem(ctx, roundDeadlinesKey, roomID.String()).Err(); err != nil {
+		return err
+	}
+
+	var winner *string
+	if state != nil {
+		if team, ok := leader(state.TeamScores); ok {
+			winner = &team
+		}
+	}
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var lang string
+	err = tx.QueryRow(ctx, `
+		UPDATE rooms SET status = $1, winner = $2 WHERE id = $3
+		RETURNING lang
+	`, models.RoomStatusFinished, winner, roomID).Scan(&lang)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRoomNotFound
+		}
 		return err
 	}
+	// Rating is idempotent, so a retry after a failed commit still rates
 	if state != nil {
-		state.Status = string(models.RoomStatusFinished)
-		if err := s.SaveGameState(ctx, state); err != nil {
+		if err := rateGame(ctx, tx, roomID, lang, state.TeamScores); err != nil {
 			return err
 		}
 	}
+	if ended {
+		event := GameEndedEvent{TeamScores: state.TeamScores}
+		if winner != nil {
+			event.Winner = *winner
+		}
+		if err := appendGameEvent(ctx, tx, roomID, GameEventEnded, event); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
 
-	_, err = s.pool.Exec(ctx, `
-		UPDATE rooms SET status = $1 WHERE id = $2
-	`, models.RoomStatusFinished, roomID)
-	return err
+// leader returns the team with the highest score. ok is false when no team
+// is strictly ahead of the others.
+func leader(teamScores map[string]int) (team string, ok bool) {
+	best := 0
+	for t, score := range teamScores {
+		switch {
+		case team == "" || score > best:
+			team, best, ok = t, score, true
+		case score == best:
+			ok = false
+		}
+	}
+	if !ok {
+		return "", false
+	}
+	return team, true
 }
 
 func (s *GameService) CheckWinCondition(ctx context.Context, roomID uuid.UUID) (bool, string, error) {
@@ -308,7 +784,7 @@ func (s *GameService) CheckWinCondition(ctx context.Context, roomID uuid.UUID) (
 
 	// Check if any team reached winning score
 	for team, score := range state.TeamScores {
-		if score >= WinningScore {
+		if score >= state.winningScore() {
 			return true, team, nil
 		}
 	}
@@ -325,3 +801,233 @@ func (s *GameService) GetTeamScores(ctx context.Context, roomID uuid.UUID) (map[
 	}
 	return state.TeamScores, nil
 }
+
+func (s *GameService) scheduleRoundEnd(ctx context.Context, roomID uuid.UUID, endAt time.Time) error {
+	return s.rdb.ZAdd(ctx, roundDeadlinesKey, redis.Z{
+		Score:  float64(endAt.UnixMilli()),
+		Member: roomID.String(),
+	}).Err()
+}
+
+// RoundDeadline returns the scheduled end of the running round. ok is false
+// when no round is running in the room.
+func (s *GameService) RoundDeadline(ctx context.Context, roomID uuid.UUID) (deadline time.Time, ok bool, err error) {
+	score, err := s.rdb.ZScore(ctx, roundDeadlinesKey, roomID.String()).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return time.Time{}, false, nil
+		}
+		return time.Time{}, false, err
+	}
+	return time.UnixMilli(int64(score)), true, nil
+}
+
+// ScheduledRooms returns every room with a running round.
+func (s *GameService) ScheduledRooms(ctx context.Context) ([]uuid.UUID, error) {
+	members, err := s.rdb.ZRange(ctx, roundDeadlinesKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	roomIDs := make([]uuid.UUID, 0, len(members))
+	for _, m := range members {
+		if roomID, err := uuid.Parse(m); err == nil {
+			roomIDs = append(roomIDs, roomID)
+		}
+	}
+	return roomIDs, nil
+}
+
+// ClaimRoundEnd marks the round as being ended. Only the first caller for a
+// given room and round gets true, which makes round transitions idempotent
+// across goroutines and instances. The claim expires after roundEndClaimTTL so
+// a round whose claimer died mid-transition is retried.
+func (s *GameService) ClaimRoundEnd(ctx context.Context, roomID uuid.UUID, round int) (bool, error) {
+	key := fmt.Sprintf("round_end:%s:%d", roomID, round)
+	return s.rdb.SetNX(ctx, key, 1, roundEndClaimTTL).Result()
+}
+
+// RestoreRoundDeadlines re-schedules the rounds of every game Postgres still
+// considers playing, from the RoundEndAt saved in the game state. Games whose
+// state is gone from Redis cannot be resumed and are finished. It returns the
+// rooms with a running round.
+func (s *GameService) RestoreRoundDeadlines(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id FROM rooms WHERE status = $1
+	`, models.RoomStatusPlaying)
+	if err != nil {
+		return nil, err
+	}
+	var roomIDs []uuid.UUID
+	for rows.Next() {
+		var roomID uuid.UUID
+		if err := rows.Scan(&roomID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		roomIDs = append(roomIDs, roomID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	restored := make([]uuid.UUID, 0, len(roomIDs))
+	for _, roomID := range roomIDs {
+		state, err := s.GetGameState(ctx, roomID)
+		if err != nil {
+			return nil, err
+		}
+		if state == nil || state.Status != string(models.RoomStatusPlaying) {
+			if err := s.EndGame(ctx, roomID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// A paused round whose grace window already ran out waits for the
+		// host and has nothing scheduled
+		if state.Paused && !state.PausedUntil.After(time.Now()) {
+			continue
+		}
+
+		// NX keeps a deadline a live instance may have just moved
+		err = s.rdb.ZAddNX(ctx, roundDeadlinesKey, redis.Z{
+			Score:  float64(state.deadline().UnixMilli()),
+			Member: roomID.String(),
+		}).Err()
+		if err != nil {
+			return nil, err
+		}
+		restored = append(restored, roomID)
+	}
+	return restored, nil
+}
+
+// PauseRound pauses the running round because its explainer disconnected.
+// paused is false if userID is not the explainer or the round is not running.
+func (s *GameService) PauseRound(ctx context.Context, roomID uuid.UUID, userID int64) (state *GameState, paused bool, err error) {
+	state, err = s.updateGameState(ctx, roomID, func(state *GameState) error {
+		paused = false
+		if state.Status != string(models.RoomStatusPlaying) || state.Paused || state.CurrentExplainer != userID {
+			return errUnchanged
+		}
+
+		now := time.Now()
+		state.Paused = true
+		state.Remaining = state.RoundEndAt.Sub(now)
+		if state.Remaining < 0 {
+			state.Remaining = 0
+		}
+		state.PausedUntil = now.Add(ExplainerReconnectGrace)
+		paused = true
+		return nil
+	})
+	if errors.Is(err, ErrRoomNotFound) {
+		return nil, false, nil
+	}
+	if err != nil || !paused {
+		return state, false, err
+	}
+	if err := s.scheduleRoundEnd(ctx, roomID, state.PausedUntil); err != nil {
+		return nil, false, err
+	}
+
+	noteGameEvent(ctx, s.pool, roomID, GameEventRoundPaused, RoundPausedEvent{
+		ExplainerID: userID,
+		RemainingMs: state.Remaining.Milliseconds(),
+		PausedUntil: state.PausedUntil,
+	})
+	return state, true, nil
+}
+
+// ResumeRound continues a paused round when its explainer is back. The round
+// gets the time it had left when it was paused. resumed is false if userID is
+// not the explainer of a paused round.
+func (s *GameService) ResumeRound(ctx context.Context, roomID uuid.UUID, userID int64) (state *GameState, resumed bool, err error) {
+	state, err = s.updateGameState(ctx, roomID, func(state *GameState) error {
+		resumed = false
+		if state.Status != string(models.RoomStatusPlaying) || !state.Paused || state.CurrentExplainer != userID {
+			return errUnchanged
+		}
+
+		state.Paused = false
+		state.RoundEndAt = time.Now().Add(state.Remaining)
+		state.Remaining = 0
+		state.PausedUntil = time.Time{}
+		resumed = true
+		return nil
+	})
+	if errors.Is(err, ErrRoomNotFound) {
+		return nil, false, nil
+	}
+	if err != nil || !resumed {
+		return state, false, err
+	}
+	if err := s.scheduleRoundEnd(ctx, roomID, state.RoundEndAt); err != nil {
+		return nil, false, err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE rooms SET round_end_at = $1 WHERE id = $2
+	`, state.RoundEndAt, roomID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = appendGameEvent(ctx, tx, roomID, GameEventRoundResumed, RoundResumedEvent{RoundEndAt: state.RoundEndAt})
+	if err != nil {
+		return nil, false, err
+	}
+	return state, true, tx.Commit(ctx)
+}
+
+// ExpirePause ends the reconnect grace window of a paused round. Nothing is
+// scheduled for the room afterwards; the round waits for the explainer or for
+// the host to skip them. expired is true for exactly one caller.
+func (s *GameService) ExpirePause(ctx context.Context, roomID uuid.UUID) (expired bool, err error) {
+	removed, err := s.rdb.ZRem(ctx, roundDeadlinesKey, roomID.String()).Result()
+	if err != nil {
+		return false, err
+	}
+	return removed > 0, nil
+}
+
+// CheckSkipExplainer verifies that userID may skip the explainer of a paused
+// round: only the host, and only once the reconnect grace window is over.
+func (s *GameService) CheckSkipExplainer(ctx context.Context, roomID uuid.UUID, userID int64) (*GameState, error) {
+	var isHost bool
+	err := s.pool.QueryRow(ctx, `
+		SELECT is_host FROM players WHERE room_id = $1 AND user_id = $2
+	`, roomID, userID).Scan(&isHost)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPlayerNotFound
+		}
+		return nil, err
+	}
+	if !isHost {
+		return nil, ErrNotHost
+	}
+
+	state, err := s.GetGameState(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil || state.Status != string(models.RoomStatusPlaying) {
+		return nil, ErrRoomNotFound
+	}
+	if !state.Paused {
+		return nil, ErrRoundNotPaused
+	}
+	if time.Now().Before(state.PausedUntil) {
+		return nil, ErrSkipTooEarly
+	}
+	return state, nil
+}
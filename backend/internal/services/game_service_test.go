@@ -0,0 +1,233 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/yaroslav/elias/internal/models"
+)
+
+func TestCanSeeWord(t *testing.T) {
+	players := []*models.Player{
+		{UserID: 1, Team: "t1"},
+		{UserID: 2, Team: "t1"},
+		{UserID: 3, Team: "t2"},
+		{UserID: 4},
+	}
+
+	tests := []struct {
+		name        string
+		explainerID int64
+		userID      int64
+		want        bool
+	}{
+		{"Explainer", 1, 1, true},
+		{"Teammate of explainer", 1, 2, false},
+		{"Other team", 1, 3, true},
+		{"Player without team", 1, 4, true},
+		{"Explainer without team", 4, 1, true},
+		{"Unknown user", 1, 99, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanSeeWord(players, tt.explainerID, tt.userID); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNextExplainer(t *testing.T) {
+	players := []*models.Player{
+		{UserID: 1, Team: "t1"},
+		{UserID: 2, Team: "t2"},
+		{UserID: 3},
+		{UserID: 4, Team: "t1"},
+	}
+	all := map[int64]bool{1: true, 2: true, 3: true, 4: true}
+
+	tests := []struct {
+		name      string
+		current   int64
+		available map[int64]bool
+		want      int64
+	}{
+		{"Next player", 1, all, 2},
+		{"Skips player without team", 2, all, 4},
+		{"Wraps around", 4, all, 1},
+		{"Unknown current starts from first", 99, all, 1},
+		{"Skips offline player", 1, map[int64]bool{1: true, 4: true}, 4},
+		{"Current stays if only one available", 1, map[int64]bool{1: true}, 1},
+		{"Nobody available keeps rotation", 1, map[int64]bool{}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextExplainer(players, tt.current, tt.available); got != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+
+	if got := nextExplainer(nil, 1, all); got != 0 {
+		t.Errorf("Expected 0 for no players, got %d", got)
+	}
+}
+
+func TestGameStateDeadline(t *testing.T) {
+	roundEnd := time.Now().Add(time.Minute)
+	pausedUntil := time.Now().Add(ExplainerReconnectGrace)
+
+	running := &GameState{RoundEndAt: roundEnd, PausedUntil: pausedUntil}
+	if got := running.deadline(); !got.Equal(roundEnd) {
+		t.Errorf("Expected round end %v, got %v", roundEnd, got)
+	}
+
+	paused := &GameState{RoundEndAt: roundEnd, Paused: true, PausedUntil: pausedUntil}
+	if got := paused.deadline(); !got.Equal(pausedUntil) {
+		t.Errorf("Expected grace end %v, got %v", pausedUntil, got)
+	}
+}
+
+// TestCanTakeWord covers the check a swipe makes on the game state before its
+// result commits, including when it loses the race to the end of the round.
+func TestCanTakeWord(t *testing.T) {
+	now := time.Now()
+	playing := func() *GameState {
+		return &GameState{
+			Status:       string(models.RoomStatusPlaying),
+			CurrentRound: 1,
+			CurrentWord:  &WordState{ID: 7, Word: "кот"},
+			RoundEndAt:   now.Add(time.Minute),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(s *GameState)
+		want   error
+	}{
+		{"word still shown", func(s *GameState) {}, nil},
+		{"round ended first", func(s *GameState) {
+			// What NextRound leaves behind
+			s.CurrentRound++
+			s.CurrentWord = nil
+			s.RoundEndAt = now.Add(time.Minute)
+		}, ErrNoCurrentWord},
+		{"after the deadline", func(s *GameState) { s.RoundEndAt = now }, ErrNoCurrentWord},
+		{"next word shown", func(s *GameState) { s.CurrentWord = &WordState{ID: 8, Word: "дом"} }, ErrNoCurrentWord},
+		{"game over", func(s *GameState) { s.Status = string(models.RoomStatusFinished) }, ErrNoCurrentWord},
+		{"paused", func(s *GameState) { s.Paused = true }, ErrRoundPaused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := playing()
+			tt.mutate(state)
+			if err := state.canTakeWord(7, now); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRetryOnConflict(t *testing.T) {
+	errOther := errors.New("other")
+	tests := []struct {
+		name      string
+		conflicts int
+		final     error
+		wantCalls int
+		wantErr   error
+	}{
+		{"first try", 0, nil, 1, nil},
+		{"after conflicts", 2, nil, 3, nil},
+		{"other error", 1, errOther, 2, errOther},
+		{"exhausted", 10, nil, stateUpdateAttempts, ErrStateConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := retryOnConflict(stateUpdateAttempts, func() error {
+				calls++
+				if calls <= tt.conflicts {
+					return ErrStateConflict
+				}
+				return tt.final
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Expected %d calls, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+// saveStateScript reads the version from the stored JSON
+func TestGameStateVersionJSON(t *testing.T) {
+	data, err := json.Marshal(&GameState{Version: 7})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if raw["version"] != float64(7) {
+		t.Errorf("Expected version 7, got %v", raw["version"])
+	}
+}
+
+func TestTeamScoresFromCounts(t *testing.T) {
+	tests := []struct {
+		name   string
+		teams  map[string]int
+		counts map[string]int
+		want   map[string]int
+	}{
+		{"no words yet", map[string]int{"a": 0, "b": 0}, map[string]int{}, map[string]int{"a": 0, "b": 0}},
+		{"counts replace stale scores", map[string]int{"a": 5, "b": 1}, map[string]int{"a": 3, "b": 2}, map[string]int{"a": 3, "b": 2}},
+		{"unknown team ignored", map[string]int{"a": 0}, map[string]int{"a": 1, "gone": 4}, map[string]int{"a": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := teamScoresFromCounts(tt.teams, tt.counts)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLeader(t *testing.T) {
+	tests := []struct {
+		name       string
+		teamScores map[string]int
+		wantTeam   string
+		wantOK     bool
+	}{
+		{"no teams", map[string]int{}, "", false},
+		{"single leader", map[string]int{"a": 3, "b": 5, "c": 1}, "b", true},
+		{"tie at the top", map[string]int{"a": 5, "b": 5, "c": 1}, "", false},
+		{"tie below the top", map[string]int{"a": 2, "b": 2, "c": 4}, "c", true},
+		{"nobody scored", map[string]int{"a": 0, "b": 0}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			team, ok := leader(tt.teamScores)
+			if team != tt.wantTeam || ok != tt.wantOK {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.wantTeam, tt.wantOK, team, ok)
+			}
+		})
+	}
+}
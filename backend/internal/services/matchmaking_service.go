@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/yaroslav/elias/internal/models"
+)
+
+// openRoomsKey is a sorted set of public lobbies with free seats, scored by the
+// number of players so quick-match can fill the fullest lobby first.
+const openRoomsKey = "rooms:open"
+
+const (
+	publicRoomsLimit = 50
+
+	// The index is read openRoomsPageSize rooms at a time, and at most the
+	// openRoomsMaxPages fullest pages are looked at per listing.
+	openRoomsPageSize = 100
+	openRoomsMaxPages = 10
+
+	// quickMatchCandidates is how many lobbies quick-match tries before
+	// creating a new one.
+	quickMatchCandidates = 5
+)
+
+type MatchmakingService struct {
+	pool        *pgxpool.Pool
+	rdb         *redis.Client
+	roomService *RoomService
+}
+
+func NewMatchmakingService(pool *pgxpool.Pool, rdb *redis.Client, roomService *RoomService) *MatchmakingService {
+	return &MatchmakingService{pool: pool, rdb: rdb, roomService: roomService}
+}
+
+// SyncRoom updates the open-rooms index from Postgres. Call it after anything
+// that changes a room's status, visibility or player count.
+func (s *MatchmakingService) SyncRoom(ctx context.Context, roomID uuid.UUID) error {
+	var status models.RoomStatus
+	var isPublic bool
+	var players int
+	err := s.pool.QueryRow(ctx, `
+		SELECT r.status, r.is_public, COUNT(p.id)
+		FROM rooms r LEFT JOIN players p ON p.room_id = r.id
+		WHERE r.id = $1
+		GROUP BY r.id
+	`, roomID).Scan(&status, &isPublic, &players)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	if err == nil && isOpen(status, isPublic, players) {
+		return s.rdb.ZAdd(ctx, openRoomsKey, redis.Z{
+			Score:  float64(players),
+			Member: roomID.String(),
+		}).Err()
+	}
+	return s.rdb.ZRem(ctx, openRoomsKey, roomID.String()).Err()
+}
+
+// TrySyncRoom is SyncRoom for callers that must not fail because of the index;
+// errors are only logged.
+func (s *MatchmakingService) TrySyncRoom(roomID uuid.UUID) {
+	if err := s.SyncRoom(context.Background(), roomID); err != nil {
+		log.Printf("Error syncing open room %s: %v", roomID, err)
+	}
+}
+
+func isOpen(status models.RoomStatus, isPublic bool, players int) bool {
+	return status == models.RoomStatusLobby && isPublic && players > 0 && players < MaxPlayers
+}
+
+// ListPublicRooms returns open public lobbies matching the filter, fullest
+// first. The index is read a page at a time, and each page is checked against
+// Postgres with the filter applied there. Index entries that no longer match
+// Postgres are dropped.
+func (s *MatchmakingService) ListPublicRooms(ctx context.Context, filter models.PublicRoomFilter) ([]*models.PublicRoom, error) {
+	minFree := filter.MinFreeSeats
+	if minFree < 1 {
+		minFree = 1
+	}
+
+	result := []*models.PublicRoom{}
+	var stale []interface{}
+	for page := 0; page < openRoomsMaxPages && len(result) < publicRoomsLimit; page++ {
+		ids, err := s.rdb.ZRevRangeByScore(ctx, openRoomsKey, &redis.ZRangeBy{
+			Min:    "0",
+			Max:    strconv.Itoa(MaxPlayers - minFree),
+			Offset: int64(page * openRoomsPageSize),
+			Count:  openRoomsPageSize,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		roomIDs := make([]uuid.UUID, 0, len(ids))
+		for _, id := range ids {
+			if roomID, err := uuid.Parse(id); err == nil {
+				roomIDs = append(roomIDs, roomID)
+			} else {
+				stale = append(stale, id)
+			}
+		}
+
+		rows, err := s.openRoomRows(ctx, roomIDs, filter)
+		if err != nil {
+			return nil, err
+		}
+		rooms, staleIDs := pickOpenRooms(rows, minFree, publicRoomsLimit-len(result))
+		result = append(result, rooms...)
+		for _, id := range staleIDs {
+			stale = append(stale, id.String())
+		}
+
+		if len(ids) < openRoomsPageSize {
+			break
+		}
+	}
+
+	// Removed only now so the pages above do not shift under the offsets
+	if len(stale) > 0 {
+		if err := s.rdb.ZRem(ctx, openRoomsKey, stale...).Err(); err != nil {
+			log.Printf("Error removing stale open rooms: %v", err)
+		}
+	}
+
+	return result, nil
+}
+
+// openRoomRow is a room of the index as found in Postgres.
+type openRoomRow struct {
+	room     models.PublicRoom
+	status   models.RoomStatus
+	isPublic bool
+}
+
+// openRoomRows loads the rooms among roomIDs that match the filter's language
+// and category, in the order of roomIDs.
+func (s *MatchmakingService) openRoomRows(ctx context.Context, roomIDs []uuid.UUID, filter models.PublicRoomFilter) ([]*openRoomRow, error) {
+	if len(roomIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT r.id, COALESCE(r.code, ''), r.category, r.lang, r.num_teams, r.status, r.is_public, r.created_at,
+		       COUNT(p.id),
+		       COALESCE(MAX(COALESCE(p.first_name, p.username)) FILTER (WHERE p.is_host), '')
+		FROM rooms r LEFT JOIN players p ON p.room_id = r.id
+		WHERE r.id = ANY($1)
+			AND ($2 = '' OR r.lang = $2)
+			AND ($3 = '' OR r.category = $3)
+		GROUP BY r.id
+		ORDER BY array_position($1, r.id)
+	`, roomIDs, filter.Lang, filter.Category)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*openRoomRow
+	for rows.Next() {
+		var row openRoomRow
+		if err := rows.Scan(
+			&row.room.ID, &row.room.Code, &row.room.Category, &row.room.Lang, &row.room.NumTeams, &row.status, &row.isPublic, &row.room.CreatedAt,
+			&row.room.Players, &row.room.HostName,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, &row)
+	}
+	return result, rows.Err()
+}
+
+// pickOpenRooms returns up to limit of the rows that are still open and have
+// at least minFree free seats, and the ids of the rows that are no longer open.
+func pickOpenRooms(rows []*openRoomRow, minFree, limit int) (rooms []*models.PublicRoom, stale []uuid.UUID) {
+	for _, row := range rows {
+		if !isOpen(row.status, row.isPublic, row.room.Players) {
+			stale = append(stale, row.room.ID)
+			continue
+		}
+		if len(rooms) == limit {
+			continue
+		}
+		room := row.room
+		room.FreeSeats = MaxPlayers - room.Players
+		if room.FreeSeats < minFree {
+			continue
+		}
+		rooms = append(rooms, &room)
+	}
+	return rooms, stale
+}
+
+// QuickMatch joins the user to the fullest open lobby that matches the request,
+// or creates a new public room when there is none. created reports whether a
+// new room was made.
+func (s *MatchmakingService) QuickMatch(ctx context.Context, user *models.TelegramUser, req models.QuickMatchRequest) (room *models.Room, player *models.Player, created bool, err error) {
+	candidates, err := s.ListPublicRooms(ctx, models.PublicRoomFilter{
+		Lang:     req.Lang,
+		Category: req.Category,
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	roomID, player, err := joinFirst(candidates, func(roomID uuid.UUID) (*models.Player, error) {
+		// JoinRoom returns the player of a member as is; quick-match looks
+		// for a room the user is not in yet
+		member, err := s.roomService.IsPlayer(ctx, roomID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if member {
+			return nil, ErrAlreadyInRoom
+		}
+
+		player, err := s.roomService.JoinRoom(ctx, roomID, user)
+		if err != nil {
+			// Somebody else filled or started it in the meantime
+			s.TrySyncRoom(roomID)
+		}
+		return player, err
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if player != nil {
+		room, err := s.roomService.GetRoom(ctx, roomID)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		s.TrySyncRoom(room.ID)
+		return room, player, false, nil
+	}
+
+	room, player, err = s.roomService.CreateRoom(ctx, user, models.CreateRoomRequest{
+		Category: req.Category,
+		Lang:     req.Lang,
+		IsPublic: true,
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	s.TrySyncRoom(room.ID)
+	return room, player, true, nil
+}
+
+// joinFirst tries to join the first quickMatchCandidates candidates in order
+// and returns the first room joined. Candidates the user is already in, or
+// that filled up, started or went away since they were listed are skipped; a
+// nil player means none was joined.
+func joinFirst(candidates []*models.PublicRoom, join func(roomID uuid.UUID) (*models.Player, error)) (uuid.UUID, *models.Player, error) {
+	for i, candidate := range candidates {
+		if i == quickMatchCandidates {
+			break
+		}
+
+		player, err := join(candidate.ID)
+		if err != nil {
+			if errors.Is(err, ErrAlreadyInRoom) || errors.Is(err, ErrRoomFull) || errors.Is(err, ErrGameInProgress) || errors.Is(err, ErrRoomNotFound) {
+				continue
+			}
+			return uuid.Nil, nil, err
+		}
+		return candidate.ID, player, nil
+	}
+	return uuid.Nil, nil, nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/yaroslav/elias/internal/models"
+)
+
+func TestIsOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   models.RoomStatus
+		isPublic bool
+		players  int
+		want     bool
+	}{
+		{"Public lobby with free seats", models.RoomStatusLobby, true, 3, true},
+		{"Private lobby", models.RoomStatusLobby, false, 3, false},
+		{"Full lobby", models.RoomStatusLobby, true, MaxPlayers, false},
+		{"Empty lobby", models.RoomStatusLobby, true, 0, false},
+		{"Game in progress", models.RoomStatusPlaying, true, 3, false},
+		{"Finished game", models.RoomStatusFinished, true, 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isOpen(tt.status, tt.isPublic, tt.players); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPickOpenRooms(t *testing.T) {
+	row := func(id byte, status models.RoomStatus, isPublic bool, players int) *openRoomRow {
+		return &openRoomRow{
+			room:     models.PublicRoom{ID: uuid.UUID{id}, Players: players},
+			status:   status,
+			isPublic: isPublic,
+		}
+	}
+	rows := []*openRoomRow{
+		row(1, models.RoomStatusLobby, true, MaxPlayers-1),
+		row(2, models.RoomStatusPlaying, true, 4),
+		row(3, models.RoomStatusLobby, true, 4),
+		row(4, models.RoomStatusLobby, false, 3),
+		row(5, models.RoomStatusLobby, true, 2),
+	}
+
+	tests := []struct {
+		name      string
+		minFree   int
+		limit     int
+		wantRooms []uuid.UUID
+	}{
+		{"All open rooms in order", 1, 10, []uuid.UUID{{1}, {3}, {5}}},
+		{"Not enough free seats", 2, 10, []uuid.UUID{{3}, {5}}},
+		{"Limit", 1, 2, []uuid.UUID{{1}, {3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rooms, stale := pickOpenRooms(rows, tt.minFree, tt.limit)
+
+			var got []uuid.UUID
+			for _, room := range rooms {
+				got = append(got, room.ID)
+				if room.FreeSeats != MaxPlayers-room.Players {
+					t.Errorf("Expected %d free seats in room %s, got %d", MaxPlayers-room.Players, room.ID, room.FreeSeats)
+				}
+			}
+			if !slices.Equal(got, tt.wantRooms) {
+				t.Errorf("Expected rooms %v, got %v", tt.wantRooms, got)
+			}
+
+			// Closed rooms are reported whatever the limit
+			wantStale := []uuid.UUID{{2}, {4}}
+			if !slices.Equal(stale, wantStale) {
+				t.Errorf("Expected stale %v, got %v", wantStale, stale)
+			}
+		})
+	}
+}
+
+func TestJoinFirst(t *testing.T) {
+	candidates := make([]*models.PublicRoom, quickMatchCandidates+1)
+	for i := range candidates {
+		candidates[i] = &models.PublicRoom{ID: uuid.UUID{byte(i + 1)}}
+	}
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name     string
+		failures map[uuid.UUID]error
+		want     uuid.UUID
+		wantErr  error
+	}{
+		{"First candidate", nil, uuid.UUID{1}, nil},
+		{
+			name:     "Skips rooms that filled up, started or went away",
+			failures: map[uuid.UUID]error{{1}: ErrRoomFull, {2}: ErrGameInProgress, {3}: ErrRoomNotFound},
+			want:     uuid.UUID{4},
+		},
+		{
+			name:     "Skips rooms the user is already in",
+			failures: map[uuid.UUID]error{{1}: ErrAlreadyInRoom},
+			want:     uuid.UUID{2},
+		},
+		{
+			name:     "Unexpected error",
+			failures: map[uuid.UUID]error{{1}: ErrRoomFull, {2}: errBoom},
+			wantErr:  errBoom,
+		},
+		{
+			name: "Gives up after quickMatchCandidates",
+			failures: map[uuid.UUID]error{
+				{1}: ErrRoomFull, {2}: ErrRoomFull, {3}: ErrRoomFull, {4}: ErrRoomFull, {5}: ErrRoomFull,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tried := 0
+			roomID, player, err := joinFirst(candidates, func(roomID uuid.UUID) (*models.Player, error) {
+				tried++
+				if err := tt.failures[roomID]; err != nil {
+					return nil, err
+				}
+				return &models.Player{RoomID: roomID}, nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if tried > quickMatchCandidates {
+				t.Errorf("Expected at most %d tries, got %d", quickMatchCandidates, tried)
+			}
+			if tt.want == uuid.Nil {
+				if player != nil {
+					t.Errorf("Expected no room, got %s", roomID)
+				}
+				return
+			}
+			if roomID != tt.want || player == nil {
+				t.Errorf("Expected room %s, got %s", tt.want, roomID)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/yaroslav/elias/pkg/protocol"
+)
+
+// outboxBatch is how many events one relay pass takes at most.
+const outboxBatch = 100
+
+// OutboxEvent is a room event written in the same transaction as the change
+// it describes, waiting to be sent to the room.
+type OutboxEvent struct {
+	ID      int64
+	RoomID  uuid.UUID
+	Type    protocol.MessageType
+	Payload json.RawMessage
+}
+
+func insertOutbox(ctx context.Context, tx pgx.Tx, roomID uuid.UUID, eventType protocol.MessageType, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO outbox (room_id, type, payload) VALUES ($1, $2, $3)
+	`, roomID, eventType, data)
+	return err
+}
+
+// RelayOutbox passes the room's unpublished events to publish in the order
+// they were written and marks them published. Events are marked only after
+// publish returned, so an event may be published twice but is never lost.
+func (s *GameService) RelayOutbox(ctx context.Context, roomID uuid.UUID, publish func(*OutboxEvent)) (int, error) {
+	return s.relayOutbox(ctx, publish, `
+		SELECT id, room_id, type, payload FROM outbox
+		WHERE published_at IS NULL AND room_id = $1
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, roomID, outboxBatch)
+}
+
+// RelayAllOutbox is RelayOutbox for every room. It picks up events whose
+// writer died before relaying them.
+func (s *GameService) RelayAllOutbox(ctx context.Context, publish func(*OutboxEvent)) (int, error) {
+	return s.relayOutbox(ctx, publish, `
+		SELECT id, room_id, type, payload FROM outbox
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, outboxBatch)
+}
+
+func (s *GameService) relayOutbox(ctx context.Context, publish func(*OutboxEvent), query string, args ...interface{}) (int, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Rows locked by another relay are skipped, so two instances never send
+	// the same event at the same time
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	var events []*OutboxEvent
+	for rows.Next() {
+		var e OutboxEvent
+		if err := rows.Scan(&e.ID, &e.RoomID, &e.Type, &e.Payload); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		events = append(events, &e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int64, len(events))
+	for i, e := range events {
+		publish(e)
+		ids[i] = e.ID
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE outbox SET published_at = NOW() WHERE id = ANY($1)
+	`, ids)
+	if err != nil {
+		return 0, err
+	}
+	return len(events), tx.Commit(ctx)
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/yaroslav/elias/internal/models"
+)
+
+const (
+	// PresenceHeartbeat is how often a connected client refreshes its presence.
+	PresenceHeartbeat = 15 * time.Second
+	// PresenceTTL is how long a player stays online without a heartbeat.
+	PresenceTTL = 45 * time.Second
+	// ExplainerGracePeriod is how long a player may be offline before the
+	// explainer rotation skips them.
+	ExplainerGracePeriod = 2 * time.Minute
+)
+
+// presenceKey is a sorted set of "<room>:<user>" members scored by the last
+// heartbeat in unix milliseconds. Keeping all rooms in one set lets any
+// instance find players whose connection died with another instance.
+const presenceKey = "presence"
+
+const lastSeenTTL = 24 * time.Hour
+
+func presenceMember(roomID uuid.UUID, userID int64) string {
+	return fmt.Sprintf("%s:%d", roomID, userID)
+}
+
+func lastSeenKey(roomID uuid.UUID) string {
+	return "last_seen:" + roomID.String()
+}
+
+// presenceConnsKey is a sorted set of the player's connections, on any
+// instance, scored by their last heartbeat. The player goes offline when the
+// last of them disconnects.
+func presenceConnsKey(roomID uuid.UUID, userID int64) string {
+	return presenceMemberConnsKey(presenceMember(roomID, userID))
+}
+
+func presenceMemberConnsKey(member string) string {
+	return "presence_conns:" + member
+}
+
+// heartbeatScript refreshes a connection and the player's presence. It
+// returns 1 if the player was offline before, so exactly one of concurrent
+// heartbeats announces them.
+//
+// KEYS: presence, conns, last seen. ARGV: presence member, connection id, now,
+// heartbeat cutoff, presence TTL in milliseconds, user id, last seen TTL in
+// seconds.
+var heartbeatScript = redis.NewScript(`
+local prev = redis.call("ZSCORE", KEYS[1], ARGV[1])
+redis.call("ZADD", KEYS[1], ARGV[3], ARGV[1])
+redis.call("ZADD", KEYS[2], ARGV[3], ARGV[2])
+redis.call("PEXPIRE", KEYS[2], ARGV[5])
+redis.call("HSET", KEYS[3], ARGV[6], ARGV[3])
+redis.call("EXPIRE", KEYS[3], ARGV[7])
+if prev and tonumber(prev) >= tonumber(ARGV[4]) then
+	return 0
+end
+return 1
+`)
+
+// disconnectScript removes a connection and, if it was the player's last live
+// one, the player's presence.
+//
+// KEYS: conns, presence, last seen. ARGV: connection id, presence member,
+// heartbeat cutoff, now, user id, last seen TTL in seconds.
+var disconnectScript = redis.NewScript(`
+redis.call("ZREM", KEYS[1], ARGV[1])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", "(" .. ARGV[3])
+if redis.call("ZCARD", KEYS[1]) > 0 then
+	return 0
+end
+redis.call("HSET", KEYS[3], ARGV[5], ARGV[4])
+redis.call("EXPIRE", KEYS[3], ARGV[6])
+return redis.call("ZREM", KEYS[2], ARGV[2])
+`)
+
+// expireScript removes the player's presence and connections if their last
+// heartbeat is still older than the cutoff.
+//
+// KEYS: presence, conns. ARGV: presence member, heartbeat cutoff.
+var expireScript = redis.NewScript(`
+local score = redis.call("ZSCORE", KEYS[1], ARGV[1])
+if not score or tonumber(score) >= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call("ZREM", KEYS[1], ARGV[1])
+redis.call("DEL", KEYS[2])
+return 1
+`)
+
+// PresenceEntry identifies a player in a room.
+type PresenceEntry struct {
+	RoomID uuid.UUID
+	UserID int64
+}
+
+func parsePresenceMember(member string) (PresenceEntry, bool) {
+	room, user, ok := strings.Cut(member, ":")
+	if !ok {
+		return PresenceEntry{}, false
+	}
+	roomID, err := uuid.Parse(room)
+	if err != nil {
+		return PresenceEntry{}, false
+	}
+	userID, err := strconv.ParseInt(user, 10, 64)
+	if err != nil {
+		return PresenceEntry{}, false
+	}
+	return PresenceEntry{RoomID: roomID, UserID: userID}, true
+}
+
+// PresenceService tracks which players have a live connection to their room.
+type PresenceService struct {
+	rdb *redis.Client
+}
+
+func NewPresenceService(rdb *redis.Client) *PresenceService {
+	return &PresenceService{rdb: rdb}
+}
+
+// Heartbeat marks the player online through connection connID. cameOnline
+// reports whether they were offline before.
+func (s *PresenceService) Heartbeat(ctx context.Context, roomID uuid.UUID, userID int64, connID string) (cameOnline bool, err error) {
+	now := time.Now()
+	keys := []string{presenceKey, presenceConnsKey(roomID, userID), lastSeenKey(roomID)}
+	offline, err := heartbeatScript.Run(ctx, s.rdb, keys,
+		presenceMember(roomID, userID), connID, now.UnixMilli(), now.Add(-PresenceTTL).UnixMilli(),
+		PresenceTTL.Milliseconds(), strconv.FormatInt(userID, 10), int(lastSeenTTL.Seconds()),
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return offline > 0, nil
+}
+
+// Disconnect drops connection connID of the player and marks them offline if
+// it was their last one. wentOffline is false if they are still connected,
+// e.g. after reconnecting to another instance, or already were offline
+// because another instance expired them first.
+func (s *PresenceService) Disconnect(ctx context.Context, roomID uuid.UUID, userID int64, connID string) (wentOffline bool, err error) {
+	now := time.Now()
+	keys := []string{presenceConnsKey(roomID, userID), presenceKey, lastSeenKey(roomID)}
+	removed, err := disconnectScript.Run(ctx, s.rdb, keys,
+		connID, presenceMember(roomID, userID), now.Add(-PresenceTTL).UnixMilli(), now.UnixMilli(),
+		strconv.FormatInt(userID, 10), int(lastSeenTTL.Seconds()),
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return removed > 0, nil
+}
+
+// Expire removes players whose heartbeat is older than PresenceTTL, with their
+// connections, and returns them. Each expired player is returned to exactly
+// one caller; a player whose heartbeat came in meanwhile is kept.
+func (s *PresenceService) Expire(ctx context.Context) ([]PresenceEntry, error) {
+	cutoff := time.Now().Add(-PresenceTTL).UnixMilli()
+	members, err := s.rdb.ZRangeByScore(ctx, presenceKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: "(" + strconv.FormatInt(cutoff, 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []PresenceEntry
+	for _, member := range members {
+		keys := []string{presenceKey, presenceMemberConnsKey(member)}
+		removed, err := expireScript.Run(ctx, s.rdb, keys, member, cutoff).Int()
+		if err != nil {
+			return expired, err
+		}
+		if removed == 0 {
+			continue
+		}
+		if entry, ok := parsePresenceMember(member); ok {
+			expired = append(expired, entry)
+		}
+	}
+	return expired, nil
+}
+
+// Online returns which of the users are online in the room.
+func (s *PresenceService) Online(ctx context.Context, roomID uuid.UUID, userIDs []int64) (map[int64]bool, error) {
+	online := make(map[int64]bool, len(userIDs))
+	if len(userIDs) == 0 {
+		return online, nil
+	}
+
+	members := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		members[i] = presenceMember(roomID, userID)
+	}
+	scores, err := s.rdb.ZMScore(ctx, presenceKey, members...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i, score := range scores {
+		// Missing members come back as 0
+		online[userIDs[i]] = score > 0 && isFresh(score, now)
+	}
+	return online, nil
+}
+
+// MarkOnline sets the Online flag of players.
+func (s *PresenceService) MarkOnline(ctx context.Context, roomID uuid.UUID, players []*models.Player) error {
+	userIDs := make([]int64, len(players))
+	for i, p := range players {
+		userIDs[i] = p.UserID
+	}
+	online, err := s.Online(ctx, roomID, userIDs)
+	if err != nil {
+		return err
+	}
+	for _, p := range players {
+		p.Online = online[p.UserID]
+	}
+	return nil
+}
+
+// Available returns the users that are online or went offline less than
+// ExplainerGracePeriod ago.
+func (s *PresenceService) Available(ctx context.Context, roomID uuid.UUID, userIDs []int64) (map[int64]bool, error) {
+	available, err := s.Online(ctx, roomID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	seen, err := s.rdb.HGetAll(ctx, lastSeenKey(roomID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-ExplainerGracePeriod).UnixMilli()
+	for _, userID := range userIDs {
+		if available[userID] {
+			continue
+		}
+		lastSeen, err := strconv.ParseInt(seen[strconv.FormatInt(userID, 10)], 10, 64)
+		available[userID] = err == nil && lastSeen >= cutoff
+	}
+	return available, nil
+}
+
+func isFresh(score float64, now time.Time) bool {
+	return int64(score) >= now.Add(-PresenceTTL).UnixMilli()
+}
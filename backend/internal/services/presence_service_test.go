@@ -0,0 +1,38 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParsePresenceMember(t *testing.T) {
+	roomID := uuid.New()
+
+	entry, ok := parsePresenceMember(presenceMember(roomID, 42))
+	if !ok {
+		t.Fatal("Expected member to parse")
+	}
+	if entry.RoomID != roomID || entry.UserID != 42 {
+		t.Errorf("Expected %s/42, got %s/%d", roomID, entry.RoomID, entry.UserID)
+	}
+
+	invalid := []string{"", "42", "not-a-uuid:42", roomID.String() + ":abc"}
+	for _, member := range invalid {
+		t.Run(member, func(t *testing.T) {
+			if _, ok := parsePresenceMember(member); ok {
+				t.Errorf("Expected %q to be rejected", member)
+			}
+		})
+	}
+}
+
+// Expire only has the member to go by, so the connections key built from it
+// must be the one Heartbeat and Disconnect use.
+func TestPresenceMemberConnsKey(t *testing.T) {
+	roomID := uuid.New()
+	want := presenceConnsKey(roomID, 42)
+	if got := presenceMemberConnsKey(presenceMember(roomID, 42)); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
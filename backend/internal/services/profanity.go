@@ -0,0 +1,20 @@
+package services
+
+// Корни нецензурных слов, запрещённых в пользовательских названиях команд.
+// Сравнение идёт по началу каждого слова после приведения к нижнему регистру
+// и замены «ё» на «е».
+var profanityRoots = []string{
+	// Русский
+	"хуй", "хуе", "хуя", "хуи", "хую",
+	"пизд", "пезд",
+	"ебан", "ебат", "ебал", "ебну", "ебло", "ебуч",
+	"бля",
+	"муда", "мудо",
+	"пидор", "пидар",
+	"сука", "суки", "сучк",
+	"залуп",
+	"шлюх",
+	"гандон",
+	// English
+	"fuck", "shit", "cunt", "bitch", "dick", "whore", "faggot", "nigger",
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yaroslav/elias/internal/models"
+)
+
+// Ratings are Elo over teams, kept per word language. Every team of a game
+// plays every other team, with the mean rating of its players as its rating.
+// On top of the team result explainers gain or lose a few points for getting
+// more or fewer of their words guessed than the game average.
+const (
+	DefaultRating = 1500
+
+	ratingK    = 32
+	explainerK = 8
+
+	defaultLeaderboardLimit = 50
+	maxLeaderboardLimit     = 100
+)
+
+var ErrInvalidWindow = errors.New("invalid time window")
+
+// leaderboardWindows are the time windows of the leaderboard; zero is all time.
+var leaderboardWindows = map[string]time.Duration{
+	"all":   0,
+	"month": 30 * 24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"day":   24 * time.Hour,
+}
+
+type RatingService struct {
+	pool *pgxpool.Pool
+}
+
+func NewRatingService(pool *pgxpool.Pool) *RatingService {
+	return &RatingService{pool: pool}
+}
+
+// ratedPlayer is a player of a game as the rating sees them.
+type ratedPlayer struct {
+	UserID    int64
+	Team      string
+	Rating    int
+	Explained int
+	Guessed   int
+}
+
+// rateGame updates the ratings of the players of a finished game. A game is
+// rated only once; later calls do nothing.
+func rateGame(ctx context.Context, tx pgx.Tx, roomID uuid.UUID, lang string, teamScores map[string]int) error {
+	var rated bool
+	err := tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM rating_history WHERE room_id = $1)
+	`, roomID).Scan(&rated)
+	if err != nil || rated {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT p.user_id, p.team, COALESCE(r.rating, $3),
+			   COUNT(w.id), COUNT(w.id) FILTER (WHERE w.guessed)
+		FROM players p
+		LEFT JOIN ratings r ON r.user_id = p.user_id AND r.lang = $2
+		LEFT JOIN round_words w ON w.room_id = p.room_id AND w.explainer_id = p.user_id
+		WHERE p.room_id = $1 AND p.team <> ''
+		GROUP BY p.user_id, p.team, r.rating
+	`, roomID, lang, DefaultRating)
+	if err != nil {
+		return err
+	}
+	var players []ratedPlayer
+	for rows.Next() {
+		var p ratedPlayer
+		if err := rows.Scan(&p.UserID, &p.Team, &p.Rating, &p.Explained, &p.Guessed); err != nil {
+			rows.Close()
+			return err
+		}
+		players = append(players, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for userID, delta := range ratingDeltas(players, teamScores) {
+		// The change is applied relative to the stored rating so that games
+		// of the same user ending at once do not overwrite each other
+		var after int
+		err := tx.QueryRow(ctx, `
+			INSERT INTO ratings (user_id, lang, rating, games)
+			VALUES ($1, $2, $3, 1)
+			ON CONFLICT (user_id, lang) DO UPDATE SET
+				rating = ratings.rating + $4,
+				games = ratings.games + 1,
+				updated_at = NOW()
+			RETURNING rating
+		`, userID, lang, DefaultRating+delta, delta).Scan(&after)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO rating_history (user_id, room_id, lang, rating_before, rating_after)
+			VALUES ($1, $2, $3, $4, $5)
+		`, userID, roomID, lang, after-delta, after)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ratingDeltas returns the rating change of every rated player. Players whose
+// team is not in teamScores are not rated, and neither is a game with fewer
+// than two teams that have players.
+func ratingDeltas(players []ratedPlayer, teamScores map[string]int) map[int64]int {
+	sums := make(map[string]int)
+	sizes := make(map[string]int)
+	var explained, guessed int
+	for _, p := range players {
+		if _, ok := teamScores[p.Team]; !ok {
+			continue
+		}
+		sums[p.Team] += p.Rating
+		sizes[p.Team]++
+		explained += p.Explained
+		guessed += p.Guessed
+	}
+	if len(sizes) < 2 {
+		return nil
+	}
+
+	teams := make([]string, 0, len(sizes))
+	for team := range sizes {
+		teams = append(teams, team)
+	}
+	sort.Strings(teams)
+
+	teamDeltas := make(map[string]float64, len(teams))
+	for _, a := range teams {
+		ratingA := float64(sums[a]) / float64(sizes[a])
+		var delta float64
+		for _, b := range teams {
+			if a == b {
+				continue
+			}
+			ratingB := float64(sums[b]) / float64(sizes[b])
+			expected := 1 / (1 + math.Pow(10, (ratingB-ratingA)/400))
+			delta += ratingK * (outcome(teamScores[a], teamScores[b]) - expected)
+		}
+		teamDeltas[a] = delta / float64(len(teams)-1)
+	}
+
+	average := guessRate(guessed, explained)
+	deltas := make(map[int64]int, len(players))
+	for _, p := range players {
+		if _, ok := sizes[p.Team]; !ok {
+			continue
+		}
+		delta := teamDeltas[p.Team]
+		if p.Explained > 0 {
+			delta += explainerK * (guessRate(p.Guessed, p.Explained) - average)
+		}
+		deltas[p.UserID] = int(math.Round(delta))
+	}
+	return deltas
+}
+
+// outcome is the Elo result of a team with score against one with other.
+func outcome(score, other int) float64 {
+	switch {
+	case score > other:
+		return 1
+	case score < other:
+		return 0
+	}
+	return 0.5
+}
+
+// balanceTeams splits players between teams so that the teams differ in size
+// by at most one and their total ratings are close. Stronger players are
+// placed first, each into the smallest team with the lowest total.
+func balanceTeams(players []ratedPlayer, teamIDs []string) map[int64]string {
+	if len(teamIDs) == 0 {
+		return nil
+	}
+
+	sorted := make([]ratedPlayer, len(players))
+	copy(sorted, players)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Rating != sorted[j].Rating {
+			return sorted[i].Rating > sorted[j].Rating
+		}
+		return sorted[i].UserID < sorted[j].UserID
+	})
+
+	sizes := make([]int, len(teamIDs))
+	totals := make([]int, len(teamIDs))
+	assigned := make(map[int64]string, len(players))
+	for _, p := range sorted {
+		best := 0
+		for i := 1; i < len(teamIDs); i++ {
+			if sizes[i] < sizes[best] || (sizes[i] == sizes[best] && totals[i] < totals[best]) {
+				best = i
+			}
+		}
+		sizes[best]++
+		totals[best] += p.Rating
+		assigned[p.UserID] = teamIDs[best]
+	}
+	return assigned
+}
+
+// leaderboardSince returns the start of the named time window, or the zero
+// time for all time. An empty window is all time.
+func leaderboardSince(window string, now time.Time) (time.Time, error) {
+	if window == "" {
+		window = "all"
+	}
+	d, ok := leaderboardWindows[window]
+	if !ok {
+		return time.Time{}, ErrInvalidWindow
+	}
+	if d == 0 {
+		return time.Time{}, nil
+	}
+	return now.Add(-d), nil
+}
+
+// GetLeaderboard returns the best rated players of lang who played a rated
+// game in the time window, with their games and rating change in it.
+func (s *RatingService) GetLeaderboard(ctx context.Context, lang, window string, limit int) ([]*models.LeaderboardEntry, error) {
+	if !IsSupportedLang(lang) {
+		return nil, ErrUnsupportedLang
+	}
+	since, err := leaderboardSince(window, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultLeaderboardLimit
+	}
+	if limit > maxLeaderboardLimit {
+		limit = maxLeaderboardLimit
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT r.user_id, COALESCE(u.username, ''), COALESCE(u.first_name, ''), r.rating, h.games, h.change
+		FROM ratings r
+		JOIN users u ON u.id = r.user_id
+		JOIN (
+			SELECT user_id, COUNT(*) AS games, SUM(rating_after - rating_before) AS change
+			FROM rating_history
+			WHERE lang = $1 AND created_at >= $2
+			GROUP BY user_id
+		) h ON h.user_id = r.user_id
+		WHERE r.lang = $1
+		ORDER BY r.rating DESC, r.user_id
+		LIMIT $3
+	`, lang, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.LeaderboardEntry{}
+	for rows.Next() {
+		e := &models.LeaderboardEntry{Rank: len(entries) + 1}
+		if err := rows.Scan(&e.UserID, &e.Username, &e.FirstName, &e.Rating, &e.Games, &e.Change); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// GetHistory returns the rating changes of the user, newest first. An empty
+// lang returns the changes in every language.
+func (s *RatingService) GetHistory(ctx context.Context, userID int64, lang string) ([]*models.RatingChange, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT room_id, lang, rating_before, rating_after, created_at
+		FROM rating_history
+		WHERE user_id = $1 AND ($2 = '' OR lang = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT 100
+	`, userID, lang)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []*models.RatingChange{}
+	for rows.Next() {
+		var c models.RatingChange
+		if err := rows.Scan(&c.RoomID, &c.Lang, &c.RatingBefore, &c.RatingAfter, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, &c)
+	}
+	return history, rows.Err()
+}
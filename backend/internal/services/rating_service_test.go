@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestRatingDeltas(t *testing.T) {
+	tests := []struct {
+		name       string
+		players    []ratedPlayer
+		teamScores map[string]int
+		want       map[int64]int
+	}{
+		{
+			name: "even teams, winner takes half of K",
+			players: []ratedPlayer{
+				{UserID: 1, Team: "t1", Rating: 1500},
+				{UserID: 2, Team: "t2", Rating: 1500},
+			},
+			teamScores: map[string]int{"t1": 30, "t2": 20},
+			want:       map[int64]int{1: 16, 2: -16},
+		},
+		{
+			name: "draw between even teams",
+			players: []ratedPlayer{
+				{UserID: 1, Team: "t1", Rating: 1500},
+				{UserID: 2, Team: "t2", Rating: 1500},
+			},
+			teamScores: map[string]int{"t1": 10, "t2": 10},
+			want:       map[int64]int{1: 0, 2: 0},
+		},
+		{
+			name: "favourite wins less",
+			players: []ratedPlayer{
+				{UserID: 1, Team: "t1", Rating: 1900},
+				{UserID: 2, Team: "t2", Rating: 1500},
+			},
+			teamScores: map[string]int{"t1": 30, "t2": 20},
+			want:       map[int64]int{1: 3, 2: -3},
+		},
+		{
+			name: "explainers above and below the average",
+			players: []ratedPlayer{
+				{UserID: 1, Team: "t1", Rating: 1500, Explained: 10, Guessed: 10},
+				{UserID: 2, Team: "t1", Rating: 1500},
+				{UserID: 3, Team: "t2", Rating: 1500, Explained: 10, Guessed: 0},
+			},
+			teamScores: map[string]int{"t1": 10, "t2": 0},
+			want:       map[int64]int{1: 20, 2: 16, 3: -20},
+		},
+		{
+			name: "players without a team are not rated",
+			players: []ratedPlayer{
+				{UserID: 1, Team: "t1", Rating: 1500},
+				{UserID: 2, Team: "t2", Rating: 1500},
+				{UserID: 3, Team: "gone", Rating: 1500},
+			},
+			teamScores: map[string]int{"t1": 1, "t2": 0},
+			want:       map[int64]int{1: 16, 2: -16},
+		},
+		{
+			name: "single team is not rated",
+			players: []ratedPlayer{
+				{UserID: 1, Team: "t1", Rating: 1500},
+				{UserID: 2, Team: "t1", Rating: 1500},
+			},
+			teamScores: map[string]int{"t1": 5, "t2": 0},
+			want:       map[int64]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ratingDeltas(tt.players, tt.teamScores)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBalanceTeams(t *testing.T) {
+	players := []ratedPlayer{
+		{UserID: 1, Rating: 1800},
+		{UserID: 2, Rating: 1700},
+		{UserID: 3, Rating: 1600},
+		{UserID: 4, Rating: 1500},
+		{UserID: 5, Rating: 1400},
+	}
+
+	assigned := balanceTeams(players, []string{"t1", "t2"})
+	if len(assigned) != len(players) {
+		t.Fatalf("Expected %d players assigned, got %d", len(players), len(assigned))
+	}
+
+	sizes := map[string]int{}
+	totals := map[string]int{}
+	for _, p := range players {
+		sizes[assigned[p.UserID]]++
+		totals[assigned[p.UserID]] += p.Rating
+	}
+	if d := sizes["t1"] - sizes["t2"]; d > 1 || d < -1 {
+		t.Errorf("Expected team sizes to differ by at most 1, got %v", sizes)
+	}
+	want := map[string]int{"t1": 4700, "t2": 3300}
+	if !maps.Equal(totals, want) {
+		t.Errorf("Expected totals %v, got %v", want, totals)
+	}
+
+	t.Run("no teams", func(t *testing.T) {
+		if got := balanceTeams(players, nil); got != nil {
+			t.Errorf("Expected nil, got %v", got)
+		}
+	})
+}
+
+func TestLeaderboardSince(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		window  string
+		want    time.Time
+		wantErr error
+	}{
+		{"", time.Time{}, nil},
+		{"all", time.Time{}, nil},
+		{"week", now.AddDate(0, 0, -7), nil},
+		{"day", now.AddDate(0, 0, -1), nil},
+		{"year", time.Time{}, ErrInvalidWindow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.window, func(t *testing.T) {
+			got, err := leaderboardSince(tt.window, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// roomCodeAlphabet omits characters that are easy to confuse when read aloud
+// or handwritten: 0/O, 1/I/L.
+const roomCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+const (
+	MinRoomCodeLength = 4
+	MaxRoomCodeLength = 6
+
+	// roomCodeAttemptsPerLength is how many collisions we tolerate before
+	// switching to a longer code.
+	roomCodeAttemptsPerLength = 5
+	maxRoomCodeAttempts       = roomCodeAttemptsPerLength * (MaxRoomCodeLength - MinRoomCodeLength + 1)
+)
+
+var ErrInvalidRoomCode = errors.New("invalid room code")
+
+// GenerateRoomCode returns a random code of the given length.
+func GenerateRoomCode(length int) (string, error) {
+	max := big.NewInt(int64(len(roomCodeAlphabet)))
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = roomCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// roomCodeLength picks the code length for the given attempt so that repeated
+// collisions gradually move to longer codes.
+func roomCodeLength(attempt int) int {
+	length := MinRoomCodeLength + attempt/roomCodeAttemptsPerLength
+	if length > MaxRoomCodeLength {
+		length = MaxRoomCodeLength
+	}
+	return length
+}
+
+// NormalizeRoomCode upper-cases a user-entered code and checks that it could
+// have been produced by GenerateRoomCode.
+func NormalizeRoomCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) < MinRoomCodeLength || len(code) > MaxRoomCodeLength {
+		return "", ErrInvalidRoomCode
+	}
+	for _, r := range code {
+		if !strings.ContainsRune(roomCodeAlphabet, r) {
+			return "", ErrInvalidRoomCode
+		}
+	}
+	return code, nil
+}
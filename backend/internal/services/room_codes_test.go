@@ -0,0 +1,68 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateRoomCode(t *testing.T) {
+	for length := MinRoomCodeLength; length <= MaxRoomCodeLength; length++ {
+		for i := 0; i < 100; i++ {
+			code, err := GenerateRoomCode(length)
+			if err != nil {
+				t.Fatalf("GenerateRoomCode failed: %v", err)
+			}
+			if len(code) != length {
+				t.Errorf("Expected code of length %d, got %q", length, code)
+			}
+			if strings.ContainsAny(code, "0O1IL") {
+				t.Errorf("Code contains confusable characters: %s", code)
+			}
+			if _, err := NormalizeRoomCode(code); err != nil {
+				t.Errorf("Generated code %q does not pass validation: %v", code, err)
+			}
+		}
+	}
+}
+
+func TestRoomCodeLengthGrowsOnCollisions(t *testing.T) {
+	if got := roomCodeLength(0); got != MinRoomCodeLength {
+		t.Errorf("First attempt should use %d characters, got %d", MinRoomCodeLength, got)
+	}
+	if got := roomCodeLength(roomCodeAttemptsPerLength); got != MinRoomCodeLength+1 {
+		t.Errorf("Expected length to grow after %d collisions, got %d", roomCodeAttemptsPerLength, got)
+	}
+	if got := roomCodeLength(maxRoomCodeAttempts * 2); got != MaxRoomCodeLength {
+		t.Errorf("Length should be capped at %d, got %d", MaxRoomCodeLength, got)
+	}
+}
+
+func TestNormalizeRoomCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"Upper case", "ABCD", "ABCD", false},
+		{"Lower case with spaces", "  k7mq ", "K7MQ", false},
+		{"Six characters", "ABC234", "ABC234", false},
+		{"Too short", "ABC", "", true},
+		{"Too long", "ABCDEFG", "", true},
+		{"Confusable zero", "AB0D", "", true},
+		{"Confusable letter O", "ABOD", "", true},
+		{"Cyrillic", "АВСD", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeRoomCode(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error: %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
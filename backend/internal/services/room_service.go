@@ -8,19 +8,26 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/yaroslav/elias/internal/models"
 )
 
 var (
-	ErrRoomNotFound   = errors.New("room not found")
-	ErrPlayerNotFound = errors.New("player not found")
-	ErrAlreadyInRoom  = errors.New("player already in room")
-	ErrRoomFull       = errors.New("room is full")
-	ErrNotHost        = errors.New("only host can perform this action")
-	ErrGameInProgress = errors.New("game already in progress")
+	ErrRoomNotFound    = errors.New("room not found")
+	ErrPlayerNotFound  = errors.New("player not found")
+	ErrAlreadyInRoom   = errors.New("player already in room")
+	ErrRoomFull        = errors.New("room is full")
+	ErrNotHost         = errors.New("only host can perform this action")
+	ErrGameInProgress  = errors.New("game already in progress")
+	ErrUnsupportedLang = errors.New("unsupported language")
+	ErrGameNotFinished = errors.New("game is not finished yet")
+	ErrCannotKickSelf  = errors.New("host cannot kick themselves")
 )
 
+// MaxPlayers is the maximum number of players in a room.
+const MaxPlayers = 8
+
 type RoomService struct {
 	pool *pgxpool.Pool
 }
@@ -29,7 +36,17 @@ func NewRoomService(pool *pgxpool.Pool) *RoomService {
 	return &RoomService{pool: pool}
 }
 
-func (s *RoomService) CreateRoom(ctx context.Context, user *models.TelegramUser, category string, numTeams int) (*models.Room, *models.Player, error) {
+func (s *RoomService) CreateRoom(ctx context.Context, user *models.TelegramUser, req models.CreateRoomRequest) (*models.Room, *models.Player, error) {
+	category, lang, numTeams := req.Category, req.Lang, req.NumTeams
+
+	// Default language if not specified
+	if lang == "" {
+		lang = DefaultLang
+	}
+	if !IsSupportedLang(lang) {
+		return nil, nil, ErrUnsupportedLang
+	}
+
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
 		return nil, nil, err
@@ -42,19 +59,16 @@ func (s *RoomService) CreateRoom(ctx context.Context, user *models.TelegramUser,
 	}
 
 	// Default num_teams if not specified or invalid
-	if numTeams < 2 {
-		numTeams = 2
+	if numTeams < MinTeams {
+		numTeams = MinTeams
 	}
-	if numTeams > 5 {
-		numTeams = 5
+	if numTeams > MaxTeams {
+		numTeams = MaxTeams
 	}
 
 	// Generate team names
-	teamNames := GenerateUniqueTeamNames(numTeams)
-	teamNamesJSON, err := json.Marshal(teamNames)
-	if err != nil {
-		return nil, nil, err
-	}
+	teamNames := GenerateUniqueTeamNamesFor(TeamNameGeneratorFor(lang), numTeams)
+	teams := NewTeams(teamNames)
 
 	// Create room
 	room := models.Room{
@@ -63,14 +77,16 @@ func (s *RoomService) CreateRoom(ctx context.Context, user *models.TelegramUser,
 		CurrentExplainerID: nil,
 		RoundEndAt:         nil,
 		Category:           category,
+		Lang:               lang,
+		IsPublic:           req.IsPublic,
+		RoundSeconds:       int(RoundDuration.Seconds()),
+		WinningScore:       WinningScore,
 		NumTeams:           numTeams,
+		Teams:              teams,
 		TeamNames:          teamNames,
+		ChatID:             req.ChatID,
 	}
-	err = tx.QueryRow(ctx, `
-		INSERT INTO rooms (status, current_round, category, num_teams, team_names) VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, created_at
-	`, models.RoomStatusLobby, 0, category, numTeams, teamNamesJSON).Scan(&room.ID, &room.CreatedAt)
-	if err != nil {
+	if err := insertRoom(ctx, tx, &room); err != nil {
 		return nil, nil, err
 	}
 
@@ -100,13 +116,139 @@ func (s *RoomService) CreateRoom(ctx context.Context, user *models.TelegramUser,
 	return &room, &player, nil
 }
 
+// Rematch clones a finished room's settings, teams and players (with zeroed
+// scores) into a new lobby. Repeated calls return the already created rematch
+// room with created set to false.
+func (s *RoomService) Rematch(ctx context.Context, roomID uuid.UUID, userID int64) (*models.Room, bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	old := models.Room{ID: roomID}
+	var teamsJSON []byte
+	err = tx.QueryRow(ctx, `
+		SELECT status, category, lang, is_public, round_seconds, winning_score, teams, rematch_room_id, chat_id
+		FROM rooms WHERE id = $1 FOR UPDATE
+	`, roomID).Scan(&old.Status, &old.Category, &old.Lang, &old.IsPublic, &old.RoundSeconds, &old.WinningScore, &teamsJSON, &old.RematchRoomID, &old.ChatID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, ErrRoomNotFound
+		}
+		return nil, false, err
+	}
+
+	var isPlayer bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM players WHERE room_id = $1 AND user_id = $2)
+	`, roomID, userID).Scan(&isPlayer)
+	if err != nil {
+		return nil, false, err
+	}
+	if !isPlayer {
+		return nil, false, ErrPlayerNotFound
+	}
+
+	if old.RematchRoomID != nil {
+		if err := tx.Commit(ctx); err != nil {
+			return nil, false, err
+		}
+		room, err := s.GetRoom(ctx, *old.RematchRoomID)
+		return room, false, err
+	}
+	if old.Status != models.RoomStatusFinished {
+		return nil, false, ErrGameNotFinished
+	}
+
+	if err := json.Unmarshal(teamsJSON, &old.Teams); err != nil {
+		return nil, false, err
+	}
+
+	room := models.Room{
+		Status:       models.RoomStatusLobby,
+		Category:     old.Category,
+		Lang:         old.Lang,
+		IsPublic:     old.IsPublic,
+		RoundSeconds: old.RoundSeconds,
+		WinningScore: old.WinningScore,
+		NumTeams:     len(old.Teams),
+		Teams:        old.Teams,
+		TeamNames:    TeamNamesOf(old.Teams),
+		ChatID:       old.ChatID,
+	}
+	if err := insertRoom(ctx, tx, &room); err != nil {
+		return nil, false, err
+	}
+
+	// Same players, teams and host; joined_at is kept so explainer order is preserved
+	_, err = tx.Exec(ctx, `
+		INSERT INTO players (room_id, user_id, username, first_name, team, is_host, joined_at)
+		SELECT $1, user_id, username, first_name, team, is_host, joined_at
+		FROM players WHERE room_id = $2
+	`, room.ID, roomID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE rooms SET rematch_room_id = $1 WHERE id = $2
+	`, room.ID, roomID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, err
+	}
+	return &room, true, nil
+}
+
+// insertRoom inserts a lobby room with a fresh short code, retrying in a
+// savepoint when the code collides with another active room. room.ID,
+// room.Code and room.CreatedAt are filled in.
+func insertRoom(ctx context.Context, tx pgx.Tx, room *models.Room) error {
+	teamNamesJSON, err := json.Marshal(TeamNamesOf(room.Teams))
+	if err != nil {
+		return err
+	}
+	teamsJSON, err := json.Marshal(room.Teams)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		room.Code, err = GenerateRoomCode(roomCodeLength(attempt))
+		if err != nil {
+			return err
+		}
+
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		err = sp.QueryRow(ctx, `
+			INSERT INTO rooms (status, current_round, category, lang, num_teams, team_names, teams, code, is_public, round_seconds, winning_score, chat_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			RETURNING id, created_at
+		`, models.RoomStatusLobby, 0, room.Category, room.Lang, len(room.Teams), teamNamesJSON, teamsJSON, room.Code, room.IsPublic, room.RoundSeconds, room.WinningScore, room.ChatID).Scan(&room.ID, &room.CreatedAt)
+		if err == nil {
+			return sp.Commit(ctx)
+		}
+		sp.Rollback(ctx)
+
+		if !isUniqueViolation(err) || attempt+1 >= maxRoomCodeAttempts {
+			return err
+		}
+	}
+}
+
 func (s *RoomService) GetRoom(ctx context.Context, roomID uuid.UUID) (*models.Room, error) {
 	room := &models.Room{}
-	var teamNamesJSON []byte
+	var teamNamesJSON, teamsJSON []byte
 	err := s.pool.QueryRow(ctx, `
-		SELECT id, status, current_round, category, num_teams, team_names, created_at
+		SELECT id, COALESCE(code, ''), status, current_round, category, lang, is_public, round_seconds, winning_score, num_teams, team_names, teams, rematch_room_id, chat_id, created_at
 		FROM rooms WHERE id = $1
-	`, roomID).Scan(&room.ID, &room.Status, &room.CurrentRound, &room.Category, &room.NumTeams, &teamNamesJSON, &room.CreatedAt)
+	`, roomID).Scan(&room.ID, &room.Code, &room.Status, &room.CurrentRound, &room.Category, &room.Lang, &room.IsPublic, &room.RoundSeconds, &room.WinningScore, &room.NumTeams, &teamNamesJSON, &teamsJSON, &room.RematchRoomID, &room.ChatID, &room.CreatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrRoomNotFound
@@ -126,12 +268,39 @@ func (s *RoomService) GetRoom(ctx context.Context, roomID uuid.UUID) (*models.Ro
 		}
 	}
 
+	if len(teamsJSON) > 0 {
+		if err := json.Unmarshal(teamsJSON, &room.Teams); err != nil {
+			return nil, err
+		}
+	}
+
 	return room, nil
 }
 
+// GetRoomByCode finds the active (not finished) room with the given short code.
+func (s *RoomService) GetRoomByCode(ctx context.Context, code string) (*models.Room, error) {
+	code, err := NormalizeRoomCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	var roomID uuid.UUID
+	err = s.pool.QueryRow(ctx, `
+		SELECT id FROM rooms WHERE code = $1 AND status <> $2
+	`, code, models.RoomStatusFinished).Scan(&roomID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRoomNotFound
+		}
+		return nil, err
+	}
+
+	return s.GetRoom(ctx, roomID)
+}
+
 func (s *RoomService) GetRoomPlayers(ctx context.Context, roomID uuid.UUID) ([]*models.Player, error) {
 	rows, err := s.pool.Query(ctx, `
-		SELECT id, room_id, user_id, COALESCE(username, ''), COALESCE(first_name, ''), COALESCE(team, ''), score, is_host, joined_at
+		SELECT id, room_id, user_id, COALESCE(username, ''), COALESCE(first_name, ''), COALESCE(team, ''), score, is_host, ready, joined_at
 		FROM players WHERE room_id = $1
 		ORDER BY joined_at
 	`, roomID)
@@ -145,7 +314,7 @@ func (s *RoomService) GetRoomPlayers(ctx context.Context, roomID uuid.UUID) ([]*
 		var p models.Player
 		if err := rows.Scan(
 			&p.ID, &p.RoomID, &p.UserID, &p.Username,
-			&p.FirstName, &p.Team, &p.Score, &p.IsHost, &p.JoinedAt,
+			&p.FirstName, &p.Team, &p.Score, &p.IsHost, &p.Ready, &p.JoinedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -169,7 +338,7 @@ func (s *RoomService) JoinRoom(ctx context.Context, roomID uuid.UUID, user *mode
 	if err != nil {
 		return nil, err
 	}
-	if len(players) >= 8 {
+	if len(players) >= MaxPlayers {
 		return nil, ErrRoomFull
 	}
 
@@ -202,64 +371,73 @@ func (s *RoomService) JoinRoom(ctx context.Context, roomID uuid.UUID, user *mode
 	return &player, nil
 }
 
+// ChangeTeam moves the user to team, or out of their team if it is empty.
+// Teams are fixed once the game starts.
 func (s *RoomService) ChangeTeam(ctx context.Context, roomID uuid.UUID, userID int64, team string) (*models.Player, error) {
-	// Validate team name if not empty
-	if team != "" {
-		// Get room to check if team exists
-		room, err := s.GetRoom(ctx, roomID)
-		if err != nil {
-			log.Printf("ChangeTeam: GetRoom error: %v", err)
-			return nil, err
-		}
-
-		log.Printf("ChangeTeam: room.TeamNames=%v, requested team=%s", room.TeamNames, team)
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
 
-		// Check if team is in the room's team_names
-		validTeam := false
-		for _, teamName := range room.TeamNames {
-			if teamName == team {
-				validTeam = true
-				break
-			}
+	var status models.RoomStatus
+	var teamsJSON []byte
+	err = tx.QueryRow(ctx, `
+		SELECT status, teams FROM rooms WHERE id = $1 FOR UPDATE
+	`, roomID).Scan(&status, &teamsJSON)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRoomNotFound
 		}
+		return nil, err
+	}
+	if status != models.RoomStatusLobby {
+		return nil, ErrGameInProgress
+	}
 
-		if !validTeam {
-			log.Printf("ChangeTeam: invalid team, available teams: %v", room.TeamNames)
-			return nil, errors.New("invalid team")
+	// An empty team leaves the current one; anything else must be one of the
+	// room's team ids
+	if team != "" {
+		var teams []models.Team
+		if err := json.Unmarshal(teamsJSON, &teams); err != nil {
+			return nil, err
+		}
+		if FindTeam(teams, team) < 0 {
+			return nil, ErrTeamNotFound
 		}
-		log.Printf("ChangeTeam: team validated successfully")
 	}
 
-	log.Printf("ChangeTeam: executing UPDATE for user=%d, team='%s'", userID, team)
 	var player models.Player
-	err := s.pool.QueryRow(ctx, `
+	err = tx.QueryRow(ctx, `
 		UPDATE players SET team = $1
 		WHERE room_id = $2 AND user_id = $3
-		RETURNING id, room_id, user_id, COALESCE(username, ''), COALESCE(first_name, ''), team, score, is_host, joined_at
+		RETURNING id, room_id, user_id, COALESCE(username, ''), COALESCE(first_name, ''), team, score, is_host, ready, joined_at
 	`, team, roomID, userID).Scan(
 		&player.ID, &player.RoomID, &player.UserID, &player.Username,
-		&player.FirstName, &player.Team, &player.Score, &player.IsHost, &player.JoinedAt,
+		&player.FirstName, &player.Team, &player.Score, &player.IsHost, &player.Ready, &player.JoinedAt,
 	)
 	if err != nil {
-		log.Printf("ChangeTeam: UPDATE/SCAN error: %v", err)
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrPlayerNotFound
 		}
 		return nil, err
 	}
-	log.Printf("ChangeTeam: SUCCESS, player updated to team '%s'", player.Team)
 
-	return &player, nil
+	if err := appendGameEvent(ctx, tx, roomID, GameEventTeamChanged, TeamChangedEvent{UserID: userID, Team: team}); err != nil {
+		return nil, err
+	}
+
+	return &player, tx.Commit(ctx)
 }
 
 func (s *RoomService) GetPlayer(ctx context.Context, roomID uuid.UUID, userID int64) (*models.Player, error) {
 	var player models.Player
 	err := s.pool.QueryRow(ctx, `
-		SELECT id, room_id, user_id, username, first_name, team, score, is_host, joined_at
+		SELECT id, room_id, user_id, username, first_name, team, score, is_host, ready, joined_at
 		FROM players WHERE room_id = $1 AND user_id = $2
 	`, roomID, userID).Scan(
 		&player.ID, &player.RoomID, &player.UserID, &player.Username,
-		&player.FirstName, &player.Team, &player.Score, &player.IsHost, &player.JoinedAt,
+		&player.FirstName, &player.Team, &player.Score, &player.IsHost, &player.Ready, &player.JoinedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -270,6 +448,216 @@ func (s *RoomService) GetPlayer(ctx context.Context, roomID uuid.UUID, userID in
 	return &player, nil
 }
 
+// LeaveRoom removes the player from a room that is still in the lobby. If the
+// host leaves, the earliest joined remaining player becomes the host and their
+// user id is returned. A room left without players is closed.
+func (s *RoomService) LeaveRoom(ctx context.Context, roomID uuid.UUID, userID int64) (int64, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var status models.RoomStatus
+	err = tx.QueryRow(ctx, `
+		SELECT status FROM rooms WHERE id = $1 FOR UPDATE
+	`, roomID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrRoomNotFound
+		}
+		return 0, err
+	}
+	if status != models.RoomStatusLobby {
+		return 0, ErrGameInProgress
+	}
+
+	var wasHost bool
+	err = tx.QueryRow(ctx, `
+		DELETE FROM players WHERE room_id = $1 AND user_id = $2
+		RETURNING is_host
+	`, roomID, userID).Scan(&wasHost)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrPlayerNotFound
+		}
+		return 0, err
+	}
+
+	var newHostID int64
+	if wasHost {
+		err = tx.QueryRow(ctx, `
+			UPDATE players SET is_host = TRUE
+			WHERE id = (SELECT id FROM players WHERE room_id = $1 ORDER BY joined_at LIMIT 1)
+			RETURNING user_id
+		`, roomID).Scan(&newHostID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return 0, err
+		}
+	}
+
+	// Close the room once everybody has left so its code can be reused
+	_, err = tx.Exec(ctx, `
+		UPDATE rooms SET status = $1
+		WHERE id = $2 AND NOT EXISTS (SELECT 1 FROM players WHERE room_id = $2)
+	`, models.RoomStatusFinished, roomID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return newHostID, nil
+}
+
+// SetVisibility lists or unlists the room in the public room browser. Host only.
+func (s *RoomService) SetVisibility(ctx context.Context, roomID uuid.UUID, userID int64, isPublic bool) error {
+	isHost, err := s.IsHost(ctx, roomID, userID)
+	if err != nil {
+		return err
+	}
+	if !isHost {
+		return ErrNotHost
+	}
+
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE rooms SET is_public = $1 WHERE id = $2
+	`, isPublic, roomID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRoomNotFound
+	}
+	return nil
+}
+
+// KickPlayer removes a player from a room that is still in the lobby. Host
+// only; the host cannot kick themselves.
+func (s *RoomService) KickPlayer(ctx context.Context, roomID uuid.UUID, hostID, userID int64) error {
+	if hostID == userID {
+		return ErrCannotKickSelf
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockLobbyAsHost(ctx, tx, roomID, hostID); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `
+		DELETE FROM players WHERE room_id = $1 AND user_id = $2
+	`, roomID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPlayerNotFound
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetRules changes the rules of a room that is still in the lobby and returns
+// the updated room. Host only.
+func (s *RoomService) SetRules(ctx context.Context, roomID uuid.UUID, userID int64, rules models.RoomRules) (*models.Room, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockLobbyAsHost(ctx, tx, roomID, userID); err != nil {
+		return nil, err
+	}
+
+	room := &models.Room{ID: roomID}
+	err = tx.QueryRow(ctx, `
+		SELECT category, lang, round_seconds, winning_score FROM rooms WHERE id = $1
+	`, roomID).Scan(&room.Category, &room.Lang, &room.RoundSeconds, &room.WinningScore)
+	if err != nil {
+		return nil, err
+	}
+	if err := ApplyRules(room, rules); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE rooms SET category = $1, lang = $2, round_seconds = $3, winning_score = $4 WHERE id = $5
+	`, room.Category, room.Lang, room.RoundSeconds, room.WinningScore, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return s.GetRoom(ctx, roomID)
+}
+
+// SetReady marks the player ready (or not) while the room is in the lobby.
+func (s *RoomService) SetReady(ctx context.Context, roomID uuid.UUID, userID int64, ready bool) error {
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE players SET ready = $1
+		WHERE room_id = $2 AND user_id = $3
+		AND EXISTS (SELECT 1 FROM rooms WHERE id = $2 AND status = $4)
+	`, ready, roomID, userID, models.RoomStatusLobby)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	// Tell a missing player apart from a game that already started
+	room, err := s.GetRoom(ctx, roomID)
+	if err != nil {
+		return err
+	}
+	if room.Status != models.RoomStatusLobby {
+		return ErrGameInProgress
+	}
+	return ErrPlayerNotFound
+}
+
+// lockLobbyAsHost locks the room row and checks that it is in the lobby and
+// that userID is its host.
+func lockLobbyAsHost(ctx context.Context, tx pgx.Tx, roomID uuid.UUID, userID int64) error {
+	var status models.RoomStatus
+	err := tx.QueryRow(ctx, `
+		SELECT status FROM rooms WHERE id = $1 FOR UPDATE
+	`, roomID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRoomNotFound
+		}
+		return err
+	}
+	if status != models.RoomStatusLobby {
+		return ErrGameInProgress
+	}
+
+	var isHost bool
+	err = tx.QueryRow(ctx, `
+		SELECT is_host FROM players WHERE room_id = $1 AND user_id = $2
+	`, roomID, userID).Scan(&isHost)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPlayerNotFound
+		}
+		return err
+	}
+	if !isHost {
+		return ErrNotHost
+	}
+	return nil
+}
+
 func (s *RoomService) IsHost(ctx context.Context, roomID uuid.UUID, userID int64) (bool, error) {
 	player, err := s.GetPlayer(ctx, roomID, userID)
 	if err != nil {
@@ -278,6 +666,15 @@ func (s *RoomService) IsHost(ctx context.Context, roomID uuid.UUID, userID int64
 	return player.IsHost, nil
 }
 
+// IsPlayer tells whether userID is a player of the room.
+func (s *RoomService) IsPlayer(ctx context.Context, roomID uuid.UUID, userID int64) (bool, error) {
+	var exists bool
+	err := s.pool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM players WHERE room_id = $1 AND user_id = $2)
+	`, roomID, userID).Scan(&exists)
+	return exists, err
+}
+
 func (s *RoomService) UpdateRoomStatus(ctx context.Context, roomID uuid.UUID, status models.RoomStatus) error {
 	_, err := s.pool.Exec(ctx, `
 		UPDATE rooms SET status = $1 WHERE id = $2
@@ -292,3 +689,284 @@ func (s *RoomService) UpdateScore(ctx context.Context, roomID uuid.UUID, userID
 	`, delta, roomID, userID)
 	return err
 }
+
+// RerollTeamName replaces the name of a team with a freshly generated one that
+// does not clash with the other teams. Allowed for the host and team members.
+func (s *RoomService) RerollTeamName(ctx context.Context, roomID uuid.UUID, userID int64, teamID string) ([]models.Team, error) {
+	return s.updateTeams(ctx, roomID, func(tx pgx.Tx, room *models.Room) error {
+		idx := FindTeam(room.Teams, teamID)
+		if idx < 0 {
+			return ErrTeamNotFound
+		}
+		if err := s.checkTeamEditor(ctx, tx, roomID, userID, teamID); err != nil {
+			return err
+		}
+
+		generator := TeamNameGeneratorFor(room.Lang)
+		room.Teams[idx].Name = GenerateTeamNameExcludingFor(generator, TeamNamesOf(room.Teams))
+		return nil
+	})
+}
+
+// RenameTeam sets a custom name for a team. Allowed for the host and team members.
+func (s *RoomService) RenameTeam(ctx context.Context, roomID uuid.UUID, userID int64, teamID string, name string) ([]models.Team, error) {
+	name, err := ValidateTeamName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateTeams(ctx, roomID, func(tx pgx.Tx, room *models.Room) error {
+		idx := FindTeam(room.Teams, teamID)
+		if idx < 0 {
+			return ErrTeamNotFound
+		}
+		if err := s.checkTeamEditor(ctx, tx, roomID, userID, teamID); err != nil {
+			return err
+		}
+		if teamNameTaken(room.Teams, name, teamID) {
+			return ErrTeamNameTaken
+		}
+
+		room.Teams[idx].Name = name
+		return nil
+	})
+}
+
+// SetNumTeams grows or shrinks the list of teams while the room is in the lobby.
+// New teams get generated names; players of removed teams become unassigned and
+// their user ids are returned. Host only.
+func (s *RoomService) SetNumTeams(ctx context.Context, roomID uuid.UUID, userID int64, numTeams int) ([]models.Team, []int64, error) {
+	if numTeams < MinTeams || numTeams > MaxTeams {
+		return nil, nil, ErrInvalidNumTeams
+	}
+
+	var unassigned []int64
+	teams, err := s.updateTeams(ctx, roomID, func(tx pgx.Tx, room *models.Room) error {
+		if room.Status != models.RoomStatusLobby {
+			return ErrGameInProgress
+		}
+
+		var isHost bool
+		err := tx.QueryRow(ctx, `
+			SELECT is_host FROM players WHERE room_id = $1 AND user_id = $2
+		`, roomID, userID).Scan(&isHost)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrPlayerNotFound
+			}
+			return err
+		}
+		if !isHost {
+			return ErrNotHost
+		}
+
+		generator := TeamNameGeneratorFor(room.Lang)
+		for len(room.Teams) < numTeams {
+			room.Teams = append(room.Teams, models.Team{
+				ID:   nextTeamID(room.Teams),
+				Name: GenerateTeamNameExcludingFor(generator, TeamNamesOf(room.Teams)),
+			})
+		}
+
+		if len(room.Teams) > numTeams {
+			removed := make([]string, 0, len(room.Teams)-numTeams)
+			for _, t := range room.Teams[numTeams:] {
+				removed = append(removed, t.ID)
+			}
+			room.Teams = room.Teams[:numTeams]
+
+			rows, err := tx.Query(ctx, `
+				UPDATE players SET team = ''
+				WHERE room_id = $1 AND team = ANY($2)
+				RETURNING user_id
+			`, roomID, removed)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var uid int64
+				if err := rows.Scan(&uid); err != nil {
+					return err
+				}
+				unassigned = append(unassigned, uid)
+			}
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			for _, uid := range unassigned {
+				if err := appendGameEvent(ctx, tx, roomID, GameEventTeamChanged, TeamChangedEvent{UserID: uid}); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return teams, unassigned, nil
+}
+
+// BalanceTeams reassigns every player of a lobby so that the teams are even
+// by size and by rating in the room's language. It returns the players whose
+// team changed. Host only.
+func (s *RoomService) BalanceTeams(ctx context.Context, roomID uuid.UUID, userID int64) ([]*models.Player, error) {
+	var moved []*models.Player
+	_, err := s.updateTeams(ctx, roomID, func(tx pgx.Tx, room *models.Room) error {
+		if room.Status != models.RoomStatusLobby {
+			return ErrGameInProgress
+		}
+
+		var isHost bool
+		err := tx.QueryRow(ctx, `
+			SELECT is_host FROM players WHERE room_id = $1 AND user_id = $2
+		`, roomID, userID).Scan(&isHost)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrPlayerNotFound
+			}
+			return err
+		}
+		if !isHost {
+			return ErrNotHost
+		}
+
+		rows, err := tx.Query(ctx, `
+			SELECT p.user_id, COALESCE(p.team, ''), COALESCE(r.rating, $3)
+			FROM players p
+			LEFT JOIN ratings r ON r.user_id = p.user_id AND r.lang = $2
+			WHERE p.room_id = $1
+		`, roomID, room.Lang, DefaultRating)
+		if err != nil {
+			return err
+		}
+		var players []ratedPlayer
+		for rows.Next() {
+			var p ratedPlayer
+			if err := rows.Scan(&p.UserID, &p.Team, &p.Rating); err != nil {
+				rows.Close()
+				return err
+			}
+			players = append(players, p)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		teamIDs := make([]string, 0, len(room.Teams))
+		for _, t := range room.Teams {
+			teamIDs = append(teamIDs, t.ID)
+		}
+		assigned := balanceTeams(players, teamIDs)
+
+		for _, p := range players {
+			team := assigned[p.UserID]
+			if team == p.Team {
+				continue
+			}
+
+			var player models.Player
+			err := tx.QueryRow(ctx, `
+				UPDATE players SET team = $1
+				WHERE room_id = $2 AND user_id = $3
+				RETURNING id, room_id, user_id, COALESCE(username, ''), COALESCE(first_name, ''), team, score, is_host, ready, joined_at
+			`, team, roomID, p.UserID).Scan(
+				&player.ID, &player.RoomID, &player.UserID, &player.Username,
+				&player.FirstName, &player.Team, &player.Score, &player.IsHost, &player.Ready, &player.JoinedAt,
+			)
+			if err != nil {
+				return err
+			}
+			if err := appendGameEvent(ctx, tx, roomID, GameEventTeamChanged, TeamChangedEvent{UserID: p.UserID, Team: team}); err != nil {
+				return err
+			}
+			moved = append(moved, &player)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
+
+// updateTeams runs fn on the room under a row lock and persists the resulting
+// room.Teams to both teams and team_names.
+func (s *RoomService) updateTeams(ctx context.Context, roomID uuid.UUID, fn func(tx pgx.Tx, room *models.Room) error) ([]models.Team, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	room := &models.Room{ID: roomID}
+	var teamsJSON []byte
+	err = tx.QueryRow(ctx, `
+		SELECT status, lang, teams FROM rooms WHERE id = $1 FOR UPDATE
+	`, roomID).Scan(&room.Status, &room.Lang, &teamsJSON)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRoomNotFound
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(teamsJSON, &room.Teams); err != nil {
+		return nil, err
+	}
+
+	if err := fn(tx, room); err != nil {
+		return nil, err
+	}
+
+	teamsJSON, err = json.Marshal(room.Teams)
+	if err != nil {
+		return nil, err
+	}
+	teamNamesJSON, err := json.Marshal(TeamNamesOf(room.Teams))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE rooms SET teams = $1, team_names = $2, num_teams = $3 WHERE id = $4
+	`, teamsJSON, teamNamesJSON, len(room.Teams), roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return room.Teams, nil
+}
+
+// checkTeamEditor allows the host and members of the team to edit it.
+func (s *RoomService) checkTeamEditor(ctx context.Context, tx pgx.Tx, roomID uuid.UUID, userID int64, teamID string) error {
+	var isHost bool
+	var team string
+	err := tx.QueryRow(ctx, `
+		SELECT is_host, COALESCE(team, '') FROM players WHERE room_id = $1 AND user_id = $2
+	`, roomID, userID).Scan(&isHost, &team)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPlayerNotFound
+		}
+		return err
+	}
+	if !isHost && team != teamID {
+		return ErrNotTeamEditor
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is a Postgres unique_violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
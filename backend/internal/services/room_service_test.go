@@ -47,7 +47,7 @@ func TestCreateRoomWithTeamNames(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			room, player, err := service.CreateRoom(ctx, user, tt.category, tt.numTeams)
+			room, player, err := service.CreateRoom(ctx, user, models.CreateRoomRequest{Category: tt.category, NumTeams: tt.numTeams})
 			if err != nil {
 				t.Fatalf("CreateRoom failed: %v", err)
 			}
@@ -106,7 +106,7 @@ func TestGetRoomWithTeamNames(t *testing.T) {
 	}
 
 	// Create a room
-	room, _, err := service.CreateRoom(ctx, user, "general", 3)
+	room, _, err := service.CreateRoom(ctx, user, models.CreateRoomRequest{Category: "general", NumTeams: 3})
 	if err != nil {
 		t.Fatalf("CreateRoom failed: %v", err)
 	}
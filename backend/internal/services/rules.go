@@ -0,0 +1,54 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yaroslav/elias/internal/models"
+)
+
+// Bounds of the rules a host can set in the lobby.
+const (
+	MinRoundSeconds   = 30
+	MaxRoundSeconds   = 180
+	MinWinningScore   = 5
+	MaxWinningScore   = 100
+	maxCategoryLength = 32
+)
+
+var ErrInvalidRules = errors.New("invalid rules")
+
+// ApplyRules validates rules and applies them to room. Zero fields are left
+// as they are. On error the room is not modified.
+func ApplyRules(room *models.Room, rules models.RoomRules) error {
+	updated := *room
+
+	if category := strings.TrimSpace(rules.Category); category != "" {
+		if utf8.RuneCountInString(category) > maxCategoryLength {
+			return ErrInvalidRules
+		}
+		updated.Category = category
+	}
+	if rules.Lang != "" {
+		if !IsSupportedLang(rules.Lang) {
+			return ErrUnsupportedLang
+		}
+		updated.Lang = rules.Lang
+	}
+	if rules.RoundSeconds != 0 {
+		if rules.RoundSeconds < MinRoundSeconds || rules.RoundSeconds > MaxRoundSeconds {
+			return ErrInvalidRules
+		}
+		updated.RoundSeconds = rules.RoundSeconds
+	}
+	if rules.WinningScore != 0 {
+		if rules.WinningScore < MinWinningScore || rules.WinningScore > MaxWinningScore {
+			return ErrInvalidRules
+		}
+		updated.WinningScore = rules.WinningScore
+	}
+
+	*room = updated
+	return nil
+}
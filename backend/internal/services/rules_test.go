@@ -0,0 +1,43 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yaroslav/elias/internal/models"
+)
+
+func TestApplyRules(t *testing.T) {
+	base := models.Room{Category: "general", Lang: "ru", RoundSeconds: 60, WinningScore: 20}
+
+	tests := []struct {
+		name    string
+		rules   models.RoomRules
+		want    models.Room
+		wantErr error
+	}{
+		{"Empty keeps everything", models.RoomRules{}, base, nil},
+		{"Round length", models.RoomRules{RoundSeconds: 90}, models.Room{Category: "general", Lang: "ru", RoundSeconds: 90, WinningScore: 20}, nil},
+		{"Winning score and category", models.RoomRules{WinningScore: 30, Category: " animals "}, models.Room{Category: "animals", Lang: "ru", RoundSeconds: 60, WinningScore: 30}, nil},
+		{"Language", models.RoomRules{Lang: "en"}, models.Room{Category: "general", Lang: "en", RoundSeconds: 60, WinningScore: 20}, nil},
+		{"Round too short", models.RoomRules{RoundSeconds: MinRoundSeconds - 1}, base, ErrInvalidRules},
+		{"Round too long", models.RoomRules{RoundSeconds: MaxRoundSeconds + 1}, base, ErrInvalidRules},
+		{"Score too low", models.RoomRules{WinningScore: MinWinningScore - 1, RoundSeconds: 90}, base, ErrInvalidRules},
+		{"Score too high", models.RoomRules{WinningScore: MaxWinningScore + 1}, base, ErrInvalidRules},
+		{"Unsupported language", models.RoomRules{Lang: "xx"}, base, ErrUnsupportedLang},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := base
+			err := ApplyRules(&room, tt.rules)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if room.Category != tt.want.Category || room.Lang != tt.want.Lang ||
+				room.RoundSeconds != tt.want.RoundSeconds || room.WinningScore != tt.want.WinningScore {
+				t.Errorf("Expected %+v, got %+v", tt.want, room)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yaroslav/elias/internal/models"
+)
+
+type SummaryService struct {
+	pool        *pgxpool.Pool
+	gameService *GameService
+	roomService *RoomService
+}
+
+func NewSummaryService(pool *pgxpool.Pool, gameService *GameService, roomService *RoomService) *SummaryService {
+	return &SummaryService{pool: pool, gameService: gameService, roomService: roomService}
+}
+
+// playedWord is a row of round_words in play order.
+type playedWord struct {
+	RoundNum    int
+	WordID      int
+	Word        string
+	Guessed     bool
+	ExplainerID int64
+}
+
+// GetSummary sums up the game of the room. Team scores come from the game
+// state, or from round_words once the state has expired.
+func (s *SummaryService) GetSummary(ctx context.Context, roomID uuid.UUID) (*models.GameSummary, error) {
+	var teamsJSON []byte
+	err := s.pool.QueryRow(ctx, `SELECT teams FROM rooms WHERE id = $1`, roomID).Scan(&teamsJSON)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRoomNotFound
+		}
+		return nil, err
+	}
+	var teams []models.Team
+	if len(teamsJSON) > 0 {
+		if err := json.Unmarshal(teamsJSON, &teams); err != nil {
+			return nil, err
+		}
+	}
+
+	players, err := s.roomService.GetRoomPlayers(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	words, err := s.playedWords(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	teamScores, err := s.teamScores(ctx, roomID, teams)
+	if err != nil {
+		return nil, err
+	}
+
+	guessRates, err := s.guessRates(ctx, words)
+	if err != nil {
+		return nil, err
+	}
+
+	return summarize(roomID, teams, teamScores, players, words, guessRates), nil
+}
+
+func (s *SummaryService) teamScores(ctx context.Context, roomID uuid.UUID, teams []models.Team) (map[string]int, error) {
+	state, err := s.gameService.GetGameState(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if state != nil {
+		return state.TeamScores, nil
+	}
+
+	zero := make(map[string]int, len(teams))
+	for _, team := range teams {
+		zero[team.ID] = 0
+	}
+	return countTeamScores(ctx, s.pool, roomID, zero)
+}
+
+func (s *SummaryService) playedWords(ctx context.Context, roomID uuid.UUID) ([]*playedWord, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT rw.round_num, rw.word_id, w.word, rw.guessed, COALESCE(rw.explainer_id, 0)
+		FROM round_words rw JOIN words w ON w.id = rw.word_id
+		WHERE rw.room_id = $1
+		ORDER BY rw.created_at, rw.id
+	`, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var words []*playedWord
+	for rows.Next() {
+		var w playedWord
+		if err := rows.Scan(&w.RoundNum, &w.WordID, &w.Word, &w.Guessed, &w.ExplainerID); err != nil {
+			return nil, err
+		}
+		words = append(words, &w)
+	}
+	return words, rows.Err()
+}
+
+// guessRates returns how often each of the words was guessed over all games.
+func (s *SummaryService) guessRates(ctx context.Context, words []*playedWord) (map[int]float64, error) {
+	ids := make([]int, 0, len(words))
+	for _, w := range words {
+		if w.Guessed {
+			ids = append(ids, w.WordID)
+		}
+	}
+	rates := make(map[int]float64, len(ids))
+	if len(ids) == 0 {
+		return rates, nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT word_id, AVG(guessed::int)::float8
+		FROM round_words
+		WHERE word_id = ANY($1)
+		GROUP BY word_id
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var rate float64
+		if err := rows.Scan(&id, &rate); err != nil {
+			return nil, err
+		}
+		rates[id] = rate
+	}
+	return rates, rows.Err()
+}
+
+// summarize builds the summary from the played words in play order. Ties are
+// broken in favour of what happened first.
+func summarize(roomID uuid.UUID, teams []models.Team, teamScores map[string]int, players []*models.Player, words []*playedWord, guessRates map[int]float64) *models.GameSummary {
+	summary := &models.GameSummary{
+		RoomID:    roomID,
+		Standings: standings(teams, teamScores),
+		Players:   make([]*models.PlayerSummary, 0, len(players)),
+	}
+	if len(summary.Standings) > 0 && (len(summary.Standings) == 1 || summary.Standings[1].Place > 1) {
+		summary.Winner = summary.Standings[0].Team
+	}
+
+	byUser := make(map[int64]*models.PlayerSummary, len(players))
+	for _, p := range players {
+		ps := &models.PlayerSummary{UserID: p.UserID, FirstName: p.FirstName, Team: p.Team}
+		byUser[p.UserID] = ps
+		summary.Players = append(summary.Players, ps)
+	}
+
+	roundGuessed := make(map[int]int)
+	roundExplainers := make(map[int64]map[int]bool)
+	streak := 0
+	for i, w := range words {
+		// A streak goes on while the same explainer keeps getting words
+		// guessed in the same round
+		if i > 0 && (words[i-1].RoundNum != w.RoundNum || words[i-1].ExplainerID != w.ExplainerID) {
+			streak = 0
+		}
+		if w.Guessed {
+			streak++
+			roundGuessed[w.RoundNum]++
+		} else {
+			streak = 0
+		}
+
+		if w.Guessed {
+			if best := summary.BestRound; best == nil || roundGuessed[w.RoundNum] > best.WordsGuessed {
+				summary.BestRound = &models.RoundHighlight{RoundNum: w.RoundNum, ExplainerID: w.ExplainerID, WordsGuessed: roundGuessed[w.RoundNum]}
+			}
+			if longest := summary.LongestStreak; longest == nil || streak > longest.Length {
+				summary.LongestStreak = &models.StreakHighlight{RoundNum: w.RoundNum, ExplainerID: w.ExplainerID, Length: streak}
+			}
+
+			rate, ok := guessRates[w.WordID]
+			if !ok {
+				rate = 1
+			}
+			if hardest := summary.HardestWord; hardest == nil || rate < hardest.GuessRate {
+				summary.HardestWord = &models.WordHighlight{WordID: w.WordID, Word: w.Word, RoundNum: w.RoundNum, ExplainerID: w.ExplainerID, GuessRate: rate}
+			}
+		}
+
+		ps, ok := byUser[w.ExplainerID]
+		if !ok {
+			// Logged before explainers were recorded, or the player left
+			continue
+		}
+		if w.Guessed {
+			ps.WordsGuessed++
+			if streak > ps.LongestStreak {
+				ps.LongestStreak = streak
+			}
+		} else {
+			ps.WordsMissed++
+		}
+		if roundExplainers[w.ExplainerID] == nil {
+			roundExplainers[w.ExplainerID] = make(map[int]bool)
+		}
+		roundExplainers[w.ExplainerID][w.RoundNum] = true
+	}
+
+	var mvp *models.PlayerSummary
+	for _, ps := range summary.Players {
+		ps.RoundsExplained = len(roundExplainers[ps.UserID])
+		if total := ps.WordsGuessed + ps.WordsMissed; total > 0 {
+			ps.Efficiency = float64(ps.WordsGuessed) / float64(total)
+		}
+		if ps.WordsGuessed == 0 {
+			continue
+		}
+		if mvp == nil || ps.WordsGuessed > mvp.WordsGuessed ||
+			(ps.WordsGuessed == mvp.WordsGuessed && ps.Efficiency > mvp.Efficiency) {
+			mvp = ps
+		}
+	}
+	if mvp != nil {
+		summary.MVPID = mvp.UserID
+	}
+
+	return summary
+}
+
+// standings orders the teams by score. Teams with the same score share a
+// place and keep their room order.
+func standings(teams []models.Team, teamScores map[string]int) []*models.TeamStanding {
+	result := make([]*models.TeamStanding, 0, len(teams))
+	for _, team := range teams {
+		result = append(result, &models.TeamStanding{Team: team.ID, Name: team.Name, Score: teamScores[team.ID]})
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Score > result[j].Score })
+
+	for i, st := range result {
+		st.Place = i + 1
+		if i > 0 && st.Score == result[i-1].Score {
+			st.Place = result[i-1].Place
+		}
+	}
+	return result
+}
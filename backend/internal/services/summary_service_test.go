@@ -0,0 +1,123 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/yaroslav/elias/internal/models"
+)
+
+func TestStandings(t *testing.T) {
+	teams := []models.Team{{ID: "a", Name: "Foxes"}, {ID: "b", Name: "Owls"}, {ID: "c", Name: "Bees"}}
+
+	tests := []struct {
+		name   string
+		scores map[string]int
+		want   []string
+		places []int
+	}{
+		{"ordered by score", map[string]int{"a": 3, "b": 7, "c": 5}, []string{"b", "c", "a"}, []int{1, 2, 3}},
+		{"tie shares place", map[string]int{"a": 5, "b": 5, "c": 1}, []string{"a", "b", "c"}, []int{1, 1, 3}},
+		{"missing score is zero", map[string]int{"b": 1}, []string{"b", "a", "c"}, []int{1, 2, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := standings(teams, tt.scores)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %d teams, got %d", len(tt.want), len(got))
+			}
+			for i, st := range got {
+				if st.Team != tt.want[i] || st.Place != tt.places[i] {
+					t.Errorf("Expected %s at place %d, got %s at place %d", tt.want[i], tt.places[i], st.Team, st.Place)
+				}
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	roomID := uuid.New()
+	teams := []models.Team{{ID: "a", Name: "Foxes"}, {ID: "b", Name: "Owls"}}
+	players := []*models.Player{
+		{UserID: 1, FirstName: "Ann", Team: "a"},
+		{UserID: 2, FirstName: "Bob", Team: "b"},
+		{UserID: 3, FirstName: "Cid", Team: "a"},
+	}
+	words := []*playedWord{
+		// Round 1, Ann: 2 guessed, 1 missed, 1 guessed
+		{RoundNum: 1, WordID: 1, Word: "cat", Guessed: true, ExplainerID: 1},
+		{RoundNum: 1, WordID: 2, Word: "dog", Guessed: true, ExplainerID: 1},
+		{RoundNum: 1, WordID: 3, Word: "owl", Guessed: false, ExplainerID: 1},
+		{RoundNum: 1, WordID: 4, Word: "sun", Guessed: true, ExplainerID: 1},
+		// Round 2, Bob: 3 guessed in a row
+		{RoundNum: 2, WordID: 5, Word: "sea", Guessed: true, ExplainerID: 2},
+		{RoundNum: 2, WordID: 6, Word: "sky", Guessed: true, ExplainerID: 2},
+		{RoundNum: 2, WordID: 7, Word: "map", Guessed: true, ExplainerID: 2},
+		// Round 3, Ann: a streak does not carry over from round 1
+		{RoundNum: 3, WordID: 8, Word: "axe", Guessed: true, ExplainerID: 1},
+	}
+	guessRates := map[int]float64{1: 0.9, 2: 0.2, 4: 0.5, 5: 0.8, 6: 0.8, 7: 0.2, 8: 0.7}
+
+	s := summarize(roomID, teams, map[string]int{"a": 4, "b": 3}, players, words, guessRates)
+
+	if s.Winner != "a" {
+		t.Errorf("Expected winner a, got %q", s.Winner)
+	}
+	if s.MVPID != 1 {
+		// Ann got 4 words guessed, Bob 3 without a miss
+		t.Errorf("Expected MVP 1, got %d", s.MVPID)
+	}
+
+	ann, bob, cid := s.Players[0], s.Players[1], s.Players[2]
+	if ann.WordsGuessed != 4 || ann.WordsMissed != 1 || ann.RoundsExplained != 2 || ann.LongestStreak != 2 {
+		t.Errorf("Expected Ann 4/1 over 2 rounds with streak 2, got %d/%d over %d with streak %d", ann.WordsGuessed, ann.WordsMissed, ann.RoundsExplained, ann.LongestStreak)
+	}
+	if ann.Efficiency != 0.8 {
+		t.Errorf("Expected Ann efficiency 0.8, got %v", ann.Efficiency)
+	}
+	if bob.WordsGuessed != 3 || bob.Efficiency != 1 || bob.LongestStreak != 3 {
+		t.Errorf("Expected Bob 3 guessed at 1.0 with streak 3, got %d at %v with streak %d", bob.WordsGuessed, bob.Efficiency, bob.LongestStreak)
+	}
+	if cid.WordsGuessed != 0 || cid.Efficiency != 0 || cid.RoundsExplained != 0 {
+		t.Errorf("Expected Cid with nothing explained, got %+v", cid)
+	}
+
+	if s.BestRound == nil || s.BestRound.RoundNum != 1 || s.BestRound.WordsGuessed != 3 {
+		// Rounds 1 and 2 both have 3 guessed; the earlier one wins
+		t.Errorf("Expected best round 1 with 3 guessed, got %+v", s.BestRound)
+	}
+	if s.LongestStreak == nil || s.LongestStreak.ExplainerID != 2 || s.LongestStreak.Length != 3 {
+		t.Errorf("Expected Bob's streak of 3, got %+v", s.LongestStreak)
+	}
+	if s.HardestWord == nil || s.HardestWord.WordID != 2 {
+		// dog and map share the lowest rate; dog came first
+		t.Errorf("Expected hardest word dog, got %+v", s.HardestWord)
+	}
+}
+
+func TestSummarizeNoWords(t *testing.T) {
+	teams := []models.Team{{ID: "a"}, {ID: "b"}}
+	s := summarize(uuid.New(), teams, map[string]int{"a": 0, "b": 0}, []*models.Player{{UserID: 1, Team: "a"}}, nil, nil)
+
+	if s.Winner != "" || s.MVPID != 0 {
+		t.Errorf("Expected no winner and no MVP, got %q and %d", s.Winner, s.MVPID)
+	}
+	if s.BestRound != nil || s.LongestStreak != nil || s.HardestWord != nil {
+		t.Errorf("Expected no highlights, got %+v %+v %+v", s.BestRound, s.LongestStreak, s.HardestWord)
+	}
+}
+
+func TestSummarizeMVPTieBreak(t *testing.T) {
+	players := []*models.Player{{UserID: 1, Team: "a"}, {UserID: 2, Team: "b"}}
+	words := []*playedWord{
+		{RoundNum: 1, WordID: 1, Guessed: true, ExplainerID: 1},
+		{RoundNum: 1, WordID: 2, Guessed: false, ExplainerID: 1},
+		{RoundNum: 2, WordID: 3, Guessed: true, ExplainerID: 2},
+	}
+
+	s := summarize(uuid.New(), nil, nil, players, words, nil)
+	if s.MVPID != 2 {
+		t.Errorf("Expected the more efficient explainer 2 as MVP, got %d", s.MVPID)
+	}
+}
@@ -101,30 +101,89 @@ type TeamNamePair struct {
 	Gender    Gender
 }
 
-// GenerateTeamName генерирует случайное название команды (прилагательное + существительное)
-func GenerateTeamName() string {
+// TeamNameGenerator генерирует случайные названия команд для одного языка.
+// У каждого языка свои правила грамматики: в русском и украинском
+// прилагательное согласуется с существительным по роду, в английском — нет.
+type TeamNameGenerator interface {
+	Lang() string
+	Generate() string
+}
+
+// genderedGenerator согласует прилагательное с существительным по роду
+type genderedGenerator struct {
+	lang       string
+	adjectives map[Gender][]string
+	nouns      map[Gender][]string
+}
+
+func (g *genderedGenerator) Lang() string {
+	return g.lang
+}
+
+func (g *genderedGenerator) Generate() string {
 	// Выбираем случайный род
 	gender := Gender(rand.Intn(3))
 
-	var adjective, noun string
-
-	switch gender {
-	case Masculine:
-		adjective = adjectivesMasc[rand.Intn(len(adjectivesMasc))]
-		noun = nounsMasc[rand.Intn(len(nounsMasc))]
-	case Feminine:
-		adjective = adjectivesFem[rand.Intn(len(adjectivesFem))]
-		noun = nounsFem[rand.Intn(len(nounsFem))]
-	case Neuter:
-		adjective = adjectivesNeut[rand.Intn(len(adjectivesNeut))]
-		noun = nounsNeut[rand.Intn(len(nounsNeut))]
+	adjectives := g.adjectives[gender]
+	nouns := g.nouns[gender]
+	return adjectives[rand.Intn(len(adjectives))] + " " + nouns[rand.Intn(len(nouns))]
+}
+
+var russianTeamNames = &genderedGenerator{
+	lang: "ru",
+	adjectives: map[Gender][]string{
+		Masculine: adjectivesMasc,
+		Feminine:  adjectivesFem,
+		Neuter:    adjectivesNeut,
+	},
+	nouns: map[Gender][]string{
+		Masculine: nounsMasc,
+		Feminine:  nounsFem,
+		Neuter:    nounsNeut,
+	},
+}
+
+// DefaultLang — язык комнаты по умолчанию
+const DefaultLang = "ru"
+
+var teamNameGenerators = map[string]TeamNameGenerator{
+	russianTeamNames.Lang():   russianTeamNames,
+	ukrainianTeamNames.Lang(): ukrainianTeamNames,
+	englishTeamNames.Lang():   englishTeamNames,
+}
+
+// RegisterTeamNameGenerator добавляет генератор для нового языка.
+// Вызывать только при инициализации пакета.
+func RegisterTeamNameGenerator(g TeamNameGenerator) {
+	teamNameGenerators[g.Lang()] = g
+}
+
+// IsSupportedLang проверяет, есть ли генератор названий для языка
+func IsSupportedLang(lang string) bool {
+	_, ok := teamNameGenerators[lang]
+	return ok
+}
+
+// TeamNameGeneratorFor возвращает генератор для языка, для неизвестных — русский
+func TeamNameGeneratorFor(lang string) TeamNameGenerator {
+	if g, ok := teamNameGenerators[lang]; ok {
+		return g
 	}
+	return teamNameGenerators[DefaultLang]
+}
 
-	return adjective + " " + noun
+// GenerateTeamName генерирует случайное русское название команды (прилагательное + существительное)
+func GenerateTeamName() string {
+	return russianTeamNames.Generate()
 }
 
-// GenerateUniqueTeamNames генерирует N уникальных названий команд
+// GenerateUniqueTeamNames генерирует N уникальных русских названий команд
 func GenerateUniqueTeamNames(count int) []string {
+	return GenerateUniqueTeamNamesFor(russianTeamNames, count)
+}
+
+// GenerateUniqueTeamNamesFor генерирует N уникальных названий команд генератором g
+func GenerateUniqueTeamNamesFor(g TeamNameGenerator, count int) []string {
 	names := make([]string, 0, count)
 	used := make(map[string]bool)
 
@@ -132,7 +191,7 @@ func GenerateUniqueTeamNames(count int) []string {
 	attempts := 0
 
 	for len(names) < count && attempts < maxAttempts {
-		name := GenerateTeamName()
+		name := g.Generate()
 		if !used[name] {
 			used[name] = true
 			names = append(names, name)
@@ -142,8 +201,30 @@ func GenerateUniqueTeamNames(count int) []string {
 
 	// Если не хватило уникальных названий, добавляем с номерами
 	for len(names) < count {
-		names = append(names, GenerateTeamName()+" "+string(rune('A'+len(names))))
+		names = append(names, g.Generate()+" "+string(rune('A'+len(names))))
 	}
 
 	return names
 }
+
+// GenerateTeamNameExcluding генерирует русское название команды, которого нет среди used
+func GenerateTeamNameExcluding(used []string) string {
+	return GenerateTeamNameExcludingFor(russianTeamNames, used)
+}
+
+// GenerateTeamNameExcludingFor генерирует название генератором g, которого нет среди used
+func GenerateTeamNameExcludingFor(g TeamNameGenerator, used []string) string {
+	taken := make(map[string]bool, len(used))
+	for _, name := range used {
+		taken[name] = true
+	}
+
+	for attempts := 0; attempts < 100; attempts++ {
+		name := g.Generate()
+		if !taken[name] {
+			return name
+		}
+	}
+
+	return g.Generate() + " " + string(rune('A'+len(used)))
+}
@@ -0,0 +1,51 @@
+package services
+
+import "math/rand"
+
+// English adjectives have no gender, any adjective goes with any noun
+var enAdjectives = []string{
+	"Fast", "Lazy", "Sad", "Happy", "Mighty",
+	"Clever", "Silly", "Grumpy", "Sneaky", "Brave",
+	"Fancy", "Hungry", "Sleepy", "Fluffy", "Spiky",
+	"Wild", "Flying", "Dancing", "Rusty", "Shiny",
+	"Loud", "Quiet", "Crispy", "Soggy", "Cosmic",
+	"Electric", "Funky", "Jolly", "Tiny", "Giant",
+	"Wobbly", "Nervous", "Dizzy", "Mysterious", "Invisible",
+	"Furious", "Curious", "Salty", "Spicy", "Frozen",
+}
+
+// Plural nouns read naturally as team names ("Sleepy Penguins")
+var enNouns = []string{
+	"Hippos", "Crocodiles", "Giraffes", "Elephants", "Tigers",
+	"Lions", "Bears", "Wolves", "Rabbits", "Hedgehogs",
+	"Cats", "Dogs", "Hamsters", "Raccoons", "Penguins",
+	"Dolphins", "Whales", "Octopuses", "Crabs", "Pandas",
+	"Cobras", "Sharks", "Turtles", "Monkeys", "Foxes",
+	"Squirrels", "Owls", "Ducks", "Bees", "Llamas",
+	"Pickles", "Tomatoes", "Bananas", "Pineapples", "Pancakes",
+	"Waffles", "Noodles", "Teapots", "Toasters", "Pencils",
+	"Rockets", "Unicorns", "Dragons", "Potatoes", "Muffins",
+	"Nuggets", "Donuts", "Robots", "Ninjas", "Wizards",
+}
+
+// simpleGenerator pairs a random adjective with a random noun without any
+// agreement rules.
+type simpleGenerator struct {
+	lang       string
+	adjectives []string
+	nouns      []string
+}
+
+func (g *simpleGenerator) Lang() string {
+	return g.lang
+}
+
+func (g *simpleGenerator) Generate() string {
+	return g.adjectives[rand.Intn(len(g.adjectives))] + " " + g.nouns[rand.Intn(len(g.nouns))]
+}
+
+var englishTeamNames = &simpleGenerator{
+	lang:       "en",
+	adjectives: enAdjectives,
+	nouns:      enNouns,
+}
@@ -130,3 +130,65 @@ func TestGenderArraysNotEmpty(t *testing.T) {
 		t.Error("nounsNeut is empty")
 	}
 }
+
+func TestTeamNameGeneratorFor(t *testing.T) {
+	for _, lang := range []string{"ru", "uk", "en"} {
+		t.Run(lang, func(t *testing.T) {
+			if !IsSupportedLang(lang) {
+				t.Fatalf("Expected %s to be supported", lang)
+			}
+			if got := TeamNameGeneratorFor(lang).Lang(); got != lang {
+				t.Errorf("Expected generator for %s, got %s", lang, got)
+			}
+		})
+	}
+
+	if IsSupportedLang("xx") {
+		t.Error("Unknown language should not be supported")
+	}
+	if got := TeamNameGeneratorFor("xx").Lang(); got != DefaultLang {
+		t.Errorf("Unknown language should fall back to %s, got %s", DefaultLang, got)
+	}
+}
+
+func TestGenerateUniqueTeamNamesForAllLanguages(t *testing.T) {
+	for lang, g := range teamNameGenerators {
+		t.Run(lang, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				names := GenerateUniqueTeamNamesFor(g, MaxTeams)
+				if len(names) != MaxTeams {
+					t.Fatalf("Expected %d names, got %d", MaxTeams, len(names))
+				}
+
+				seen := make(map[string]bool)
+				for _, name := range names {
+					if seen[name] {
+						t.Errorf("Duplicate name found: %s", name)
+					}
+					seen[name] = true
+
+					if parts := strings.Split(name, " "); len(parts) != 2 {
+						t.Errorf("Generated name should have exactly 2 parts, got %d: %s", len(parts), name)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestUkrainianGenderAgreement(t *testing.T) {
+	// Adjective lists are parallel: the same adjective in three genders
+	if len(ukAdjectivesMasc) != len(ukAdjectivesFem) || len(ukAdjectivesMasc) != len(ukAdjectivesNeut) {
+		t.Fatalf("Ukrainian adjective lists differ in length: %d/%d/%d",
+			len(ukAdjectivesMasc), len(ukAdjectivesFem), len(ukAdjectivesNeut))
+	}
+
+	for gender, nouns := range ukrainianTeamNames.nouns {
+		if len(nouns) == 0 {
+			t.Errorf("No Ukrainian nouns for gender %d", gender)
+		}
+		if len(ukrainianTeamNames.adjectives[gender]) == 0 {
+			t.Errorf("No Ukrainian adjectives for gender %d", gender)
+		}
+	}
+}
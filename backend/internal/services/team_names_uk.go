@@ -0,0 +1,81 @@
+package services
+
+// Прикметники (чоловічий рід)
+var ukAdjectivesMasc = []string{
+	"Швидкий", "Лінивий", "Сумний", "Веселий", "Сильний",
+	"Розумний", "Хитрий", "Чесний", "Хоробрий", "Багатий",
+	"Товстий", "Високий", "Гарний", "Страшний", "Молодий",
+	"Старий", "Голодний", "Ситий", "Холодний", "Гарячий",
+	"Мокрий", "Сухий", "Чистий", "Яскравий", "Темний",
+	"Гучний", "Тихий", "Пухнастий", "Колючий", "Дикий",
+	"Добрий", "Злий", "Летючий", "Сонний", "Хвацький",
+}
+
+// Прикметники (жіночий рід)
+var ukAdjectivesFem = []string{
+	"Швидка", "Лінива", "Сумна", "Весела", "Сильна",
+	"Розумна", "Хитра", "Чесна", "Хоробра", "Багата",
+	"Товста", "Висока", "Гарна", "Страшна", "Молода",
+	"Стара", "Голодна", "Сита", "Холодна", "Гаряча",
+	"Мокра", "Суха", "Чиста", "Яскрава", "Темна",
+	"Гучна", "Тиха", "Пухнаста", "Колюча", "Дика",
+	"Добра", "Зла", "Летюча", "Сонна", "Хвацька",
+}
+
+// Прикметники (середній рід)
+var ukAdjectivesNeut = []string{
+	"Швидке", "Ліниве", "Сумне", "Веселе", "Сильне",
+	"Розумне", "Хитре", "Чесне", "Хоробре", "Багате",
+	"Товсте", "Високе", "Гарне", "Страшне", "Молоде",
+	"Старе", "Голодне", "Сите", "Холодне", "Гаряче",
+	"Мокре", "Сухе", "Чисте", "Яскраве", "Темне",
+	"Гучне", "Тихе", "Пухнасте", "Колюче", "Дике",
+	"Добре", "Зле", "Летюче", "Сонне", "Хвацьке",
+}
+
+// Іменники (чоловічий рід)
+var ukNounsMasc = []string{
+	"Бегемот", "Крокодил", "Жираф", "Слон", "Тигр",
+	"Лев", "Ведмідь", "Вовк", "Заєць", "Їжак",
+	"Кіт", "Пес", "Хом'як", "Єнот", "Пінгвін",
+	"Дельфін", "Кит", "Восьминіг", "Краб", "Тарган",
+	"Комар", "Павук", "Жук", "Пиріг", "Млинець",
+	"Борщ", "Вареник", "Огірок", "Помідор", "Кавун",
+	"Чайник", "Холодильник", "Пилосос", "Олівець", "Глобус",
+	"Літак", "Гелікоптер", "Корабель", "Потяг", "Автобус",
+}
+
+// Іменники (жіночий рід)
+var ukNounsFem = []string{
+	"Панда", "Кобра", "Акула", "Черепаха", "Мавпа",
+	"Лисиця", "Білка", "Миша", "Змія", "Сова",
+	"Ворона", "Синиця", "Курка", "Качка", "Бабка",
+	"Бджола", "Муха", "Морква", "Картопля", "Капуста",
+	"Груша", "Слива", "Вишня", "Полуниця", "Малина",
+	"Ложка", "Виделка", "Каструля", "Сковорідка", "Тарілка",
+	"Лампа", "Праска", "Книжка", "Ракета", "Карета",
+}
+
+// Іменники (середній рід)
+var ukNounsNeut = []string{
+	"Чудовисько", "Створіння", "Сонце", "Море", "Озеро",
+	"Болото", "Поле", "Небо", "Яблуко", "Яйце",
+	"Молоко", "Тісто", "Відро", "Колесо", "Крило",
+	"Дзеркало", "Вікно", "Мило", "Пальто", "Кільце",
+	"Диво", "Щастя", "Слово", "Місто", "Серце",
+	"Кошеня", "Цуценя", "Слоненя", "Порося", "Каченя",
+}
+
+var ukrainianTeamNames = &genderedGenerator{
+	lang: "uk",
+	adjectives: map[Gender][]string{
+		Masculine: ukAdjectivesMasc,
+		Feminine:  ukAdjectivesFem,
+		Neuter:    ukAdjectivesNeut,
+	},
+	nouns: map[Gender][]string{
+		Masculine: ukNounsMasc,
+		Feminine:  ukNounsFem,
+		Neuter:    ukNounsNeut,
+	},
+}
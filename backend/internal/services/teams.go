@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yaroslav/elias/internal/models"
+)
+
+const (
+	MinTeams = 2
+	MaxTeams = 5
+
+	MinTeamNameLength = 2
+	MaxTeamNameLength = 32
+)
+
+var (
+	ErrTeamNotFound     = errors.New("team not found")
+	ErrTeamNameTooShort = errors.New("team name is too short")
+	ErrTeamNameTooLong  = errors.New("team name is too long")
+	ErrTeamNameProfane  = errors.New("team name contains forbidden words")
+	ErrTeamNameTaken    = errors.New("team name is already taken")
+	ErrInvalidNumTeams  = errors.New("invalid number of teams")
+	ErrNotTeamEditor    = errors.New("only host or team members can edit the team")
+)
+
+// NewTeams assigns stable ids (t1, t2, ...) to the given team names.
+func NewTeams(names []string) []models.Team {
+	teams := make([]models.Team, 0, len(names))
+	for _, name := range names {
+		teams = append(teams, models.Team{ID: nextTeamID(teams), Name: name})
+	}
+	return teams
+}
+
+// nextTeamID returns the smallest tN id not used by teams.
+func nextTeamID(teams []models.Team) string {
+	used := make(map[string]bool, len(teams))
+	for _, t := range teams {
+		used[t.ID] = true
+	}
+	for i := 1; ; i++ {
+		id := "t" + strconv.Itoa(i)
+		if !used[id] {
+			return id
+		}
+	}
+}
+
+// TeamNamesOf returns the display names of teams in order.
+func TeamNamesOf(teams []models.Team) []string {
+	names := make([]string, 0, len(teams))
+	for _, t := range teams {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// FindTeam returns the index of the team with the given id, or -1.
+func FindTeam(teams []models.Team, id string) int {
+	for i, t := range teams {
+		if t.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// ValidateTeamName normalizes a user-supplied team name and checks its length
+// and content. The returned name is what should be stored.
+func ValidateTeamName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+
+	length := utf8.RuneCountInString(name)
+	if length < MinTeamNameLength {
+		return "", ErrTeamNameTooShort
+	}
+	if length > MaxTeamNameLength {
+		return "", ErrTeamNameTooLong
+	}
+	if containsProfanity(name) {
+		return "", ErrTeamNameProfane
+	}
+	return name, nil
+}
+
+// teamNameTaken reports whether another team (other than exceptID) already
+// uses name, ignoring case.
+func teamNameTaken(teams []models.Team, name, exceptID string) bool {
+	for _, t := range teams {
+		if t.ID != exceptID && strings.EqualFold(t.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsProfanity checks every word of name against the blocklist roots.
+func containsProfanity(name string) bool {
+	normalized := strings.ReplaceAll(strings.ToLower(name), "ё", "е")
+	words := strings.FieldsFunc(normalized, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		for _, root := range profanityRoots {
+			if strings.HasPrefix(word, root) {
+				return true
+			}
+		}
+	}
+	return false
+}
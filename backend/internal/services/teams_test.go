@@ -0,0 +1,95 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yaroslav/elias/internal/models"
+)
+
+func TestNewTeamsAssignsStableIDs(t *testing.T) {
+	teams := NewTeams([]string{"Быстрый Кот", "Умная Сова", "Доброе Облако"})
+
+	want := []string{"t1", "t2", "t3"}
+	if len(teams) != len(want) {
+		t.Fatalf("Expected %d teams, got %d", len(want), len(teams))
+	}
+	for i, team := range teams {
+		if team.ID != want[i] {
+			t.Errorf("Team %d: expected id %s, got %s", i, want[i], team.ID)
+		}
+	}
+}
+
+func TestNextTeamIDReusesGaps(t *testing.T) {
+	teams := []models.Team{{ID: "t1"}, {ID: "t3"}}
+	if id := nextTeamID(teams); id != "t2" {
+		t.Errorf("Expected t2, got %s", id)
+	}
+}
+
+func TestFindTeam(t *testing.T) {
+	teams := NewTeams([]string{"Быстрый Кот", "Умная Сова"})
+
+	if idx := FindTeam(teams, "t2"); idx != 1 {
+		t.Errorf("Expected index 1, got %d", idx)
+	}
+	if idx := FindTeam(teams, "Умная Сова"); idx != -1 {
+		t.Errorf("Lookup by name should fail, got index %d", idx)
+	}
+}
+
+func TestValidateTeamName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{"Valid name", "Ночные Совы", "Ночные Совы", nil},
+		{"Collapses whitespace", "  Ночные   Совы ", "Ночные Совы", nil},
+		{"Too short", " Я ", "", ErrTeamNameTooShort},
+		{"Empty", "", "", ErrTeamNameTooShort},
+		{"Too long", strings.Repeat("я", MaxTeamNameLength+1), "", ErrTeamNameTooLong},
+		{"Max length in runes", strings.Repeat("я", MaxTeamNameLength), strings.Repeat("я", MaxTeamNameLength), nil},
+		{"Profanity", "Злые Пиздюки", "", ErrTeamNameProfane},
+		{"Profanity with yo", "Ёбаные Коты", "", ErrTeamNameProfane},
+		{"English profanity", "Fucking Cats", "", ErrTeamNameProfane},
+		{"Root inside a word is allowed", "Барсуками", "Барсуками", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateTeamName(tt.input)
+			if err != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTeamNameTaken(t *testing.T) {
+	teams := NewTeams([]string{"Быстрый Кот", "Умная Сова"})
+
+	if !teamNameTaken(teams, "умная сова", "t1") {
+		t.Error("Expected case-insensitive clash with another team")
+	}
+	if teamNameTaken(teams, "Умная Сова", "t2") {
+		t.Error("Renaming a team to its own name should be allowed")
+	}
+}
+
+func TestGenerateTeamNameExcluding(t *testing.T) {
+	used := GenerateUniqueTeamNames(4)
+	for i := 0; i < 100; i++ {
+		name := GenerateTeamNameExcluding(used)
+		for _, u := range used {
+			if name == u {
+				t.Fatalf("Generated name %q clashes with used names", name)
+			}
+		}
+	}
+}
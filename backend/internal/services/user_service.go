@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yaroslav/elias/internal/models"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+type UserService struct {
+	pool *pgxpool.Pool
+}
+
+func NewUserService(pool *pgxpool.Pool) *UserService {
+	return &UserService{pool: pool}
+}
+
+// Upsert stores the user as Telegram last described them.
+func (s *UserService) Upsert(ctx context.Context, user *models.TelegramUser) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO users (id, username, first_name, last_name, photo_url)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET
+			username = EXCLUDED.username,
+			first_name = EXCLUDED.first_name,
+			last_name = EXCLUDED.last_name,
+			photo_url = EXCLUDED.photo_url,
+			last_seen_at = NOW()
+	`, user.ID, user.Username, user.FirstName, user.LastName, user.PhotoURL)
+	return err
+}
+
+func (s *UserService) GetUser(ctx context.Context, userID int64) (*models.User, error) {
+	var user models.User
+	err := s.pool.QueryRow(ctx, `
+		SELECT id, COALESCE(username, ''), COALESCE(first_name, ''), COALESCE(last_name, ''), COALESCE(photo_url, ''), created_at, last_seen_at
+		FROM users WHERE id = $1
+	`, userID).Scan(&user.ID, &user.Username, &user.FirstName, &user.LastName, &user.PhotoURL, &user.CreatedAt, &user.LastSeenAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetStats returns the lifetime stats of the user over finished games. Words
+// explained are counted from the games that recorded their explainer.
+func (s *UserService) GetStats(ctx context.Context, userID int64) (*models.UserStats, error) {
+	if _, err := s.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	stats := &models.UserStats{UserID: userID}
+	err := s.pool.QueryRow(ctx, `
+		SELECT COUNT(*),
+			   COUNT(*) FILTER (WHERE r.winner IS NOT NULL AND r.winner = p.team)
+		FROM players p JOIN rooms r ON r.id = p.room_id
+		WHERE p.user_id = $1 AND r.status = $2
+	`, userID, models.RoomStatusFinished).Scan(&stats.GamesPlayed, &stats.Wins)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.pool.QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE guessed)
+		FROM round_words
+		WHERE explainer_id = $1
+	`, userID).Scan(&stats.WordsExplained, &stats.WordsGuessed)
+	if err != nil {
+		return nil, err
+	}
+	stats.GuessRate = guessRate(stats.WordsGuessed, stats.WordsExplained)
+
+	err = s.pool.QueryRow(ctx, `
+		SELECT r.category
+		FROM players p JOIN rooms r ON r.id = p.room_id
+		WHERE p.user_id = $1 AND r.status = $2
+		GROUP BY r.category
+		ORDER BY COUNT(*) DESC, r.category
+		LIMIT 1
+	`, userID, models.RoomStatusFinished).Scan(&stats.FavouriteCategory)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func guessRate(guessed, explained int) float64 {
+	if explained == 0 {
+		return 0
+	}
+	return float64(guessed) / float64(explained)
+}
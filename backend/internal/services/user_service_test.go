@@ -0,0 +1,24 @@
+package services
+
+import "testing"
+
+func TestGuessRate(t *testing.T) {
+	tests := []struct {
+		name      string
+		guessed   int
+		explained int
+		want      float64
+	}{
+		{"nothing explained", 0, 0, 0},
+		{"all guessed", 4, 4, 1},
+		{"half guessed", 3, 6, 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := guessRate(tt.guessed, tt.explained); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
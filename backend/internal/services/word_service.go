@@ -16,7 +16,21 @@ func NewWordService(pool *pgxpool.Pool) *WordService {
 	return &WordService{pool: pool}
 }
 
+// wordLangs are the languages with a word list in seeds/. Rooms in other
+// languages are dealt words in DefaultLang until a list for theirs is added.
+var wordLangs = map[string]bool{DefaultLang: true}
+
+// wordLang returns the language of the words dealt in a room of lang.
+func wordLang(lang string) string {
+	if wordLangs[lang] {
+		return lang
+	}
+	return DefaultLang
+}
+
 func (s *WordService) GetRandomWord(ctx context.Context, roomID uuid.UUID, lang string, category string) (*models.Word, error) {
+	lang = wordLang(lang)
+
 	var word models.Word
 	err := s.pool.QueryRow(ctx, `
 		SELECT id, word, lang, category FROM words
@@ -34,25 +48,10 @@ func (s *WordService) GetRandomWord(ctx context.Context, roomID uuid.UUID, lang
 	return &word, nil
 }
 
-func (s *WordService) RecordWordUsed(ctx context.Context, roomID uuid.UUID, wordID int, roundNum int, guessed bool) error {
-	_, err := s.pool.Exec(ctx, `
-		INSERT INTO round_words (room_id, word_id, round_num, guessed)
-		VALUES ($1, $2, $3, $4)
-	`, roomID, wordID, roundNum, guessed)
-	return err
-}
-
-func (s *WordService) UpdateWordResult(ctx context.Context, roomID uuid.UUID, wordID int, guessed bool) error {
-	_, err := s.pool.Exec(ctx, `
-		UPDATE round_words SET guessed = $1
-		WHERE room_id = $2 AND word_id = $3
-	`, guessed, roomID, wordID)
-	return err
-}
-
 func (s *WordService) GetRoundStats(ctx context.Context, roomID uuid.UUID) ([]*models.RoundStats, error) {
 	rows, err := s.pool.Query(ctx, `
 		SELECT round_num,
+			   COALESCE(MAX(explainer_id), 0) as explainer_id,
 			   COUNT(*) FILTER (WHERE guessed = TRUE) as guessed,
 			   COUNT(*) FILTER (WHERE guessed = FALSE) as missed
 		FROM round_words
@@ -68,7 +67,7 @@ func (s *WordService) GetRoundStats(ctx context.Context, roomID uuid.UUID) ([]*m
 	var stats []*models.RoundStats
 	for rows.Next() {
 		var s models.RoundStats
-		if err := rows.Scan(&s.RoundNum, &s.WordsGuessed, &s.WordsMissed); err != nil {
+		if err := rows.Scan(&s.RoundNum, &s.ExplainerID, &s.WordsGuessed, &s.WordsMissed); err != nil {
 			return nil, err
 		}
 		stats = append(stats, &s)
@@ -76,6 +75,35 @@ func (s *WordService) GetRoundStats(ctx context.Context, roomID uuid.UUID) ([]*m
 	return stats, nil
 }
 
+// GetRoundSummary returns the words played in the given round in play order.
+func (s *WordService) GetRoundSummary(ctx context.Context, roomID uuid.UUID, roundNum int) (*models.RoundSummary, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT rw.word_id, w.word, rw.guessed
+		FROM round_words rw JOIN words w ON w.id = rw.word_id
+		WHERE rw.room_id = $1 AND rw.round_num = $2
+		ORDER BY rw.created_at, rw.id
+	`, roomID, roundNum)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary := &models.RoundSummary{RoundNum: roundNum, Words: []*models.RoundEntry{}}
+	for rows.Next() {
+		var e models.RoundEntry
+		if err := rows.Scan(&e.WordID, &e.Word, &e.Guessed); err != nil {
+			return nil, err
+		}
+		if e.Guessed {
+			summary.WordsGuessed++
+		} else {
+			summary.WordsMissed++
+		}
+		summary.Words = append(summary.Words, &e)
+	}
+	return summary, rows.Err()
+}
+
 func (s *WordService) SeedWords(ctx context.Context, words []string, lang string) error {
 	for _, word := range words {
 		_, err := s.pool.Exec(ctx, `
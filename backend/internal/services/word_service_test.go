@@ -0,0 +1,23 @@
+package services
+
+import "testing"
+
+func TestWordLang(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"ru", "ru"},
+		{"en", DefaultLang},
+		{"uk", DefaultLang},
+		{"", DefaultLang},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			if got := wordLang(tt.lang); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
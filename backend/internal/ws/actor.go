@@ -0,0 +1,127 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// actorIdleTimeout is how long a room actor waits for commands before it exits.
+const actorIdleTimeout = time.Minute
+
+var errHubClosed = errors.New("hub is closed")
+
+type roomCommand struct {
+	ctx  context.Context
+	fn   func(ctx context.Context) error
+	done chan error
+}
+
+// roomActor runs the game mutations of one room one at a time, so a swipe, a
+// round end fired by the timer and a command from an HTTP handler never
+// interleave their read-modify-write of the game state.
+//
+// Actors live apart from RoomHub: a room needs one even without local clients
+// (the timer owner, an HTTP request), and commands broadcast to the room,
+// which must not wait on the RoomHub loop.
+type roomActor struct {
+	roomID uuid.UUID
+	// commands is unbuffered, so a command is only ever taken by a running
+	// actor and cannot be lost when the actor exits
+	commands chan *roomCommand
+	stopped  chan struct{}
+}
+
+// do runs fn in the room's actor and waits for its result. fn must not call
+// do for the same room.
+func (h *Hub) do(ctx context.Context, roomID uuid.UUID, fn func(ctx context.Context) error) error {
+	cmd := &roomCommand{ctx: ctx, fn: fn, done: make(chan error, 1)}
+	for {
+		select {
+		case <-h.done:
+			return errHubClosed
+		default:
+		}
+
+		a := h.actor(roomID)
+		select {
+		case a.commands <- cmd:
+			return <-cmd.done
+		case <-a.stopped:
+			// Went idle in the meantime; start a new one
+		case <-h.done:
+			return errHubClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (h *Hub) actor(roomID uuid.UUID) *roomActor {
+	h.actorsMu.Lock()
+	defer h.actorsMu.Unlock()
+
+	if a, ok := h.actors[roomID]; ok {
+		return a
+	}
+	a := &roomActor{
+		roomID:   roomID,
+		commands: make(chan *roomCommand),
+		stopped:  make(chan struct{}),
+	}
+	h.actors[roomID] = a
+	go h.runActor(a)
+	return a
+}
+
+func (h *Hub) runActor(a *roomActor) {
+	idle := time.NewTimer(actorIdleTimeout)
+	defer idle.Stop()
+
+	for {
+		select {
+		case cmd := <-a.commands:
+			cmd.done <- runCommand(cmd)
+			if !idle.Stop() {
+				<-idle.C
+			}
+			idle.Reset(actorIdleTimeout)
+		case <-idle.C:
+			h.stopActor(a)
+			return
+		case <-h.done:
+			h.stopActor(a)
+			return
+		}
+	}
+}
+
+func (h *Hub) stopActor(a *roomActor) {
+	h.actorsMu.Lock()
+	defer h.actorsMu.Unlock()
+	delete(h.actors, a.roomID)
+	close(a.stopped)
+}
+
+// runCommand runs a command, turning a panic into an error so one bad command
+// does not take the room's actor down with it.
+func runCommand(cmd *roomCommand) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("command panicked: %v", r)
+		}
+	}()
+	return cmd.fn(cmd.ctx)
+}
+
+// background runs fn in the room's actor for callers that have nobody to
+// report the error to, such as timers and presence changes.
+func (h *Hub) background(roomID uuid.UUID, fn func(ctx context.Context) error) {
+	if err := h.do(context.Background(), roomID, fn); err != nil && !errors.Is(err, errHubClosed) {
+		log.Printf("Error in room %s: %v", roomID, err)
+	}
+}
@@ -0,0 +1,199 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestHub() *Hub {
+	return &Hub{
+		actors: make(map[uuid.UUID]*roomActor),
+		done:   make(chan struct{}),
+	}
+}
+
+// fakeGame mimics the game state blob: every mutation reads it, yields and
+// writes it back, like a GET/SET round trip to Redis.
+type fakeGame struct {
+	round     int
+	roundWord int
+	score     int
+	ended     []int
+}
+
+func (g *fakeGame) swipe() {
+	words, score := g.roundWord, g.score
+	runtime.Gosched()
+	g.roundWord, g.score = words+1, score+1
+}
+
+func (g *fakeGame) endRound(round int) {
+	if g.round != round {
+		// Someone else ended it already
+		return
+	}
+	words := g.roundWord
+	runtime.Gosched()
+	g.ended = append(g.ended, words)
+	g.round, g.roundWord = round+1, 0
+}
+
+func TestActorSerializesSwipesAndRoundEnds(t *testing.T) {
+	h := newTestHub()
+	defer close(h.done)
+
+	roomID := uuid.New()
+	game := &fakeGame{}
+
+	const players, swipes, rounds = 8, 50, 20
+	var wg sync.WaitGroup
+	for p := 0; p < players; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < swipes; i++ {
+				err := h.do(context.Background(), roomID, func(ctx context.Context) error {
+					game.swipe()
+					return nil
+				})
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+			}
+		}()
+	}
+	// Two timers fire for every round, as when two instances own it
+	for timer := 0; timer < 2; timer++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				h.background(roomID, func(ctx context.Context) error {
+					game.endRound(r)
+					return nil
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if game.score != players*swipes {
+		t.Errorf("Expected score %d, got %d", players*swipes, game.score)
+	}
+	if game.round != rounds {
+		t.Errorf("Expected %d rounds, got %d", rounds, game.round)
+	}
+	counted := game.roundWord
+	for _, words := range game.ended {
+		counted += words
+	}
+	if counted != players*swipes {
+		t.Errorf("Expected %d words over all rounds, got %d", players*swipes, counted)
+	}
+}
+
+func TestActorRoomsRunIndependently(t *testing.T) {
+	h := newTestHub()
+	defer close(h.done)
+
+	blocked := make(chan struct{})
+	release := make(chan struct{})
+	go h.do(context.Background(), uuid.New(), func(ctx context.Context) error {
+		close(blocked)
+		<-release
+		return nil
+	})
+	<-blocked
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ran := false
+	err := h.do(ctx, uuid.New(), func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	if err != nil || !ran {
+		t.Errorf("Expected other room to run while one is busy, got %v", err)
+	}
+}
+
+func TestActorDo(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name    string
+		fn      func(ctx context.Context) error
+		wantErr bool
+	}{
+		{"ok", func(ctx context.Context) error { return nil }, false},
+		{"error", func(ctx context.Context) error { return errBoom }, true},
+		{"panic", func(ctx context.Context) error { panic("boom") }, true},
+	}
+
+	h := newTestHub()
+	defer close(h.done)
+	roomID := uuid.New()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := h.do(context.Background(), roomID, tt.fn)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// The actor survives a panicking command
+	if err := h.do(context.Background(), roomID, func(ctx context.Context) error { return nil }); err != nil {
+		t.Errorf("Expected actor to keep running, got %v", err)
+	}
+}
+
+func TestActorWaitsForBusyRoom(t *testing.T) {
+	h := newTestHub()
+	defer close(h.done)
+
+	roomID := uuid.New()
+	blocked := make(chan struct{})
+	release := make(chan struct{})
+	go h.do(context.Background(), roomID, func(ctx context.Context) error {
+		close(blocked)
+		<-release
+		return nil
+	})
+	<-blocked
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := h.do(ctx, roomID, func(ctx context.Context) error {
+		t.Error("Expected command not to run while the room is busy")
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestActorClosedHub(t *testing.T) {
+	h := newTestHub()
+	roomID := uuid.New()
+	if err := h.do(context.Background(), roomID, func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	close(h.done)
+	err := h.do(context.Background(), roomID, func(ctx context.Context) error {
+		t.Error("Expected command not to run on a closed hub")
+		return nil
+	})
+	if !errors.Is(err, errHubClosed) {
+		t.Errorf("Expected %v, got %v", errHubClosed, err)
+	}
+}
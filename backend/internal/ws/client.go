@@ -3,12 +3,16 @@ package ws
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/gofiber/contrib/websocket"
 	"github.com/google/uuid"
 	"github.com/yaroslav/elias/internal/models"
+	"github.com/yaroslav/elias/internal/services"
+	"github.com/yaroslav/elias/pkg/protocol"
 )
 
 const (
@@ -18,27 +22,53 @@ const (
 	maxMessageSize = 512
 )
 
+var (
+	errInvalidSwipe = errors.New("swipe action must be up or down")
+	errRateLimited  = errors.New("too many messages")
+)
+
 type Client struct {
 	hub    *Hub
 	conn   *websocket.Conn
 	send   chan []byte
 	roomID uuid.UUID
 	user   *models.TelegramUser
+	// connID tells the connection apart from the user's other connections,
+	// e.g. a reconnect to another instance, in their presence
+	connID string
+
+	// done is closed to drop the client: the write pump flushes what is
+	// queued and closes the connection
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// lastSeq is the last room event the client saw before connecting; 0 for
+	// a fresh connection
+	lastSeq int64
+	// version is the protocol version negotiated for the connection
+	version int
+	limiter *rateLimiter
 }
 
-func NewClient(hub *Hub, conn *websocket.Conn, roomID uuid.UUID, user *models.TelegramUser) *Client {
+func NewClient(hub *Hub, conn *websocket.Conn, roomID uuid.UUID, user *models.TelegramUser, lastSeq int64, version int) *Client {
 	return &Client{
-		hub:    hub,
-		conn:   conn,
-		send:   make(chan []byte, 256),
-		roomID: roomID,
-		user:   user,
+		hub:     hub,
+		conn:    conn,
+		send:    make(chan []byte, 256),
+		done:    make(chan struct{}),
+		roomID:  roomID,
+		user:    user,
+		connID:  uuid.NewString(),
+		lastSeq: lastSeq,
+		version: version,
+		limiter: newRateLimiter(commandRate, commandBurst),
 	}
 }
 
 func (c *Client) ReadPump() {
 	defer func() {
 		c.hub.Unregister(c)
+		c.close()
 		c.conn.Close()
 	}()
 
@@ -58,31 +88,34 @@ func (c *Client) ReadPump() {
 			break
 		}
 
-		var msg IncomingMessage
-		if err := json.Unmarshal(message, &msg); err != nil {
+		cmd, err := protocol.DecodeIncoming(message)
+		if err != nil {
+			log.Printf("Invalid message from %d in room %s: %v", c.user.ID, c.roomID, err)
+			c.replyError(cmd.RequestID, protocol.ErrCodeBadRequest, err)
+			continue
+		}
+		if !c.limiter.allow(time.Now()) {
+			c.replyError(cmd.RequestID, protocol.ErrCodeRateLimited, errRateLimited)
 			continue
 		}
 
-		c.handleMessage(&msg)
+		c.handleMessage(cmd)
 	}
 }
 
 func (c *Client) WritePump() {
 	ticker := time.NewTicker(pingPeriod)
+	heartbeat := time.NewTicker(services.PresenceHeartbeat)
 	defer func() {
 		ticker.Stop()
+		heartbeat.Stop()
 		c.conn.Close()
 	}()
 
 	for {
 		select {
-		case message, ok := <-c.send:
+		case message := <-c.send:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
-			}
-
 			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
 				return
 			}
@@ -92,90 +125,178 @@ func (c *Client) WritePump() {
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
+
+		case <-heartbeat.C:
+			c.hub.heartbeat(c)
+
+		case <-c.done:
+			c.flush()
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+			return
 		}
 	}
 }
 
-func (c *Client) handleMessage(msg *IncomingMessage) {
-	switch msg.Type {
-	case MsgTypeSwipe:
-		c.handleSwipe(msg.Action)
-	case MsgTypeVoteStart:
+// flush writes the messages queued before the client was closed, such as the
+// notice of a kick.
+func (c *Client) flush() {
+	for {
+		select {
+		case message := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// close drops the client. It is safe to call more than once. The send
+// channel is never closed: the read pump keeps replying on it until it
+// notices the connection is gone.
+func (c *Client) close() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
+
+// trySend queues message for the write pump without waiting. It reports false
+// when the client was closed or cannot keep up.
+func (c *Client) trySend(message []byte) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+	select {
+	case c.send <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+// sendWait queues message for the write pump, waiting for room in the queue.
+// It reports false when the client is closed first. Room loops must use
+// trySend instead.
+func (c *Client) sendWait(message []byte) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+	select {
+	case c.send <- message:
+		return true
+	case <-c.done:
+		return false
+	}
+}
+
+// handleMessage runs a decoded client message and answers it with an ack or
+// an error.
+func (c *Client) handleMessage(cmd protocol.Command) {
+	if err := c.dispatch(cmd); err != nil {
+		code := commandErrorCode(err)
+		if code == protocol.ErrCodeInternal {
+			log.Printf("Error handling %s from %d in room %s: %v", cmd.Type, c.user.ID, c.roomID, err)
+		}
+		c.replyError(cmd.RequestID, code, err)
+		return
+	}
+	c.ack(cmd.RequestID)
+}
+
+func (c *Client) dispatch(cmd protocol.Command) error {
+	ctx := context.Background()
+
+	switch cmd.Type {
+	case protocol.MsgTypeSwipe:
+		return c.handleSwipe(ctx, cmd.Payload.(*protocol.SwipePayload).Action)
+	case protocol.MsgTypeVoteStart:
 		c.handleVoteStart()
-	case MsgTypeVotePause:
+	case protocol.MsgTypeVotePause:
 		c.handleVotePause()
+	case protocol.MsgTypeResume:
+		c.hub.resume(c, cmd.Payload.(*protocol.ResumePayload).LastSeq)
+	case protocol.MsgTypeGetState:
+		return c.hub.sendRoomState(c)
+	case protocol.MsgTypeChangeTeam:
+		_, err := c.hub.ChangeTeam(ctx, c.roomID, c.user.ID, cmd.Payload.(*protocol.ChangeTeamPayload).Team)
+		return err
+	case protocol.MsgTypeStartGame:
+		return c.hub.StartGame(ctx, c.roomID, c.user.ID)
+	case protocol.MsgTypeKick:
+		return c.hub.KickPlayer(ctx, c.roomID, c.user.ID, cmd.Payload.(*protocol.KickPayload).UserID)
+	case protocol.MsgTypeSetRules:
+		payload := cmd.Payload.(*protocol.SetRulesPayload)
+		_, err := c.hub.SetRules(ctx, c.roomID, c.user.ID, models.RoomRules{
+			Category:     payload.Category,
+			Lang:         payload.Lang,
+			RoundSeconds: payload.RoundSeconds,
+			WinningScore: payload.WinningScore,
+		})
+		return err
+	case protocol.MsgTypeReady:
+		return c.hub.SetReady(ctx, c.roomID, c.user.ID, cmd.Payload.(*protocol.ReadyPayload).Ready)
 	}
+	return nil
 }
 
-func (c *Client) handleSwipe(action string) {
+func (c *Client) handleSwipe(ctx context.Context, action string) error {
 	log.Printf("Player %d swiped %s in room %s", c.user.ID, action, c.roomID)
 
 	// Only process up/down swipes
 	if action != "up" && action != "down" {
-		return
+		return errInvalidSwipe
 	}
 
+	return c.hub.do(ctx, c.roomID, func(ctx context.Context) error {
+		return c.hub.swipe(ctx, c.roomID, c.user.ID, action)
+	})
+}
+
+// swipe scores the current word and deals the next one. Runs in the room's
+// actor.
+func (h *Hub) swipe(ctx context.Context, roomID uuid.UUID, userID int64, action string) error {
 	// Process swipe through game service
-	ctx := context.Background()
-	guessed, word, err := c.hub.gameService.ProcessSwipe(ctx, c.roomID, c.user.ID, action)
-	if err != nil {
-		log.Printf("Error processing swipe: %v", err)
-		return
+	if _, _, err := h.gameService.ProcessSwipe(ctx, roomID, userID, action); err != nil {
+		return err
 	}
 
-	// If word was processed, broadcast result
-	if word != nil {
-		// Broadcast word result
-		resultMsg, _ := json.Marshal(OutgoingMessage{
-			Type: MsgTypeWordResult,
-			Payload: WordResultPayload{
-				WordID:  word.ID,
-				Word:    word.Word,
-				Guessed: guessed,
-			},
-		})
-		c.hub.BroadcastToRoom(c.roomID, resultMsg)
-
-		// Get and broadcast team scores
-		teamScores, _ := c.hub.gameService.GetTeamScores(ctx, c.roomID)
-		scoreMsg, _ := json.Marshal(OutgoingMessage{
-			Type: MsgTypeScoreUpdate,
-			Payload: ScoreUpdatePayload{
-				TeamScores: teamScores,
-			},
-		})
-		c.hub.BroadcastToRoom(c.roomID, scoreMsg)
+	// Broadcast word result and team scores written with the swipe
+	if err := h.relayOutbox(ctx, roomID); err != nil {
+		// The outbox poller sends them later
+		log.Printf("Error relaying outbox of room %s: %v", roomID, err)
+	}
 
-		// Get room category
-		room, err := c.hub.roomService.GetRoom(ctx, c.roomID)
-		if err != nil {
-			log.Printf("Error getting room: %v", err)
-			return
-		}
+	// Achievements never fail the swipe
+	if unlocked, err := h.achievements.OnSwipe(ctx, roomID, userID); err != nil {
+		log.Printf("Error evaluating achievements of %d in room %s: %v", userID, roomID, err)
+	} else {
+		h.announceAchievements(roomID, unlocked)
+	}
 
-		// Get next word
-		nextWord, err := c.hub.wordService.GetRandomWord(ctx, c.roomID, "ru", room.Category)
-		if err != nil {
-			log.Printf("Error getting next word: %v", err)
-			return
-		}
+	// Get room category
+	room, err := h.roomService.GetRoom(ctx, roomID)
+	if err != nil {
+		return err
+	}
 
-		// Set current word in game state
-		if err := c.hub.gameService.SetCurrentWord(ctx, c.roomID, nextWord); err != nil {
-			log.Printf("Error setting current word: %v", err)
-			return
-		}
+	// Get next word
+	nextWord, err := h.wordService.GetRandomWord(ctx, roomID, room.Lang, room.Category)
+	if err != nil {
+		return err
+	}
 
-		// Broadcast new word
-		newWordMsg, _ := json.Marshal(OutgoingMessage{
-			Type: MsgTypeNewWord,
-			Payload: NewWordPayload{
-				WordID: nextWord.ID,
-				Word:   nextWord.Word,
-			},
-		})
-		c.hub.BroadcastToRoom(c.roomID, newWordMsg)
+	// Set current word in game state
+	if err := h.gameService.SetCurrentWord(ctx, roomID, nextWord); err != nil {
+		return err
 	}
+
+	// Show the new word to whoever may see it; only the explainer swipes
+	return h.dealWord(ctx, roomID, userID, nextWord)
 }
 
 func (c *Client) handleVoteStart() {
@@ -186,15 +307,21 @@ func (c *Client) handleVotePause() {
 	log.Printf("Player %d voted to pause in room %s", c.user.ID, c.roomID)
 }
 
-func (c *Client) SendMessage(msg *OutgoingMessage) {
+// reply sends a direct reply to the client, dropping it if the client cannot
+// keep up.
+func (c *Client) reply(message []byte) {
+	if !c.trySend(message) {
+		log.Printf("Dropped reply to %d in room %s", c.user.ID, c.roomID)
+	}
+}
+
+func (c *Client) SendMessage(msg *protocol.OutgoingMessage) {
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return
 	}
 
-	select {
-	case c.send <- data:
-	default:
-		c.hub.Unregister(c)
+	if !c.trySend(data) {
+		c.close()
 	}
 }
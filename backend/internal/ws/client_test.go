@@ -0,0 +1,54 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yaroslav/elias/internal/models"
+)
+
+func newTestClient() *Client {
+	return NewClient(nil, nil, uuid.New(), &models.TelegramUser{ID: 1}, 0, 2)
+}
+
+func TestClientSend(t *testing.T) {
+	c := newTestClient()
+	for i := 0; i < cap(c.send); i++ {
+		if !c.trySend([]byte("m")) {
+			t.Fatalf("Expected message %d to be queued", i+1)
+		}
+	}
+	if c.trySend([]byte("m")) {
+		t.Error("Expected message to a full queue to be dropped")
+	}
+
+	// A waiting send is released by close
+	sent := make(chan bool)
+	go func() { sent <- c.sendWait([]byte("m")) }()
+	time.Sleep(10 * time.Millisecond)
+	c.close()
+	select {
+	case ok := <-sent:
+		if ok {
+			t.Error("Expected send to a closed client to fail")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected close to release the waiting send")
+	}
+}
+
+func TestClientSendAfterClose(t *testing.T) {
+	c := newTestClient()
+	c.close()
+	c.close()
+
+	// Replies racing with a drop must not panic
+	if c.trySend([]byte("m")) {
+		t.Error("Expected trySend to a closed client to fail")
+	}
+	if c.sendWait([]byte("m")) {
+		t.Error("Expected sendWait to a closed client to fail")
+	}
+	c.reply([]byte("m"))
+}
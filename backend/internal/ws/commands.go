@@ -0,0 +1,243 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/yaroslav/elias/internal/models"
+	"github.com/yaroslav/elias/internal/services"
+	"github.com/yaroslav/elias/pkg/protocol"
+)
+
+// Lobby and game commands. Each one goes through the services and tells the
+// room what changed; the WebSocket commands and the REST handlers share them.
+
+// ChangeTeam moves the user to team, or out of their team if it is empty.
+func (h *Hub) ChangeTeam(ctx context.Context, roomID uuid.UUID, userID int64, team string) (*models.Player, error) {
+	player, err := h.roomService.ChangeTeam(ctx, roomID, userID, team)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg, ok := encode(protocol.MsgTypeTeamChanged, protocol.TeamChangedPayload{
+		UserID: userID,
+		Team:   team,
+	}); ok {
+		h.BroadcastToRoom(roomID, msg)
+	}
+	return player, nil
+}
+
+// BalanceTeams evens out the teams of a lobby by rating and tells the room
+// about every player that moved. Host only.
+func (h *Hub) BalanceTeams(ctx context.Context, roomID uuid.UUID, userID int64) ([]*models.Player, error) {
+	moved, err := h.roomService.BalanceTeams(ctx, roomID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range moved {
+		if msg, ok := encode(protocol.MsgTypeTeamChanged, protocol.TeamChangedPayload{
+			UserID: p.UserID,
+			Team:   p.Team,
+		}); ok {
+			h.BroadcastToRoom(roomID, msg)
+		}
+	}
+	return moved, nil
+}
+
+// StartGame starts the game in a lobby room: deals the first word and takes
+// ownership of the round timer. Host only.
+func (h *Hub) StartGame(ctx context.Context, roomID uuid.UUID, userID int64) error {
+	return h.do(ctx, roomID, func(ctx context.Context) error {
+		return h.startGame(ctx, roomID, userID)
+	})
+}
+
+func (h *Hub) startGame(ctx context.Context, roomID uuid.UUID, userID int64) error {
+	isHost, err := h.roomService.IsHost(ctx, roomID, userID)
+	if err != nil {
+		return err
+	}
+	if !isHost {
+		return services.ErrNotHost
+	}
+
+	room, err := h.roomService.GetRoom(ctx, roomID)
+	if err != nil {
+		return err
+	}
+	if room.Status != models.RoomStatusLobby {
+		return services.ErrGameInProgress
+	}
+
+	players, err := h.roomService.GetRoomPlayers(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	// Dealt before the game is saved as playing, so a room without words
+	// stays in the lobby
+	firstWord, err := h.wordService.GetRandomWord(ctx, roomID, room.Lang, room.Category)
+	if err != nil {
+		return err
+	}
+
+	gameState, err := h.gameService.StartGame(ctx, roomID, players, firstWord)
+	if err != nil {
+		return err
+	}
+	h.matchmaking.TrySyncRoom(roomID)
+
+	if startedMsg, ok := encode(protocol.MsgTypeGameStarted, protocol.GameStartedPayload{
+		ExplainerID: gameState.CurrentExplainer,
+		RoundEndAt:  gameState.RoundEndAt.Unix(),
+	}); ok {
+		h.BroadcastToRoom(roomID, startedMsg)
+	}
+
+	if err := h.dealWord(ctx, roomID, gameState.CurrentExplainer, firstWord); err != nil {
+		return err
+	}
+
+	h.StartTimer(roomID)
+	log.Printf("Started game in room %s, explainer=%d", roomID, gameState.CurrentExplainer)
+	return nil
+}
+
+// KickPlayer removes userID from a lobby room. Host only. The kicked player's
+// connection to this instance is closed; on other instances the client leaves
+// when it sees player_kicked about itself.
+func (h *Hub) KickPlayer(ctx context.Context, roomID uuid.UUID, hostID, userID int64) error {
+	if err := h.roomService.KickPlayer(ctx, roomID, hostID, userID); err != nil {
+		return err
+	}
+	h.matchmaking.TrySyncRoom(roomID)
+
+	msg, ok := encode(protocol.MsgTypePlayerKicked, protocol.PlayerKickedPayload{
+		UserID:   userID,
+		KickedBy: hostID,
+	})
+	if ok {
+		h.BroadcastToRoom(roomID, msg)
+	}
+	h.dropLocalUser(roomID, userID, msg)
+	return nil
+}
+
+// SetRules changes the rules of a lobby room. Host only.
+func (h *Hub) SetRules(ctx context.Context, roomID uuid.UUID, userID int64, rules models.RoomRules) (*models.Room, error) {
+	room, err := h.roomService.SetRules(ctx, roomID, userID, rules)
+	if err != nil {
+		return nil, err
+	}
+	h.matchmaking.TrySyncRoom(roomID)
+
+	if msg, ok := encode(protocol.MsgTypeRulesUpdated, rulesPayload(room)); ok {
+		h.BroadcastToRoom(roomID, msg)
+	}
+	return room, nil
+}
+
+// SetReady marks the user ready or not ready in the lobby.
+func (h *Hub) SetReady(ctx context.Context, roomID uuid.UUID, userID int64, ready bool) error {
+	if err := h.roomService.SetReady(ctx, roomID, userID, ready); err != nil {
+		return err
+	}
+
+	if msg, ok := encode(protocol.MsgTypePlayerReady, protocol.PlayerReadyPayload{
+		UserID: userID,
+		Ready:  ready,
+	}); ok {
+		h.BroadcastToRoom(roomID, msg)
+	}
+	return nil
+}
+
+// dropLocalUser sends message, if not nil, to the user's connection on this
+// instance, if any, and closes it. The connection's read pump may still be handling a
+// command, so the client is closed rather than its send channel.
+func (h *Hub) dropLocalUser(roomID uuid.UUID, userID int64, message []byte) {
+	h.mu.RLock()
+	room, ok := h.rooms[roomID]
+	h.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	room.mu.Lock()
+	if client, ok := room.clients[userID]; ok {
+		// Still delivered: the write pump flushes the queue before it
+		// closes the connection
+		if message != nil {
+			client.trySend(message)
+		}
+		room.dropLocked(client)
+	}
+	room.mu.Unlock()
+}
+
+func rulesPayload(room *models.Room) protocol.RulesPayload {
+	return protocol.RulesPayload{
+		Category:         room.Category,
+		Lang:             room.Lang,
+		RoundSeconds:     room.RoundSeconds,
+		MaxWordsPerRound: services.MaxWordsPerRound,
+		WinningScore:     room.WinningScore,
+	}
+}
+
+func (c *Client) ack(requestID string) {
+	if msg, ok := encode(protocol.MsgTypeAck, protocol.AckPayload{RequestID: requestID}); ok {
+		c.reply(msg)
+	}
+}
+
+func (c *Client) replyError(requestID string, code protocol.ErrorCode, err error) {
+	message := err.Error()
+	if code == protocol.ErrCodeInternal {
+		message = "internal error"
+	}
+	if msg, ok := encode(protocol.MsgTypeError, protocol.ErrorPayload{
+		RequestID: requestID,
+		Code:      code,
+		Message:   message,
+	}); ok {
+		c.reply(msg)
+	}
+}
+
+// commandErrorCode maps errors of client messages to the error codes sent to
+// clients. Unexpected errors are internal.
+func commandErrorCode(err error) protocol.ErrorCode {
+	switch {
+	case errors.Is(err, services.ErrRoomNotFound), errors.Is(err, services.ErrPlayerNotFound):
+		return protocol.ErrCodeNotFound
+	case errors.Is(err, services.ErrNotHost):
+		return protocol.ErrCodeNotHost
+	case errors.Is(err, services.ErrCannotKickSelf):
+		return protocol.ErrCodeBadRequest
+	case errors.Is(err, services.ErrGameInProgress):
+		return protocol.ErrCodeGameInProgress
+	case errors.Is(err, services.ErrTeamNotFound):
+		return protocol.ErrCodeInvalidTeam
+	case errors.Is(err, services.ErrInvalidRules), errors.Is(err, services.ErrUnsupportedLang):
+		return protocol.ErrCodeInvalidRules
+	case errors.Is(err, services.ErrNotExplainer):
+		return protocol.ErrCodeNotExplainer
+	case errors.Is(err, services.ErrNoCurrentWord):
+		return protocol.ErrCodeNoCurrentWord
+	case errors.Is(err, services.ErrRoundPaused):
+		return protocol.ErrCodeGamePaused
+	case errors.Is(err, services.ErrStateConflict):
+		return protocol.ErrCodeStateConflict
+	case errors.Is(err, errInvalidSwipe):
+		return protocol.ErrCodeBadRequest
+	case errors.Is(err, errRateLimited):
+		return protocol.ErrCodeRateLimited
+	}
+	return protocol.ErrCodeInternal
+}
@@ -0,0 +1,144 @@
+package ws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/yaroslav/elias/pkg/protocol"
+)
+
+const (
+	// eventLogSize is how many recent events per room are kept for resume.
+	eventLogSize = 256
+	eventLogTTL  = 24 * time.Hour
+)
+
+func roomSeqKey(roomID uuid.UUID) string {
+	return "room_seq:" + roomID.String()
+}
+
+func roomLogKey(roomID uuid.UUID) string {
+	return "room_log:" + roomID.String()
+}
+
+// isSequenced reports whether messages of type t are numbered and logged.
+// Timer ticks are superseded every second and are not worth replaying;
+// new_word is only for some players and must not be replayed to the others.
+func isSequenced(t protocol.MessageType) bool {
+	return t != protocol.MsgTypeTimer && t != protocol.MsgTypeNewWord
+}
+
+// eventLog numbers room broadcasts with a per-room monotonically increasing
+// seq and keeps the last eventLogSize of them in Redis, so reconnecting
+// clients can catch up on what they missed.
+type eventLog struct {
+	rdb *redis.Client
+}
+
+// appendEventScript stamps a room event with the next seq, logs it and
+// publishes it to the room's channel in one step, so every instance receives
+// the room's events in seq order whoever sends them.
+//
+// KEYS: seq, log. ARGV: the message without its opening brace, the channel,
+// the start of the envelope, the log size and its TTL in seconds.
+var appendEventScript = redis.NewScript(`
+local seq = redis.call("INCR", KEYS[1])
+local message = '{"seq":' .. seq .. ',' .. ARGV[1]
+redis.call("ZADD", KEYS[2], seq, message)
+redis.call("ZREMRANGEBYRANK", KEYS[2], 0, -tonumber(ARGV[4]) - 1)
+redis.call("EXPIRE", KEYS[1], ARGV[5])
+redis.call("EXPIRE", KEYS[2], ARGV[5])
+redis.call("PUBLISH", ARGV[2], ARGV[3] .. message .. "}")
+return seq
+`)
+
+// publish stamps message with the next seq of the room, logs it and publishes
+// it to every instance. sequenced is false for messages that are not
+// numbered, which are left to the caller.
+func (l *eventLog) publish(ctx context.Context, roomID uuid.UUID, message []byte) (sequenced bool, err error) {
+	var msg struct {
+		Type protocol.MessageType `json:"type"`
+	}
+	if err := json.Unmarshal(message, &msg); err != nil || !isSequenced(msg.Type) {
+		return false, nil
+	}
+	body, ok := eventBody(message)
+	if !ok {
+		return false, nil
+	}
+
+	keys := []string{roomSeqKey(roomID), roomLogKey(roomID)}
+	return true, appendEventScript.Run(ctx, l.rdb, keys,
+		body, roomChannel(roomID), roomEnvelopePrefix(roomID), eventLogSize, int(eventLogTTL.Seconds()),
+	).Err()
+}
+
+// eventBody returns a JSON object without its opening brace, ready to have
+// seq put in front of its other fields.
+func eventBody(message []byte) (string, bool) {
+	message = bytes.TrimSpace(message)
+	if len(message) < 2 || message[0] != '{' || message[len(message)-1] != '}' || bytes.Equal(message, []byte("{}")) {
+		return "", false
+	}
+	return string(message[1:]), true
+}
+
+// messageSeq returns the seq of a server message, 0 if it has none.
+func messageSeq(message []byte) int64 {
+	var msg struct {
+		Seq int64 `json:"seq"`
+	}
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return 0
+	}
+	return msg.Seq
+}
+
+// current returns the seq of the last event in the room, 0 if there was none.
+func (l *eventLog) current(ctx context.Context, roomID uuid.UUID) (int64, error) {
+	seq, err := l.rdb.Get(ctx, roomSeqKey(roomID)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return seq, err
+}
+
+// since returns the logged events after lastSeq in order. complete is false
+// when some of them are no longer in the log and a snapshot is needed instead.
+func (l *eventLog) since(ctx context.Context, roomID uuid.UUID, lastSeq int64) (events [][]byte, complete bool, err error) {
+	current, err := l.current(ctx, roomID)
+	if err != nil {
+		return nil, false, err
+	}
+	if lastSeq > current {
+		// The log was reset since the client last saw it
+		return nil, false, nil
+	}
+	if lastSeq == current {
+		return nil, true, nil
+	}
+
+	logged, err := l.rdb.ZRangeByScoreWithScores(ctx, roomLogKey(roomID), &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(lastSeq, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(logged) == 0 || int64(logged[0].Score) != lastSeq+1 {
+		return nil, false, nil
+	}
+
+	events = make([][]byte, 0, len(logged))
+	for _, z := range logged {
+		if member, ok := z.Member.(string); ok {
+			events = append(events, []byte(member))
+		}
+	}
+	return events, true, nil
+}
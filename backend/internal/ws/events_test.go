@@ -0,0 +1,88 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/yaroslav/elias/pkg/protocol"
+)
+
+func TestEventBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+		ok      bool
+	}{
+		{"Message", `{"type":"team_changed","payload":{"team":"t1"}}`, `"type":"team_changed","payload":{"team":"t1"}}`, true},
+		{"Empty object", `{}`, "", false},
+		{"Not an object", `[1]`, "", false},
+		{"Empty", ``, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := eventBody([]byte(tt.message))
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("Expected %q (%v), got %q (%v)", tt.want, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+// TestStampedEnvelope checks that what appendEventScript publishes, built the
+// same way here, is an envelope with a valid server message in it.
+func TestStampedEnvelope(t *testing.T) {
+	roomID := uuid.New()
+	message, err := protocol.Encode(protocol.MsgTypeTeamChanged, protocol.TeamChangedPayload{UserID: 7, Team: "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, ok := eventBody(message)
+	if !ok {
+		t.Fatal("Expected message to have a body")
+	}
+
+	stamped := `{"seq":42,` + body
+	published := roomEnvelopePrefix(roomID) + stamped + "}"
+
+	var env envelope
+	if err := json.Unmarshal([]byte(published), &env); err != nil {
+		t.Fatalf("Expected a valid envelope, got %v", err)
+	}
+	if env.RoomID != roomID || env.UserID != 0 {
+		t.Errorf("Expected room-wide envelope for %s, got %s/%d", roomID, env.RoomID, env.UserID)
+	}
+
+	msgType, seq, payload, err := protocol.DecodeOutgoing(env.Message)
+	if err != nil {
+		t.Fatalf("Expected a valid message, got %v", err)
+	}
+	if msgType != protocol.MsgTypeTeamChanged || seq != 42 {
+		t.Errorf("Expected team_changed #42, got %s #%d", msgType, seq)
+	}
+	if p := payload.(*protocol.TeamChangedPayload); p.UserID != 7 || p.Team != "t1" {
+		t.Errorf("Expected payload to survive, got %+v", p)
+	}
+}
+
+func TestIsSequenced(t *testing.T) {
+	tests := []struct {
+		msgType protocol.MessageType
+		want    bool
+	}{
+		{protocol.MsgTypeTeamChanged, true},
+		{protocol.MsgTypeWordResult, true},
+		{protocol.MsgTypeTimer, false},
+		{protocol.MsgTypeNewWord, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.msgType), func(t *testing.T) {
+			if got := isSequenced(tt.msgType); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yaroslav/elias/pkg/protocol"
+)
+
+// pauseRound pauses the round if userID, who just went offline, is its
+// explainer.
+func (h *Hub) pauseRound(roomID uuid.UUID, userID int64) {
+	h.background(roomID, func(ctx context.Context) error {
+		return h.doPauseRound(ctx, roomID, userID)
+	})
+}
+
+func (h *Hub) doPauseRound(ctx context.Context, roomID uuid.UUID, userID int64) error {
+	state, paused, err := h.gameService.PauseRound(ctx, roomID, userID)
+	if err != nil {
+		return fmt.Errorf("pausing round: %w", err)
+	}
+	if !paused {
+		return nil
+	}
+
+	if msg, ok := encode(protocol.MsgTypeRoundPaused, protocol.RoundPausedPayload{
+		ExplainerID:    userID,
+		SecondsLeft:    int(state.Remaining.Seconds()),
+		ResumeDeadline: state.PausedUntil.Unix(),
+	}); ok {
+		h.BroadcastToRoom(roomID, msg)
+	}
+	log.Printf("Paused round %d in room %s, explainer %d disconnected", state.CurrentRound, roomID, userID)
+	return nil
+}
+
+// resumeRound continues the round if userID, who just came back online, is the
+// explainer it was paused for.
+func (h *Hub) resumeRound(roomID uuid.UUID, userID int64) {
+	h.background(roomID, func(ctx context.Context) error {
+		return h.doResumeRound(ctx, roomID, userID)
+	})
+}
+
+func (h *Hub) doResumeRound(ctx context.Context, roomID uuid.UUID, userID int64) error {
+	state, resumed, err := h.gameService.ResumeRound(ctx, roomID, userID)
+	if err != nil {
+		return fmt.Errorf("resuming round: %w", err)
+	}
+	if !resumed {
+		return nil
+	}
+
+	if msg, ok := encode(protocol.MsgTypeRoundResumed, protocol.RoundResumedPayload{
+		ExplainerID: userID,
+		RoundEndAt:  state.RoundEndAt.Unix(),
+		SecondsLeft: int(time.Until(state.RoundEndAt).Seconds()),
+	}); ok {
+		h.BroadcastToRoom(roomID, msg)
+	}
+	h.timers.start(roomID)
+	log.Printf("Resumed round %d in room %s", state.CurrentRound, roomID)
+	return nil
+}
+
+// handleDeadline is called by the timer owner when the room's deadline has
+// passed: either the round is over or the explainer did not come back in time.
+func (h *Hub) handleDeadline(roomID uuid.UUID) {
+	h.background(roomID, func(ctx context.Context) error {
+		h.deadlinePassed(ctx, roomID)
+		return nil
+	})
+}
+
+func (h *Hub) deadlinePassed(ctx context.Context, roomID uuid.UUID) {
+	state, err := h.gameService.GetGameState(ctx, roomID)
+	if err != nil || state == nil {
+		log.Printf("Error getting game state: %v", err)
+		return
+	}
+	if !state.Paused {
+		h.handleRoundEnd(ctx, roomID)
+		return
+	}
+
+	expired, err := h.gameService.ExpirePause(ctx, roomID)
+	if err != nil {
+		log.Printf("Error expiring pause in room %s: %v", roomID, err)
+		return
+	}
+	if !expired {
+		return
+	}
+
+	if msg, ok := encode(protocol.MsgTypeExplainerTimeout, protocol.ExplainerTimeoutPayload{ExplainerID: state.CurrentExplainer}); ok {
+		h.BroadcastToRoom(roomID, msg)
+	}
+}
+
+// SkipExplainer lets the host end a round whose explainer did not come back
+// within the grace window. The next round starts with the next explainer.
+func (h *Hub) SkipExplainer(ctx context.Context, roomID uuid.UUID, userID int64) error {
+	return h.do(ctx, roomID, func(ctx context.Context) error {
+		return h.skipExplainer(ctx, roomID, userID)
+	})
+}
+
+func (h *Hub) skipExplainer(ctx context.Context, roomID uuid.UUID, userID int64) error {
+	state, err := h.gameService.CheckSkipExplainer(ctx, roomID, userID)
+	if err != nil {
+		return err
+	}
+
+	if msg, ok := encode(protocol.MsgTypeExplainerSkipped, protocol.ExplainerSkippedPayload{
+		ExplainerID: state.CurrentExplainer,
+		SkippedBy:   userID,
+	}); ok {
+		h.BroadcastToRoom(roomID, msg)
+	}
+
+	h.handleRoundEnd(ctx, roomID)
+	h.timers.start(roomID)
+	return nil
+}
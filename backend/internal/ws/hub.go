@@ -2,23 +2,33 @@ package ws
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 	"sync"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"github.com/yaroslav/elias/internal/services"
+	"github.com/yaroslav/elias/pkg/protocol"
 )
 
 type Hub struct {
-	rooms       map[uuid.UUID]*RoomHub
-	mu          sync.RWMutex
-	rdb         *redis.Client
-	gameService *services.GameService
-	wordService *services.WordService
-	roomService *services.RoomService
+	rooms        map[uuid.UUID]*RoomHub
+	mu           sync.RWMutex
+	rdb          *redis.Client
+	publisher    *publisher
+	timers       *roundTimers
+	events       *eventLog
+	gameService  *services.GameService
+	wordService  *services.WordService
+	roomService  *services.RoomService
+	presence     *services.PresenceService
+	matchmaking  *services.MatchmakingService
+	summary      *services.SummaryService
+	achievements *services.AchievementService
+	done         chan struct{}
+
+	actors   map[uuid.UUID]*roomActor
+	actorsMu sync.Mutex
 }
 
 type RoomHub struct {
@@ -29,37 +39,80 @@ type RoomHub struct {
 	unregister chan *Client
 	mu         sync.RWMutex
 	hub        *Hub
-	timer      *time.Ticker
-	timerStop  chan struct{}
+
+	// Clients are caught up outside the loop. Until they are, the room's
+	// broadcasts to them are held back in syncing (guarded by mu); resync
+	// starts another catch-up and synced ends one
+	syncing map[*Client][][]byte
+	resync  chan syncRequest
+	synced  chan syncResult
+	// stopped is closed when the loop returns
+	stopped chan struct{}
+}
+
+type syncRequest struct {
+	client  *Client
+	lastSeq int64
+}
+
+// syncResult reports that client was caught up as of seq.
+type syncResult struct {
+	client *Client
+	seq    int64
 }
 
-func NewHub(rdb *redis.Client, gameService *services.GameService, wordService *services.WordService, roomService *services.RoomService) *Hub {
-	return &Hub{
-		rooms:       make(map[uuid.UUID]*RoomHub),
-		rdb:         rdb,
-		gameService: gameService,
-		wordService: wordService,
-		roomService: roomService,
+// maxHeldBack is how many broadcasts are held back for a client being caught
+// up before it is dropped.
+const maxHeldBack = eventLogSize
+
+func NewHub(rdb *redis.Client, gameService *services.GameService, wordService *services.WordService, roomService *services.RoomService, presence *services.PresenceService, matchmaking *services.MatchmakingService, summary *services.SummaryService, achievements *services.AchievementService) *Hub {
+	h := &Hub{
+		rooms:        make(map[uuid.UUID]*RoomHub),
+		rdb:          rdb,
+		publisher:    newPublisher(rdb),
+		events:       &eventLog{rdb: rdb},
+		gameService:  gameService,
+		wordService:  wordService,
+		roomService:  roomService,
+		presence:     presence,
+		matchmaking:  matchmaking,
+		summary:      summary,
+		achievements: achievements,
+		done:         make(chan struct{}),
+		actors:       make(map[uuid.UUID]*roomActor),
 	}
+	h.timers = newRoundTimers(h)
+	return h
 }
 
+// Run delivers published room messages to local clients, picks up round
+// timers nobody owns, expires stale presence and relays events left in the
+// outbox. It returns when the hub is closed.
 func (h *Hub) Run() {
-	// Subscribe to Redis pub/sub for cross-instance messaging
-	ctx := context.Background()
-	pubsub := h.rdb.Subscribe(ctx, "game_events")
-	defer pubsub.Close()
-
-	ch := pubsub.Channel()
-	for msg := range ch {
-		var event struct {
-			RoomID  uuid.UUID       `json:"room_id"`
-			Message json.RawMessage `json:"message"`
-		}
-		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
-			continue
+	go h.timers.poll()
+	go h.expirePresence()
+	go h.pollOutbox()
+	h.publisher.receive(func(env *envelope) {
+		if env.UserID != 0 {
+			h.sendToLocalUser(env.RoomID, env.UserID, env.Message)
+			return
 		}
-		h.BroadcastToRoom(event.RoomID, event.Message)
-	}
+		h.broadcastLocal(env.RoomID, env.Message)
+	})
+}
+
+// Recover re-arms round timers of games that were in flight when the server
+// went down. It returns the number of recovered games.
+func (h *Hub) Recover(ctx context.Context) (int, error) {
+	return h.timers.recover(ctx)
+}
+
+// Close stops receiving messages from other instances and releases the round
+// timers owned by this instance.
+func (h *Hub) Close() error {
+	close(h.done)
+	h.timers.close()
+	return h.publisher.close()
 }
 
 func (h *Hub) GetOrCreateRoomHub(roomID uuid.UUID) *RoomHub {
@@ -70,6 +123,10 @@ func (h *Hub) GetOrCreateRoomHub(roomID uuid.UUID) *RoomHub {
 		return room
 	}
 
+	// Subscribing under the lock keeps it ordered with the unsubscribe in
+	// RoomHub.run when the room is recreated right after being removed
+	h.publisher.subscribe(roomID)
+
 	room := &RoomHub{
 		roomID:     roomID,
 		clients:    make(map[int64]*Client),
@@ -77,7 +134,10 @@ func (h *Hub) GetOrCreateRoomHub(roomID uuid.UUID) *RoomHub {
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		hub:        h,
-		timerStop:  make(chan struct{}),
+		syncing:    make(map[*Client][][]byte),
+		resync:     make(chan syncRequest),
+		synced:     make(chan syncResult),
+		stopped:    make(chan struct{}),
 	}
 	h.rooms[roomID] = room
 	go room.run()
@@ -85,31 +145,97 @@ func (h *Hub) GetOrCreateRoomHub(roomID uuid.UUID) *RoomHub {
 }
 
 func (h *Hub) Register(client *Client) {
-	room := h.GetOrCreateRoomHub(client.roomID)
-	room.register <- client
+	// hello always comes first; version 1 clients do not know it
+	if client.version >= protocol.HelloVersion {
+		hello, ok := encode(protocol.MsgTypeHello, protocol.HelloPayload{
+			ProtocolVersion:    client.version,
+			MinProtocolVersion: protocol.MinProtocolVersion,
+			MaxProtocolVersion: protocol.ProtocolVersion,
+		})
+		if ok {
+			client.trySend(hello)
+		}
+	}
+
+	// The room may stop between looking it up and registering, when its last
+	// client just left; a new one is created then
+	for {
+		room := h.GetOrCreateRoomHub(client.roomID)
+		select {
+		case room.register <- client:
+			return
+		case <-room.stopped:
+		}
+	}
 }
 
 func (h *Hub) Unregister(client *Client) {
-	h.mu.RLock()
-	room, ok := h.rooms[client.roomID]
-	h.mu.RUnlock()
+	for {
+		h.mu.RLock()
+		room, ok := h.rooms[client.roomID]
+		h.mu.RUnlock()
+		if !ok {
+			return
+		}
 
-	if ok {
-		room.unregister <- client
+		select {
+		case room.unregister <- client:
+			return
+		case <-room.stopped:
+		}
 	}
 }
 
+// BroadcastToRoom sends message to every client in the room on all instances.
+// Room events are stamped with the next seq and logged for resume; clients
+// get them in seq order whichever goroutine or instance sends them.
 func (h *Hub) BroadcastToRoom(roomID uuid.UUID, message []byte) {
+	sequenced, err := h.events.publish(context.Background(), roomID, message)
+	if err != nil {
+		log.Printf("Error publishing event to room %s: %v", roomID, err)
+	}
+	if !sequenced {
+		h.publisher.publish(roomID, 0, message)
+	}
+}
+
+// SendToUser sends message to one user in the room on whichever instance they
+// are connected to.
+func (h *Hub) SendToUser(roomID uuid.UUID, userID int64, message []byte) {
+	h.publisher.publish(roomID, userID, message)
+}
+
+// encode encodes a server message for callers that cannot return the error;
+// it is logged and ok is false.
+func encode(msgType protocol.MessageType, payload interface{}) (msg []byte, ok bool) {
+	msg, err := protocol.Encode(msgType, payload)
+	if err != nil {
+		log.Printf("Error encoding %s message: %v", msgType, err)
+		return nil, false
+	}
+	return msg, true
+}
+
+// broadcastLocal hands message to the room's local clients. It never waits:
+// the pub/sub receiver delivers for every room on this instance, so a room
+// that cannot keep up loses the message rather than stalling the others; its
+// clients catch up on resume.
+func (h *Hub) broadcastLocal(roomID uuid.UUID, message []byte) {
 	h.mu.RLock()
 	room, ok := h.rooms[roomID]
 	h.mu.RUnlock()
 
-	if ok {
-		room.broadcast <- message
+	if !ok {
+		return
+	}
+	select {
+	case room.broadcast <- message:
+	default:
+		log.Printf("Dropped broadcast to room %s", roomID)
 	}
 }
 
-func (h *Hub) SendToUser(roomID uuid.UUID, userID int64, message []byte) {
+func (h *Hub) sendToLocalUser(roomID uuid.UUID, userID int64, message []byte) {
 	h.mu.RLock()
 	room, ok := h.rooms[roomID]
 	h.mu.RUnlock()
@@ -119,40 +245,27 @@ func (h *Hub) SendToUser(roomID uuid.UUID, userID int64, message []byte) {
 		client, ok := room.clients[userID]
 		room.mu.RUnlock()
 
-		if ok {
-			select {
-			case client.send <- message:
-			default:
-				close(client.send)
-				room.mu.Lock()
-				delete(room.clients, userID)
-				room.mu.Unlock()
-			}
+		if ok && !client.trySend(message) {
+			room.mu.Lock()
+			room.dropLocked(client)
+			room.mu.Unlock()
 		}
 	}
 }
 
-func (h *Hub) StartTimer(roomID uuid.UUID, duration time.Duration) {
-	h.mu.RLock()
-	room, ok := h.rooms[roomID]
-	h.mu.RUnlock()
-
-	if ok {
-		room.startTimer(duration)
-	}
+// StartTimer makes this instance drive the room's round timer unless another
+// instance already does. The deadline itself is scheduled by GameService.
+func (h *Hub) StartTimer(roomID uuid.UUID) {
+	h.timers.start(roomID)
 }
 
 func (h *Hub) StopTimer(roomID uuid.UUID) {
-	h.mu.RLock()
-	room, ok := h.rooms[roomID]
-	h.mu.RUnlock()
-
-	if ok {
-		room.stopTimer()
-	}
+	h.timers.stop(roomID)
 }
 
 func (rh *RoomHub) run() {
+	defer close(rh.stopped)
+
 	for {
 		select {
 		case client := <-rh.register:
@@ -160,16 +273,27 @@ func (rh *RoomHub) run() {
 			rh.clients[client.user.ID] = client
 			rh.mu.Unlock()
 			log.Printf("Client %d joined room %s", client.user.ID, rh.roomID)
+			go rh.hub.heartbeat(client)
 
-			// Send current game state to the newly connected client
-			go rh.sendGameStateToClient(client)
+			// Catch the client up before any further broadcast reaches it
+			rh.startSync(client, client.lastSeq)
+
+		case req := <-rh.resync:
+			rh.mu.RLock()
+			current := rh.clients[req.client.user.ID] == req.client
+			rh.mu.RUnlock()
+			if current {
+				rh.startSync(req.client, req.lastSeq)
+			}
+
+		case res := <-rh.synced:
+			rh.finishSync(res)
 
 		case client := <-rh.unregister:
 			rh.mu.Lock()
-			if _, ok := rh.clients[client.user.ID]; ok {
-				delete(rh.clients, client.user.ID)
-				close(client.send)
-			}
+			// A reconnect may already have replaced this connection, or it
+			// was dropped already
+			rh.dropLocked(client)
 			rh.mu.Unlock()
 			log.Printf("Client %d left room %s", client.user.ID, rh.roomID)
 
@@ -179,227 +303,289 @@ func (rh *RoomHub) run() {
 			rh.mu.RUnlock()
 
 			if empty {
-				rh.stopTimer()
 				rh.hub.mu.Lock()
 				delete(rh.hub.rooms, rh.roomID)
+				rh.hub.publisher.unsubscribe(rh.roomID)
 				rh.hub.mu.Unlock()
 				return
 			}
 
 		case message := <-rh.broadcast:
-			rh.mu.RLock()
+			rh.mu.Lock()
 			for _, client := range rh.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(rh.clients, client.user.ID)
+				if held, ok := rh.syncing[client]; ok {
+					if len(held) == maxHeldBack {
+						rh.dropLocked(client)
+						continue
+					}
+					rh.syncing[client] = append(held, message)
+					continue
+				}
+				// Clients that cannot keep up reconnect and resume
+				if !client.trySend(message) {
+					rh.dropLocked(client)
 				}
 			}
-			rh.mu.RUnlock()
+			rh.mu.Unlock()
 		}
 	}
 }
 
-func (rh *RoomHub) startTimer(duration time.Duration) {
-	rh.stopTimer()
-
-	rh.timer = time.NewTicker(time.Second)
-	endTime := time.Now().Add(duration)
+// startSync holds the room's broadcasts to client back and catches it up
+// after lastSeq in the background. Runs in the loop.
+func (rh *RoomHub) startSync(client *Client, lastSeq int64) {
+	rh.mu.Lock()
+	_, ok := rh.syncing[client]
+	if !ok {
+		rh.syncing[client] = [][]byte{}
+	}
+	rh.mu.Unlock()
+	if ok {
+		// Already being caught up; that will do
+		return
+	}
 
 	go func() {
-		for {
-			select {
-			case <-rh.timerStop:
-				return
-			case t := <-rh.timer.C:
-				remaining := int(endTime.Sub(t).Seconds())
-				if remaining <= 0 {
-					// Timer ended, handle round end
-					rh.stopTimer()
-					go rh.handleRoundEnd()
-					return
-				}
-
-				msg, _ := json.Marshal(OutgoingMessage{
-					Type:    MsgTypeTimer,
-					Payload: TimerPayload{SecondsLeft: remaining},
-				})
-				rh.broadcast <- msg
-			}
+		seq := rh.syncClient(client, lastSeq)
+		select {
+		case rh.synced <- syncResult{client: client, seq: seq}:
+		case <-rh.stopped:
 		}
 	}()
 }
 
-func (rh *RoomHub) stopTimer() {
-	if rh.timer != nil {
-		rh.timer.Stop()
-		rh.timer = nil
+// finishSync sends client the broadcasts held back while it was caught up,
+// skipping room events it already got. Runs in the loop.
+func (rh *RoomHub) finishSync(res syncResult) {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+
+	held, ok := rh.syncing[res.client]
+	if !ok {
+		return
 	}
-	select {
-	case rh.timerStop <- struct{}{}:
-	default:
+	delete(rh.syncing, res.client)
+	for _, message := range held {
+		if seq := messageSeq(message); seq != 0 && seq <= res.seq {
+			continue
+		}
+		if !res.client.trySend(message) {
+			rh.dropLocked(res.client)
+			return
+		}
 	}
 }
 
+// dropLocked removes client from the room, closes it and marks its player
+// offline. It does nothing if client is no longer the user's connection in
+// the room. rh.mu must be held.
+func (rh *RoomHub) dropLocked(client *Client) {
+	if rh.clients[client.user.ID] != client {
+		return
+	}
+	delete(rh.clients, client.user.ID)
+	delete(rh.syncing, client)
+	client.close()
+	go rh.hub.disconnect(client)
+}
+
 func (rh *RoomHub) GetClientCount() int {
 	rh.mu.RLock()
 	defer rh.mu.RUnlock()
 	return len(rh.clients)
 }
 
-func (rh *RoomHub) handleRoundEnd() {
-	ctx := context.Background()
-
+// handleRoundEnd ends the current round of the room and starts the next one
+// or finishes the game. Rounds are claimed by number, so a round is ended only
+// once even if several timers fire for it. Runs in the room's actor.
+func (h *Hub) handleRoundEnd(ctx context.Context, roomID uuid.UUID) {
 	// Get current game state
-	gameState, err := rh.hub.gameService.GetGameState(ctx, rh.roomID)
+	gameState, err := h.gameService.GetGameState(ctx, roomID)
 	if err != nil || gameState == nil {
 		log.Printf("Error getting game state: %v", err)
 		return
 	}
 
+	claimed, err := h.gameService.ClaimRoundEnd(ctx, roomID, gameState.CurrentRound)
+	if err != nil {
+		log.Printf("Error claiming end of round %d in room %s: %v", gameState.CurrentRound, roomID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	// A swipe whose state update failed leaves the team scores behind
+	// round_words
+	if fixed, err := h.gameService.ReconcileTeamScores(ctx, roomID); err != nil {
+		log.Printf("Error reconciling team scores in room %s: %v", roomID, err)
+	} else if fixed {
+		log.Printf("Reconciled team scores in room %s", roomID)
+		if gameState, err = h.gameService.GetGameState(ctx, roomID); err != nil || gameState == nil {
+			log.Printf("Error getting game state: %v", err)
+			return
+		}
+	}
+
 	// Check win condition
-	hasWinner, winner, _ := rh.hub.gameService.CheckWinCondition(ctx, rh.roomID)
+	hasWinner, winner, _ := h.gameService.CheckWinCondition(ctx, roomID)
 
 	if hasWinner {
 		// Game ended - someone won
-		if err := rh.hub.gameService.EndGame(ctx, rh.roomID); err != nil {
+		if err := h.gameService.EndGame(ctx, roomID); err != nil {
 			log.Printf("Error ending game: %v", err)
 			return
 		}
 
 		// Broadcast game end
-		msg, _ := json.Marshal(OutgoingMessage{
-			Type: MsgTypeGameEnd,
-			Payload: GameEndPayload{
-				Winner:     winner,
-				TeamScores: gameState.TeamScores,
-			},
-		})
-		rh.broadcast <- msg
-		log.Printf("Game ended in room %s, winner: %s", rh.roomID, winner)
+		if msg, ok := encode(protocol.MsgTypeGameEnd, protocol.GameEndPayload{
+			Winner:     winner,
+			TeamScores: gameState.TeamScores,
+		}); ok {
+			h.BroadcastToRoom(roomID, msg)
+		}
+		log.Printf("Game ended in room %s, winner: %s", roomID, winner)
+
+		// The summary and the achievements do not depend on each other; one
+		// failing does not hold back the other
+		if summary, err := h.summary.GetSummary(ctx, roomID); err != nil {
+			log.Printf("Error summing up game in room %s: %v", roomID, err)
+		} else if summaryMsg, ok := encode(protocol.MsgTypeGameSummary, wireGameSummary(summary)); ok {
+			h.BroadcastToRoom(roomID, summaryMsg)
+		}
+
+		unlocked, err := h.achievements.OnGameEnd(ctx, roomID)
+		if err != nil {
+			log.Printf("Error evaluating achievements in room %s: %v", roomID, err)
+			return
+		}
+		h.announceAchievements(roomID, unlocked)
 	} else {
 		// Get room players for next round
-		players, err := rh.hub.roomService.GetRoomPlayers(ctx, rh.roomID)
+		players, err := h.roomService.GetRoomPlayers(ctx, roomID)
 		if err != nil {
 			log.Printf("Error getting room players: %v", err)
 			return
 		}
 
 		// Start next round
-		nextState, err := rh.hub.gameService.NextRound(ctx, rh.roomID, players)
+		nextState, err := h.gameService.NextRound(ctx, roomID, gameState.CurrentRound, players)
 		if err != nil {
 			log.Printf("Error starting next round: %v", err)
 			return
 		}
 
 		// Broadcast round end
-		msg, _ := json.Marshal(OutgoingMessage{
-			Type: MsgTypeRoundEnd,
-			Payload: RoundEndPayload{
-				Round:         nextState.CurrentRound - 1,
-				TeamScores:    nextState.TeamScores,
-				NextExplainer: nextState.CurrentExplainer,
-			},
-		})
-		rh.broadcast <- msg
+		if msg, ok := encode(protocol.MsgTypeRoundEnd, protocol.RoundEndPayload{
+			Round:         nextState.CurrentRound - 1,
+			TeamScores:    nextState.TeamScores,
+			NextExplainer: nextState.CurrentExplainer,
+		}); ok {
+			h.BroadcastToRoom(roomID, msg)
+		}
 
 		// Get room category
-		room, err := rh.hub.roomService.GetRoom(ctx, rh.roomID)
+		room, err := h.roomService.GetRoom(ctx, roomID)
 		if err != nil {
 			log.Printf("Error getting room: %v", err)
 			return
 		}
 
 		// Get first word for next round
-		nextWord, err := rh.hub.wordService.GetRandomWord(ctx, rh.roomID, "ru", room.Category)
+		nextWord, err := h.wordService.GetRandomWord(ctx, roomID, room.Lang, room.Category)
 		if err != nil {
 			log.Printf("Error getting next word: %v", err)
 			return
 		}
 
 		// Set current word
-		if err := rh.hub.gameService.SetCurrentWord(ctx, rh.roomID, nextWord); err != nil {
+		if err := h.gameService.SetCurrentWord(ctx, roomID, nextWord); err != nil {
 			log.Printf("Error setting current word: %v", err)
 			return
 		}
 
-		// Broadcast new word
-		newWordMsg, _ := json.Marshal(OutgoingMessage{
-			Type: MsgTypeNewWord,
-			Payload: NewWordPayload{
-				WordID: nextWord.ID,
-				Word:   nextWord.Word,
-			},
-		})
-		rh.broadcast <- newWordMsg
+		// Show the new word to whoever may see it
+		if err := h.dealWord(ctx, roomID, nextState.CurrentExplainer, nextWord); err != nil {
+			log.Printf("Error dealing word in room %s: %v", roomID, err)
+			return
+		}
 
-		// Start timer for next round
-		rh.startTimer(60 * time.Second)
-		log.Printf("Started round %d in room %s, explainer: %d", nextState.CurrentRound, rh.roomID, nextState.CurrentExplainer)
+		log.Printf("Started round %d in room %s, explainer: %d", nextState.CurrentRound, roomID, nextState.CurrentExplainer)
 	}
 }
 
-func (rh *RoomHub) sendGameStateToClient(client *Client) {
+// resume catches a connected client up after lastSeq.
+func (h *Hub) resume(client *Client, lastSeq int64) {
+	h.mu.RLock()
+	room, ok := h.rooms[client.roomID]
+	h.mu.RUnlock()
+
+	if ok {
+		select {
+		case room.resync <- syncRequest{client: client, lastSeq: lastSeq}:
+		case <-room.stopped:
+		}
+	}
+}
+
+// syncClient replays the room events the client missed after lastSeq, or
+// sends a room_state snapshot when they are no longer logged (or lastSeq is
+// 0), and finishes with a resumed message. It returns the seq the client is
+// up to date with. Runs outside the loop: it reads Redis and Postgres and
+// waits for room in the client's queue.
+func (rh *RoomHub) syncClient(client *Client, lastSeq int64) int64 {
 	ctx := context.Background()
 
-	// Get current game state
-	gameState, err := rh.hub.gameService.GetGameState(ctx, rh.roomID)
-	if err != nil || gameState == nil {
-		return
+	current, err := rh.hub.events.current(ctx, rh.roomID)
+	if err != nil {
+		log.Printf("Error getting last seq of room %s: %v", rh.roomID, err)
 	}
 
-	// Only send state if game is playing
-	if gameState.Status != "playing" {
-		return
+	if lastSeq > 0 {
+		events, complete, err := rh.hub.events.since(ctx, rh.roomID, lastSeq)
+		if err != nil {
+			log.Printf("Error reading event log of room %s: %v", rh.roomID, err)
+		}
+		if err == nil && complete {
+			for _, event := range events {
+				if !client.sendWait(event) {
+					return current
+				}
+			}
+			if n := int64(len(events)); lastSeq+n > current {
+				current = lastSeq + n
+			}
+			// new_word is not logged; the client may have missed it too
+			if err := rh.hub.sendCurrentWord(ctx, client); err != nil {
+				log.Printf("Error sending current word of room %s: %v", rh.roomID, err)
+			}
+			rh.sendResumed(client, protocol.ResumedPayload{LastSeq: current, Replayed: len(events)})
+			return current
+		}
 	}
 
-	// Send game_started event with current explainer
-	gameStartedMsg, _ := json.Marshal(OutgoingMessage{
-		Type: MsgTypeGameStarted,
-		Payload: GameStartedPayload{
-			ExplainerID: gameState.CurrentExplainer,
-			RoundEndAt:  gameState.RoundEndAt.Unix(),
-		},
-	})
-	client.send <- gameStartedMsg
-
-	// Send current word if exists
-	if gameState.CurrentWord != nil {
-		newWordMsg, _ := json.Marshal(OutgoingMessage{
-			Type: MsgTypeNewWord,
-			Payload: NewWordPayload{
-				WordID: gameState.CurrentWord.ID,
-				Word:   gameState.CurrentWord.Word,
-			},
-		})
-		client.send <- newWordMsg
+	if err := rh.hub.sendRoomState(client); err != nil {
+		log.Printf("Error building state of room %s: %v", rh.roomID, err)
+		client.replyError("", protocol.ErrCodeInternal, err)
 	}
+	rh.sendResumed(client, protocol.ResumedPayload{LastSeq: current, Snapshot: true})
+	return current
+}
 
-	// Send current scores
-	scoreMsg, _ := json.Marshal(OutgoingMessage{
-		Type: MsgTypeScoreUpdate,
-		Payload: ScoreUpdatePayload{
-			TeamScores: gameState.TeamScores,
-		},
-	})
-	client.send <- scoreMsg
-
-	// Restart timer if not running
-	remaining := gameState.RoundEndAt.Sub(time.Now())
-	if remaining > 0 {
-		// Check if timer is running, if not - start it
-		if rh.timer == nil {
-			rh.startTimer(remaining)
-		}
+func (rh *RoomHub) sendResumed(client *Client, payload protocol.ResumedPayload) {
+	if msg, ok := encode(protocol.MsgTypeResumed, payload); ok {
+		client.sendWait(msg)
+	}
+}
 
-		// Send current timer value
-		timerMsg, _ := json.Marshal(OutgoingMessage{
-			Type: MsgTypeTimer,
-			Payload: TimerPayload{
-				SecondsLeft: int(remaining.Seconds()),
-			},
-		})
-		client.send <- timerMsg
+// announceAchievements tells the room about achievements its players unlocked.
+func (h *Hub) announceAchievements(roomID uuid.UUID, unlocked []services.UnlockedAchievement) {
+	for _, u := range unlocked {
+		if msg, ok := encode(protocol.MsgTypeAchievement, protocol.AchievementUnlockedPayload{
+			UserID:      u.UserID,
+			Achievement: protocol.Achievement(u.Achievement),
+		}); ok {
+			h.BroadcastToRoom(roomID, msg)
+		}
 	}
 }
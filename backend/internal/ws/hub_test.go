@@ -0,0 +1,66 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestFinishSync(t *testing.T) {
+	client := newTestClient()
+	rh := &RoomHub{
+		roomID:  uuid.New(),
+		clients: map[int64]*Client{client.user.ID: client},
+		syncing: map[*Client][][]byte{},
+	}
+	rh.syncing[client] = [][]byte{
+		[]byte(`{"type":"team_changed","seq":4}`),
+		[]byte(`{"type":"timer","payload":{"seconds_left":3}}`),
+		[]byte(`{"type":"team_changed","seq":5}`),
+		[]byte(`{"type":"team_changed","seq":6}`),
+	}
+
+	// Caught up as of 5: held back events up to it were already sent
+	rh.finishSync(syncResult{client: client, seq: 5})
+
+	want := []string{
+		`{"type":"timer","payload":{"seconds_left":3}}`,
+		`{"type":"team_changed","seq":6}`,
+	}
+	if len(client.send) != len(want) {
+		t.Fatalf("Expected %d messages, got %d", len(want), len(client.send))
+	}
+	for _, w := range want {
+		if got := string(<-client.send); got != w {
+			t.Errorf("Expected %s, got %s", w, got)
+		}
+	}
+	if _, ok := rh.syncing[client]; ok {
+		t.Error("Expected client to be caught up")
+	}
+
+	// A second result for the same client is ignored
+	rh.finishSync(syncResult{client: client, seq: 0})
+	if len(client.send) != 0 {
+		t.Errorf("Expected no messages, got %d", len(client.send))
+	}
+}
+
+func TestMessageSeq(t *testing.T) {
+	tests := []struct {
+		message string
+		want    int64
+	}{
+		{`{"type":"team_changed","seq":7,"payload":{}}`, 7},
+		{`{"type":"timer","payload":{"seconds_left":3}}`, 0},
+		{`not json`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			if got := messageSeq([]byte(tt.message)); got != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yaroslav/elias/internal/services"
+	"github.com/yaroslav/elias/pkg/protocol"
+)
+
+// outboxPollInterval is how often events left in the outbox by a writer that
+// died before relaying them are picked up.
+const outboxPollInterval = 5 * time.Second
+
+// relayOutbox broadcasts the room's events written to the outbox.
+func (h *Hub) relayOutbox(ctx context.Context, roomID uuid.UUID) error {
+	_, err := h.gameService.RelayOutbox(ctx, roomID, h.publishOutbox)
+	return err
+}
+
+// pollOutbox relays events of any room left in the outbox. It returns when
+// the hub is closed.
+func (h *Hub) pollOutbox() {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+			if _, err := h.gameService.RelayAllOutbox(context.Background(), h.publishOutbox); err != nil {
+				log.Printf("Error relaying outbox: %v", err)
+			}
+		}
+	}
+}
+
+func (h *Hub) publishOutbox(e *services.OutboxEvent) {
+	msg, err := outboxMessage(e)
+	if err != nil {
+		// Publishing it again would not help; drop it
+		log.Printf("Dropped outbox event %d of room %s: %v", e.ID, e.RoomID, err)
+		return
+	}
+	h.BroadcastToRoom(e.RoomID, msg)
+}
+
+// outboxMessage turns an outbox event into a server message, checking it
+// against the protocol.
+func outboxMessage(e *services.OutboxEvent) ([]byte, error) {
+	msg, err := json.Marshal(protocol.OutgoingMessage{Type: e.Type, Payload: e.Payload})
+	if err != nil {
+		return nil, err
+	}
+	if _, _, _, err := protocol.DecodeOutgoing(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,38 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/yaroslav/elias/internal/services"
+	"github.com/yaroslav/elias/pkg/protocol"
+)
+
+func TestOutboxMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   services.OutboxEvent
+		wantErr bool
+	}{
+		{"word result", services.OutboxEvent{Type: protocol.MsgTypeWordResult, Payload: json.RawMessage(`{"word_id":1,"word":"cat","guessed":true}`)}, false},
+		{"score update", services.OutboxEvent{Type: protocol.MsgTypeScoreUpdate, Payload: json.RawMessage(`{"team_scores":{"a":1}}`)}, false},
+		{"client message", services.OutboxEvent{Type: protocol.MsgTypeSwipe, Payload: json.RawMessage(`{"action":"up"}`)}, true},
+		{"bad payload", services.OutboxEvent{Type: protocol.MsgTypeWordResult, Payload: json.RawMessage(`{"word_id":"one"}`)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := outboxMessage(&tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			msgType, _, _, err := protocol.DecodeOutgoing(msg)
+			if err != nil || msgType != tt.event.Type {
+				t.Errorf("Expected %s, got %s (%v)", tt.event.Type, msgType, err)
+			}
+		})
+	}
+}
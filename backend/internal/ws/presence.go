@@ -0,0 +1,68 @@
+package ws
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yaroslav/elias/internal/services"
+	"github.com/yaroslav/elias/pkg/protocol"
+)
+
+// heartbeat refreshes the client's presence and tells the room when the
+// player comes online.
+func (h *Hub) heartbeat(client *Client) {
+	cameOnline, err := h.presence.Heartbeat(context.Background(), client.roomID, client.user.ID, client.connID)
+	if err != nil {
+		log.Printf("Error updating presence of %d in room %s: %v", client.user.ID, client.roomID, err)
+		return
+	}
+	if cameOnline {
+		h.broadcastPresence(client.roomID, client.user.ID, protocol.MsgTypePlayerOnline)
+		h.resumeRound(client.roomID, client.user.ID)
+	}
+}
+
+// disconnect drops the client's connection from its player's presence and
+// tells the room if that was the player's last connection.
+func (h *Hub) disconnect(client *Client) {
+	wentOffline, err := h.presence.Disconnect(context.Background(), client.roomID, client.user.ID, client.connID)
+	if err != nil {
+		log.Printf("Error updating presence of %d in room %s: %v", client.user.ID, client.roomID, err)
+		return
+	}
+	if wentOffline {
+		h.broadcastPresence(client.roomID, client.user.ID, protocol.MsgTypePlayerOffline)
+		h.pauseRound(client.roomID, client.user.ID)
+	}
+}
+
+// expirePresence reports players whose connection died without a clean
+// disconnect, e.g. with a crashed instance. It returns when the hub is closed.
+func (h *Hub) expirePresence() {
+	ticker := time.NewTicker(services.PresenceHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+			expired, err := h.presence.Expire(context.Background())
+			if err != nil {
+				log.Printf("Error expiring presence: %v", err)
+			}
+			for _, entry := range expired {
+				h.broadcastPresence(entry.RoomID, entry.UserID, protocol.MsgTypePlayerOffline)
+				h.pauseRound(entry.RoomID, entry.UserID)
+			}
+		}
+	}
+}
+
+func (h *Hub) broadcastPresence(roomID uuid.UUID, userID int64, msgType protocol.MessageType) {
+	if msg, ok := encode(msgType, protocol.PlayerPresencePayload{UserID: userID}); ok {
+		h.BroadcastToRoom(roomID, msg)
+	}
+}
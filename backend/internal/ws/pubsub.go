@@ -0,0 +1,91 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// roomChannelPrefix is the Redis pub/sub channel prefix for room events. Every
+// instance with local clients in a room subscribes to that room's channel only.
+const roomChannelPrefix = "room_events:"
+
+// envelope wraps a published room message.
+type envelope struct {
+	RoomID  uuid.UUID       `json:"room_id"`
+	UserID  int64           `json:"user_id,omitempty"` // 0 = whole room
+	Message json.RawMessage `json:"message"`
+}
+
+// roomEnvelopePrefix is the start of the envelope of a room-wide message, for
+// scripts that build the message themselves; the envelope ends with "}".
+func roomEnvelopePrefix(roomID uuid.UUID) string {
+	return `{"room_id":"` + roomID.String() + `","message":`
+}
+
+// publisher fans room messages out to the backend instances through Redis
+// pub/sub, this one included: delivering only what comes back from Redis
+// keeps every instance on the same order.
+type publisher struct {
+	instanceID string
+	rdb        *redis.Client
+	pubsub     *redis.PubSub
+}
+
+func newPublisher(rdb *redis.Client) *publisher {
+	return &publisher{
+		instanceID: uuid.NewString(),
+		rdb:        rdb,
+		pubsub:     rdb.Subscribe(context.Background()),
+	}
+}
+
+func roomChannel(roomID uuid.UUID) string {
+	return roomChannelPrefix + roomID.String()
+}
+
+// publish sends message to every instance. userID 0 addresses the whole room.
+func (p *publisher) publish(roomID uuid.UUID, userID int64, message []byte) {
+	data, err := json.Marshal(envelope{
+		RoomID:  roomID,
+		UserID:  userID,
+		Message: message,
+	})
+	if err != nil {
+		return
+	}
+	if err := p.rdb.Publish(context.Background(), roomChannel(roomID), data).Err(); err != nil {
+		log.Printf("Error publishing to room %s: %v", roomID, err)
+	}
+}
+
+func (p *publisher) subscribe(roomID uuid.UUID) {
+	if err := p.pubsub.Subscribe(context.Background(), roomChannel(roomID)); err != nil {
+		log.Printf("Error subscribing to room %s: %v", roomID, err)
+	}
+}
+
+func (p *publisher) unsubscribe(roomID uuid.UUID) {
+	if err := p.pubsub.Unsubscribe(context.Background(), roomChannel(roomID)); err != nil {
+		log.Printf("Error unsubscribing from room %s: %v", roomID, err)
+	}
+}
+
+// receive calls deliver for every message published to the rooms this
+// instance subscribes to until the subscription is closed.
+func (p *publisher) receive(deliver func(env *envelope)) {
+	for msg := range p.pubsub.Channel() {
+		var env envelope
+		if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+			continue
+		}
+		deliver(&env)
+	}
+}
+
+func (p *publisher) close() error {
+	return p.pubsub.Close()
+}
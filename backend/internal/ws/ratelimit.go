@@ -0,0 +1,40 @@
+package ws
+
+import "time"
+
+// Clients may send commandBurst messages at once, refilled at commandRate
+// per second. Faster clients get rate_limited errors.
+const (
+	commandRate  = 10
+	commandBurst = 20
+)
+
+// rateLimiter is a token bucket. It is used from the client's read loop only
+// and is not safe for concurrent use.
+type rateLimiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate, burst float64) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: burst, tokens: burst}
+}
+
+// allow takes a token if one is available at now.
+func (l *rateLimiter) allow(now time.Time) bool {
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
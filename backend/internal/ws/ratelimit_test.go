@@ -0,0 +1,40 @@
+package ws
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	start := time.Now()
+	l := newRateLimiter(2, 3)
+
+	for i := 0; i < 3; i++ {
+		if !l.allow(start) {
+			t.Fatalf("Expected burst message %d to be allowed", i+1)
+		}
+	}
+	if l.allow(start) {
+		t.Error("Expected message over burst to be limited")
+	}
+
+	// Two tokens per second
+	if !l.allow(start.Add(500 * time.Millisecond)) {
+		t.Error("Expected message after refill to be allowed")
+	}
+	if l.allow(start.Add(500 * time.Millisecond)) {
+		t.Error("Expected second message without refill to be limited")
+	}
+
+	// Refill never exceeds the burst
+	later := start.Add(time.Hour)
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if l.allow(later) {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("Expected %d, got %d", 3, allowed)
+	}
+}
@@ -0,0 +1,153 @@
+package ws
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yaroslav/elias/internal/models"
+	"github.com/yaroslav/elias/internal/services"
+	"github.com/yaroslav/elias/pkg/protocol"
+)
+
+// sendRoomState sends the client a room_state snapshot.
+func (h *Hub) sendRoomState(client *Client) error {
+	state, err := h.roomState(context.Background(), client.roomID, client.user.ID)
+	if err != nil {
+		return err
+	}
+
+	msg, err := protocol.Encode(protocol.MsgTypeRoomState, state)
+	if err != nil {
+		return err
+	}
+	client.sendWait(msg)
+	return nil
+}
+
+// dealWord shows word to the players allowed to see it. It is sent to each of
+// them rather than broadcast, so it reaches neither the explainer's teammates
+// nor the room's event log.
+func (h *Hub) dealWord(ctx context.Context, roomID uuid.UUID, explainerID int64, word *models.Word) error {
+	players, err := h.roomService.GetRoomPlayers(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	msg, err := protocol.Encode(protocol.MsgTypeNewWord, protocol.NewWordPayload{
+		WordID: word.ID,
+		Word:   word.Word,
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range players {
+		if services.CanSeeWord(players, explainerID, p.UserID) {
+			h.SendToUser(roomID, p.UserID, msg)
+		}
+	}
+	return nil
+}
+
+// sendCurrentWord sends the client the word being explained, if there is one
+// and the client may see it.
+func (h *Hub) sendCurrentWord(ctx context.Context, client *Client) error {
+	gameState, err := h.gameService.GetGameState(ctx, client.roomID)
+	if err != nil || gameState == nil || gameState.CurrentWord == nil || gameState.Status != string(models.RoomStatusPlaying) {
+		return err
+	}
+	players, err := h.roomService.GetRoomPlayers(ctx, client.roomID)
+	if err != nil {
+		return err
+	}
+	if !services.CanSeeWord(players, gameState.CurrentExplainer, client.user.ID) {
+		return nil
+	}
+
+	msg, err := protocol.Encode(protocol.MsgTypeNewWord, protocol.NewWordPayload{
+		WordID: gameState.CurrentWord.ID,
+		Word:   gameState.CurrentWord.Word,
+	})
+	if err != nil {
+		return err
+	}
+	client.sendWait(msg)
+	return nil
+}
+
+// roomState builds the room snapshot as seen by userID.
+func (h *Hub) roomState(ctx context.Context, roomID uuid.UUID, userID int64) (*protocol.RoomStatePayload, error) {
+	room, err := h.roomService.GetRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	players, err := h.roomService.GetRoomPlayers(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if players == nil {
+		players = []*models.Player{}
+	}
+
+	if err := h.presence.MarkOnline(ctx, roomID, players); err != nil {
+		return nil, err
+	}
+
+	state := &protocol.RoomStatePayload{
+		Room:    wireRoom(room),
+		Players: WirePlayers(players),
+		Teams:   WireTeams(room.Teams),
+		Rules:   rulesPayload(room),
+	}
+
+	gameState, err := h.gameService.GetGameState(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	lastRound := 0
+	switch {
+	case gameState != nil:
+		game := &protocol.GameStatePayload{
+			Status:         gameState.Status,
+			CurrentRound:   gameState.CurrentRound,
+			ExplainerID:    gameState.CurrentExplainer,
+			RoundEndAt:     gameState.RoundEndAt.Unix(),
+			WordsThisRound: gameState.WordsThisRound,
+			TeamScores:     gameState.TeamScores,
+		}
+		if gameState.Status == string(models.RoomStatusPlaying) {
+			remaining := time.Until(gameState.RoundEndAt)
+			if gameState.Paused {
+				remaining = gameState.Remaining
+				game.Paused = true
+				game.ResumeDeadline = gameState.PausedUntil.Unix()
+			}
+			if remaining > 0 {
+				game.SecondsLeft = int(remaining.Seconds())
+			}
+			if gameState.CurrentWord != nil && services.CanSeeWord(players, gameState.CurrentExplainer, userID) {
+				game.CurrentWord = &protocol.NewWordPayload{
+					WordID: gameState.CurrentWord.ID,
+					Word:   gameState.CurrentWord.Word,
+				}
+			}
+			lastRound = gameState.CurrentRound - 1
+		} else {
+			lastRound = gameState.CurrentRound
+		}
+		state.Game = game
+	case room.Status == models.RoomStatusFinished:
+		lastRound = room.CurrentRound
+	}
+
+	if lastRound > 0 {
+		summary, err := h.wordService.GetRoundSummary(ctx, roomID, lastRound)
+		if err != nil {
+			return nil, err
+		}
+		state.LastRound = wireRoundSummary(summary)
+	}
+
+	return state, nil
+}
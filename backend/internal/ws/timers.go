@@ -0,0 +1,218 @@
+package ws
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/yaroslav/elias/pkg/protocol"
+)
+
+const (
+	// timerLeaseTTL is how long a room's timer lease lives without renewal.
+	// Another instance takes the room over at most this long after the owner dies.
+	timerLeaseTTL = 5 * time.Second
+
+	timerTick = time.Second
+)
+
+// renewLeaseScript extends the lease only if this instance still holds it.
+var renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLeaseScript deletes the lease only if this instance still holds it.
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func timerLeaseKey(roomID uuid.UUID) string {
+	return "timer_owner:" + roomID.String()
+}
+
+// roundTimers drives round timers for the rooms this instance owns. Ownership
+// is a Redis lease per room, so exactly one instance ticks a room and ends its
+// rounds. Deadlines live in GameService, which lets any instance pick up a room
+// whose owner went away.
+type roundTimers struct {
+	hub     *Hub
+	mu      sync.Mutex
+	running map[uuid.UUID]chan struct{}
+	wg      sync.WaitGroup
+	done    chan struct{}
+}
+
+func newRoundTimers(hub *Hub) *roundTimers {
+	return &roundTimers{
+		hub:     hub,
+		running: make(map[uuid.UUID]chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// start takes ownership of the room's timer if nobody holds it.
+func (t *roundTimers) start(roomID uuid.UUID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.running[roomID]; ok {
+		return
+	}
+
+	acquired, err := t.hub.rdb.SetNX(context.Background(), timerLeaseKey(roomID), t.hub.publisher.instanceID, timerLeaseTTL).Result()
+	if err != nil {
+		log.Printf("Error acquiring timer lease for room %s: %v", roomID, err)
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	stop := make(chan struct{}, 1)
+	t.running[roomID] = stop
+	t.wg.Add(1)
+	go t.own(roomID, stop)
+}
+
+// stop stops the local timer of the room and gives up its lease.
+func (t *roundTimers) stop(roomID uuid.UUID) {
+	t.mu.Lock()
+	stop, ok := t.running[roomID]
+	t.mu.Unlock()
+
+	if ok {
+		select {
+		case stop <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// poll picks up rooms with a running round that no instance is ticking. It
+// returns when the timers are closed.
+func (t *roundTimers) poll() {
+	ticker := time.NewTicker(timerTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+			roomIDs, err := t.hub.gameService.ScheduledRooms(context.Background())
+			if err != nil {
+				log.Printf("Error listing scheduled rounds: %v", err)
+				continue
+			}
+			for _, roomID := range roomIDs {
+				t.start(roomID)
+			}
+		}
+	}
+}
+
+// recover re-arms the timers of games that were running before a restart.
+// Rounds whose deadline passed while no instance was up are ended right away.
+func (t *roundTimers) recover(ctx context.Context) (int, error) {
+	roomIDs, err := t.hub.gameService.RestoreRoundDeadlines(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, roomID := range roomIDs {
+		deadline, ok, err := t.hub.gameService.RoundDeadline(ctx, roomID)
+		if err != nil {
+			return 0, err
+		}
+		if ok && !time.Now().Before(deadline) {
+			err := t.hub.do(ctx, roomID, func(ctx context.Context) error {
+				t.hub.handleRoundEnd(ctx, roomID)
+				return nil
+			})
+			if err != nil {
+				return 0, err
+			}
+		}
+		t.start(roomID)
+	}
+	return len(roomIDs), nil
+}
+
+// close stops polling and every local timer and waits until their leases are
+// released, so other instances can take the rooms over straight away.
+func (t *roundTimers) close() {
+	close(t.done)
+	t.wg.Wait()
+}
+
+func (t *roundTimers) own(roomID uuid.UUID, stop chan struct{}) {
+	ctx := context.Background()
+	key := timerLeaseKey(roomID)
+	instanceID := t.hub.publisher.instanceID
+
+	ticker := time.NewTicker(timerTick)
+	defer func() {
+		defer t.wg.Done()
+		ticker.Stop()
+		if err := releaseLeaseScript.Run(ctx, t.hub.rdb, []string{key}, instanceID).Err(); err != nil {
+			log.Printf("Error releasing timer lease for room %s: %v", roomID, err)
+		}
+		t.mu.Lock()
+		delete(t.running, roomID)
+		t.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.done:
+			return
+		case <-ticker.C:
+			renewed, err := renewLeaseScript.Run(ctx, t.hub.rdb, []string{key}, instanceID, timerLeaseTTL.Milliseconds()).Int()
+			if err != nil {
+				log.Printf("Error renewing timer lease for room %s: %v", roomID, err)
+				continue
+			}
+			if renewed == 0 {
+				log.Printf("Lost timer lease for room %s", roomID)
+				return
+			}
+
+			deadline, ok, err := t.hub.gameService.RoundDeadline(ctx, roomID)
+			if err != nil {
+				log.Printf("Error getting round deadline for room %s: %v", roomID, err)
+				continue
+			}
+			if !ok {
+				// Game is over or waits for the host to skip the explainer
+				return
+			}
+
+			remaining := int(time.Until(deadline).Seconds())
+			if remaining <= 0 {
+				t.hub.handleDeadline(roomID)
+				continue
+			}
+
+			// A paused round has no clock to show
+			state, err := t.hub.gameService.GetGameState(ctx, roomID)
+			if err != nil || state == nil || state.Paused {
+				continue
+			}
+
+			if msg, ok := encode(protocol.MsgTypeTimer, protocol.TimerPayload{SecondsLeft: remaining}); ok {
+				t.hub.BroadcastToRoom(roomID, msg)
+			}
+		}
+	}
+}
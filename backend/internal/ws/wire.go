@@ -0,0 +1,120 @@
+package ws
+
+import (
+	"github.com/yaroslav/elias/internal/models"
+	"github.com/yaroslav/elias/pkg/protocol"
+)
+
+// Mapping of models to their protocol wire types. Flat types convert
+// directly; a model field missing on the wire fails to compile here instead
+// of leaking to clients. Nil slices stay nil so the JSON does not change.
+
+func WirePlayer(p *models.Player) *protocol.Player {
+	if p == nil {
+		return nil
+	}
+	w := protocol.Player(*p)
+	return &w
+}
+
+func WirePlayers(players []*models.Player) []*protocol.Player {
+	if players == nil {
+		return nil
+	}
+	out := make([]*protocol.Player, len(players))
+	for i, p := range players {
+		out[i] = WirePlayer(p)
+	}
+	return out
+}
+
+func WireTeams(teams []models.Team) []protocol.Team {
+	if teams == nil {
+		return nil
+	}
+	out := make([]protocol.Team, len(teams))
+	for i, t := range teams {
+		out[i] = protocol.Team(t)
+	}
+	return out
+}
+
+func wireRoom(r *models.Room) *protocol.Room {
+	if r == nil {
+		return nil
+	}
+	return &protocol.Room{
+		ID:                 r.ID,
+		Code:               r.Code,
+		Status:             string(r.Status),
+		CurrentRound:       r.CurrentRound,
+		CurrentExplainerID: r.CurrentExplainerID,
+		RoundEndAt:         r.RoundEndAt,
+		Category:           r.Category,
+		Lang:               r.Lang,
+		IsPublic:           r.IsPublic,
+		RoundSeconds:       r.RoundSeconds,
+		WinningScore:       r.WinningScore,
+		NumTeams:           r.NumTeams,
+		Teams:              WireTeams(r.Teams),
+		TeamNames:          r.TeamNames,
+		RematchRoomID:      r.RematchRoomID,
+		ChatID:             r.ChatID,
+		CreatedAt:          r.CreatedAt,
+	}
+}
+
+func wireRoundSummary(s *models.RoundSummary) *protocol.RoundSummary {
+	if s == nil {
+		return nil
+	}
+	var words []*protocol.RoundEntry
+	if s.Words != nil {
+		words = make([]*protocol.RoundEntry, len(s.Words))
+		for i, e := range s.Words {
+			w := protocol.RoundEntry(*e)
+			words[i] = &w
+		}
+	}
+	return &protocol.RoundSummary{
+		RoundNum:     s.RoundNum,
+		Words:        words,
+		WordsGuessed: s.WordsGuessed,
+		WordsMissed:  s.WordsMissed,
+	}
+}
+
+func wireGameSummary(s *models.GameSummary) *protocol.GameSummary {
+	out := &protocol.GameSummary{
+		RoomID: s.RoomID,
+		Winner: s.Winner,
+		MVPID:  s.MVPID,
+	}
+	if s.Standings != nil {
+		out.Standings = make([]*protocol.TeamStanding, len(s.Standings))
+		for i, st := range s.Standings {
+			w := protocol.TeamStanding(*st)
+			out.Standings[i] = &w
+		}
+	}
+	if s.Players != nil {
+		out.Players = make([]*protocol.PlayerSummary, len(s.Players))
+		for i, p := range s.Players {
+			w := protocol.PlayerSummary(*p)
+			out.Players[i] = &w
+		}
+	}
+	if s.BestRound != nil {
+		w := protocol.RoundHighlight(*s.BestRound)
+		out.BestRound = &w
+	}
+	if s.LongestStreak != nil {
+		w := protocol.StreakHighlight(*s.LongestStreak)
+		out.LongestStreak = &w
+	}
+	if s.HardestWord != nil {
+		w := protocol.WordHighlight(*s.HardestWord)
+		out.HardestWord = &w
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yaroslav/elias/internal/models"
+)
+
+// TestWireMatchesModels checks that the wire types encode exactly like the
+// models they replaced, so clients see no difference.
+func TestWireMatchesModels(t *testing.T) {
+	explainer := int64(7)
+	roundEnd := time.Unix(1700000000, 0).UTC()
+	room := &models.Room{
+		ID:                 uuid.New(),
+		Code:               "ABCD",
+		Status:             models.RoomStatusPlaying,
+		CurrentRound:       2,
+		CurrentExplainerID: &explainer,
+		RoundEndAt:         &roundEnd,
+		Category:           "general",
+		Lang:               "ru",
+		RoundSeconds:       60,
+		WinningScore:       30,
+		NumTeams:           2,
+		Teams:              []models.Team{{ID: "t1", Name: "Red"}, {ID: "t2", Name: "Blue"}},
+		TeamNames:          []string{"Red", "Blue"},
+		CreatedAt:          roundEnd,
+	}
+	player := &models.Player{ID: 1, RoomID: room.ID, UserID: 7, FirstName: "Ann", Team: "t1", Score: 3, IsHost: true}
+	round := &models.RoundSummary{
+		RoundNum:     1,
+		Words:        []*models.RoundEntry{{WordID: 1, Word: "кот", Guessed: true}},
+		WordsGuessed: 1,
+	}
+	summary := &models.GameSummary{
+		RoomID:        room.ID,
+		Winner:        "t1",
+		Standings:     []*models.TeamStanding{{Team: "t1", Name: "Red", Score: 30, Place: 1}},
+		Players:       []*models.PlayerSummary{{UserID: 7, FirstName: "Ann", Team: "t1", WordsGuessed: 5, Efficiency: 0.5}},
+		MVPID:         7,
+		BestRound:     &models.RoundHighlight{RoundNum: 1, ExplainerID: 7, WordsGuessed: 5},
+		LongestStreak: &models.StreakHighlight{RoundNum: 1, ExplainerID: 7, Length: 3},
+		HardestWord:   &models.WordHighlight{WordID: 1, Word: "кот", RoundNum: 1, ExplainerID: 7, GuessRate: 0.1},
+	}
+
+	tests := []struct {
+		name  string
+		model interface{}
+		wire  interface{}
+	}{
+		{"Room", room, wireRoom(room)},
+		{"Player", player, WirePlayer(player)},
+		{"Teams", room.Teams, WireTeams(room.Teams)},
+		{"Round summary", round, wireRoundSummary(round)},
+		{"Game summary", summary, wireGameSummary(summary)},
+		{"Empty game summary", &models.GameSummary{RoomID: room.ID}, wireGameSummary(&models.GameSummary{RoomID: room.ID})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := json.Marshal(tt.model)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.Marshal(tt.wire)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("Expected %s, got %s", want, got)
+			}
+		})
+	}
+}
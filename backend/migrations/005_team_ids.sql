@@ -0,0 +1,16 @@
+-- Stable team ids: players.team now references rooms.teams[].id instead of the display name
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS teams JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+-- Backfill teams from team_names, assigning ids t1..tN in the original order
+UPDATE rooms r
+SET teams = COALESCE((
+    SELECT jsonb_agg(jsonb_build_object('id', 't' || t.ord, 'name', t.name) ORDER BY t.ord)
+    FROM jsonb_array_elements_text(r.team_names) WITH ORDINALITY AS t(name, ord)
+), '[]'::jsonb)
+WHERE r.teams = '[]'::jsonb;
+
+-- Point existing players at the team id instead of the name
+UPDATE players p
+SET team = t.id
+FROM rooms r, jsonb_to_recordset(r.teams) AS t(id TEXT, name TEXT)
+WHERE p.room_id = r.id AND p.team = t.name;
@@ -0,0 +1,2 @@
+-- Room language: selects the team-name generator and the word list
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS lang VARCHAR(2) NOT NULL DEFAULT 'ru';
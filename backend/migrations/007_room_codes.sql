@@ -0,0 +1,4 @@
+-- Short human-friendly room codes, unique among rooms that are not finished
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS code VARCHAR(6);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rooms_active_code ON rooms(code) WHERE status <> 'finished';
@@ -0,0 +1,4 @@
+-- Public rooms are listed in the room browser and used by quick-match
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS is_public BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_rooms_public_lobby ON rooms(lang, category) WHERE is_public AND status = 'lobby';
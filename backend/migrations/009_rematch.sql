@@ -0,0 +1,2 @@
+-- Link a finished room to the rematch room created from it
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS rematch_room_id UUID REFERENCES rooms(id) ON DELETE SET NULL;
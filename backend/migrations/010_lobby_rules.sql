@@ -0,0 +1,6 @@
+-- Rules the host can change in the lobby
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS round_seconds INT NOT NULL DEFAULT 60;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS winning_score INT NOT NULL DEFAULT 20;
+
+-- Players mark themselves ready in the lobby
+ALTER TABLE players ADD COLUMN IF NOT EXISTS ready BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,31 @@
+-- Who explained a word and which team scored it, so team scores can be
+-- recomputed from round_words
+ALTER TABLE round_words ADD COLUMN IF NOT EXISTS explainer_id BIGINT;
+ALTER TABLE round_words ADD COLUMN IF NOT EXISTS team VARCHAR(100);
+
+-- A word is played once per room, which makes recording a swipe idempotent.
+-- Older rooms may have played a word twice; all but the first row of each are
+-- moved to round_words_duplicates rather than dropped, so they can be
+-- inspected or restored.
+CREATE TABLE IF NOT EXISTS round_words_duplicates (LIKE round_words INCLUDING DEFAULTS);
+
+WITH duplicates AS (
+    DELETE FROM round_words a USING round_words b
+    WHERE a.room_id = b.room_id AND a.word_id = b.word_id AND a.id > b.id
+    RETURNING a.*
+)
+INSERT INTO round_words_duplicates SELECT * FROM duplicates;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_round_words_room_word ON round_words(room_id, word_id);
+
+-- Room events written in the same transaction as the change they describe and
+-- relayed to clients afterwards
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox(room_id, id) WHERE published_at IS NULL;
@@ -0,0 +1,10 @@
+-- Append-only log of every game transition, used to replay finished games
+CREATE TABLE IF NOT EXISTS game_events (
+    id BIGSERIAL PRIMARY KEY,
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_events_room_id ON game_events(room_id, id);
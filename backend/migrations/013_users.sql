@@ -0,0 +1,27 @@
+-- Telegram users, upserted on every authenticated request
+CREATE TABLE IF NOT EXISTS users (
+    id BIGINT PRIMARY KEY,
+    username VARCHAR(100),
+    first_name VARCHAR(100),
+    last_name VARCHAR(100),
+    photo_url TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Everybody who already played gets a user
+INSERT INTO users (id, username, first_name)
+SELECT DISTINCT ON (user_id) user_id, username, first_name
+FROM players
+ORDER BY user_id, joined_at DESC
+ON CONFLICT (id) DO NOTHING;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'players_user_id_fkey') THEN
+        ALTER TABLE players ADD CONSTRAINT players_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
+    END IF;
+END $$;
+
+-- Team that won a finished game; NULL for a draw
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS winner VARCHAR(100);
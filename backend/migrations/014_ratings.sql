@@ -0,0 +1,26 @@
+-- Skill rating of a user, kept per word language
+CREATE TABLE IF NOT EXISTS ratings (
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    lang VARCHAR(2) NOT NULL,
+    rating INTEGER NOT NULL,
+    games INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, lang)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ratings_lang ON ratings(lang, rating DESC);
+
+-- Rating change of every player of every rated game
+CREATE TABLE IF NOT EXISTS rating_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    lang VARCHAR(2) NOT NULL,
+    rating_before INTEGER NOT NULL,
+    rating_after INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (room_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rating_history_user ON rating_history(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_rating_history_lang ON rating_history(lang, created_at);
@@ -0,0 +1,4 @@
+-- Telegram group chat a room was started from; NULL for rooms outside a chat
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS chat_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_rooms_chat ON rooms(chat_id, created_at) WHERE chat_id IS NOT NULL;
@@ -0,0 +1,8 @@
+-- Achievements unlocked by users; the rules are in backend/internal/services/achievements.json
+CREATE TABLE IF NOT EXISTS user_achievements (
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    achievement_id VARCHAR(64) NOT NULL,
+    room_id UUID REFERENCES rooms(id) ON DELETE SET NULL,
+    unlocked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, achievement_id)
+);
@@ -0,0 +1,261 @@
+// Package client connects to a game room over WebSocket and speaks the
+// protocol defined in package protocol. It is meant for bots, load tests and
+// integration tests.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/google/uuid"
+	"github.com/yaroslav/elias/pkg/protocol"
+)
+
+var ErrNoHello = errors.New("server did not send hello")
+
+// CommandError is a command rejected by the server.
+type CommandError struct {
+	Code    protocol.ErrorCode
+	Message string
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Message is a decoded server message. Payload points to the payload struct
+// registered for Type, e.g. *protocol.NewWordPayload for new_word.
+type Message struct {
+	Type    protocol.MessageType
+	Seq     int64
+	Payload interface{}
+}
+
+type Options struct {
+	// InitData is the Telegram WebApp init data used to authenticate.
+	InitData string
+	// LastSeq resumes from the given room event instead of a snapshot.
+	LastSeq int64
+	// Version is the protocol version to ask for; 0 asks for the newest.
+	Version int
+}
+
+// Client is a connection to one room. Read must not be called concurrently;
+// sends are safe to use from several goroutines.
+type Client struct {
+	conn    *websocket.Conn
+	version int
+	lastSeq int64
+	writeMu sync.Mutex
+	nextID  atomic.Int64
+}
+
+// Dial connects to the room on the server at baseURL (e.g.
+// "ws://localhost:8080") and waits for the server's hello, if the version
+// asked for has one.
+func Dial(ctx context.Context, baseURL string, roomID uuid.UUID, opts Options) (*Client, error) {
+	version := opts.Version
+	if version == 0 {
+		version = protocol.ProtocolVersion
+	}
+
+	query := url.Values{}
+	query.Set("init_data", opts.InitData)
+	query.Set("v", strconv.Itoa(version))
+	if opts.LastSeq > 0 {
+		query.Set("last_seq", strconv.FormatInt(opts.LastSeq, 10))
+	}
+	u := strings.TrimRight(baseURL, "/") + "/ws/" + roomID.String() + "?" + query.Encode()
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{conn: conn, version: version, lastSeq: opts.LastSeq}
+	if version < protocol.HelloVersion {
+		return c, nil
+	}
+
+	msg, err := c.Read(ctx)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	hello, ok := msg.Payload.(*protocol.HelloPayload)
+	if msg.Type != protocol.MsgTypeHello || !ok {
+		conn.Close()
+		return nil, ErrNoHello
+	}
+	c.version = hello.ProtocolVersion
+	return c, nil
+}
+
+// Version returns the protocol version negotiated with the server.
+func (c *Client) Version() int {
+	return c.version
+}
+
+// LastSeq returns the seq of the last room event read, to pass as
+// Options.LastSeq when reconnecting.
+func (c *Client) LastSeq() int64 {
+	return c.lastSeq
+}
+
+// Read returns the next server message. Room events that were already seen
+// (replayed and broadcast at the same time) are skipped.
+func (c *Client) Read(ctx context.Context) (*Message, error) {
+	deadline, _ := ctx.Deadline()
+	if err := c.conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		msgType, seq, payload, err := protocol.DecodeOutgoing(data)
+		if err != nil {
+			return nil, err
+		}
+		if seq > 0 {
+			if seq <= c.lastSeq {
+				continue
+			}
+			c.lastSeq = seq
+		}
+		if payload, ok := payload.(*protocol.ResumedPayload); ok && (payload.Snapshot || payload.LastSeq > c.lastSeq) {
+			c.lastSeq = payload.LastSeq
+		}
+		return &Message{Type: msgType, Seq: seq, Payload: payload}, nil
+	}
+}
+
+// WaitFor reads messages until one of type t arrives.
+func (c *Client) WaitFor(ctx context.Context, t protocol.MessageType) (*Message, error) {
+	for {
+		msg, err := c.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if msg.Type == t {
+			return msg, nil
+		}
+	}
+}
+
+// Send sends a client message. payload must be the payload struct registered
+// for t, or nil for messages without payload.
+func (c *Client) Send(t protocol.MessageType, payload interface{}) error {
+	return c.send(t, "", payload)
+}
+
+// Request sends a command with a fresh request id and returns the id, to be
+// matched with the server's ack or error by WaitReply.
+func (c *Client) Request(t protocol.MessageType, payload interface{}) (string, error) {
+	requestID := "r" + strconv.FormatInt(c.nextID.Add(1), 10)
+	return requestID, c.send(t, requestID, payload)
+}
+
+// WaitReply reads messages until the ack or error for requestID arrives. It
+// returns a *CommandError if the command failed. Other messages read meanwhile
+// are dropped.
+func (c *Client) WaitReply(ctx context.Context, requestID string) error {
+	for {
+		msg, err := c.Read(ctx)
+		if err != nil {
+			return err
+		}
+		switch payload := msg.Payload.(type) {
+		case *protocol.AckPayload:
+			if payload.RequestID == requestID {
+				return nil
+			}
+		case *protocol.ErrorPayload:
+			if payload.RequestID == requestID {
+				return &CommandError{Code: payload.Code, Message: payload.Message}
+			}
+		}
+	}
+}
+
+// Do sends a command and waits for its reply, see WaitReply.
+func (c *Client) Do(ctx context.Context, t protocol.MessageType, payload interface{}) error {
+	requestID, err := c.Request(t, payload)
+	if err != nil {
+		return err
+	}
+	return c.WaitReply(ctx, requestID)
+}
+
+func (c *Client) send(t protocol.MessageType, requestID string, payload interface{}) error {
+	data, err := protocol.EncodeIncoming(t, requestID, payload)
+	if err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second)); err != nil {
+		return err
+	}
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// Swipe sends the explainer's verdict on the current word: "up" for guessed,
+// "down" for missed.
+func (c *Client) Swipe(action string) error {
+	if action != "up" && action != "down" {
+		return fmt.Errorf("invalid swipe action %q", action)
+	}
+	return c.Send(protocol.MsgTypeSwipe, protocol.SwipePayload{Action: action})
+}
+
+// GetState asks for a room_state snapshot.
+func (c *Client) GetState() error {
+	return c.Send(protocol.MsgTypeGetState, nil)
+}
+
+// Resume asks the server to replay the room events after lastSeq.
+func (c *Client) Resume(lastSeq int64) error {
+	return c.Send(protocol.MsgTypeResume, protocol.ResumePayload{LastSeq: lastSeq})
+}
+
+// ChangeTeam moves the player to a team; an empty team leaves it.
+func (c *Client) ChangeTeam(ctx context.Context, team string) error {
+	return c.Do(ctx, protocol.MsgTypeChangeTeam, protocol.ChangeTeamPayload{Team: team})
+}
+
+// StartGame starts the game. Host only.
+func (c *Client) StartGame(ctx context.Context) error {
+	return c.Do(ctx, protocol.MsgTypeStartGame, nil)
+}
+
+// Kick removes a player from the lobby. Host only.
+func (c *Client) Kick(ctx context.Context, userID int64) error {
+	return c.Do(ctx, protocol.MsgTypeKick, protocol.KickPayload{UserID: userID})
+}
+
+// SetRules changes the room rules. Host only.
+func (c *Client) SetRules(ctx context.Context, rules protocol.SetRulesPayload) error {
+	return c.Do(ctx, protocol.MsgTypeSetRules, rules)
+}
+
+func (c *Client) Ready(ctx context.Context, ready bool) error {
+	return c.Do(ctx, protocol.MsgTypeReady, protocol.ReadyPayload{Ready: ready})
+}
+
+func (c *Client) Close() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	return c.conn.Close()
+}
@@ -0,0 +1,302 @@
+// Package protocol defines the WebSocket protocol between the game server
+// and its clients: message types, their payloads and the protocol version.
+package protocol
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+type MessageType string
+
+const (
+	// Client -> Server
+	MsgTypeSwipe     MessageType = "swipe"
+	MsgTypeVoteStart MessageType = "vote_start"
+	MsgTypeVotePause MessageType = "vote_pause"
+	MsgTypeResume    MessageType = "resume"
+	MsgTypeGetState  MessageType = "get_state"
+
+	// Client -> Server lobby and game commands
+	MsgTypeChangeTeam MessageType = "change_team"
+	MsgTypeStartGame  MessageType = "start_game"
+	MsgTypeKick       MessageType = "kick"
+	MsgTypeSetRules   MessageType = "set_rules"
+	MsgTypeReady      MessageType = "ready"
+
+	// Server -> Client
+	MsgTypeHello         MessageType = "hello"
+	MsgTypeAck           MessageType = "ack"
+	MsgTypePlayerJoined  MessageType = "player_joined"
+	MsgTypePlayerLeft    MessageType = "player_left"
+	MsgTypeTeamChanged   MessageType = "team_changed"
+	MsgTypeGameStarted   MessageType = "game_started"
+	MsgTypeNewWord       MessageType = "new_word"
+	MsgTypeWordResult    MessageType = "word_result"
+	MsgTypeTimer         MessageType = "timer"
+	MsgTypeRoundEnd      MessageType = "round_end"
+	MsgTypeGameEnd       MessageType = "game_end"
+	MsgTypeGameSummary   MessageType = "game_summary"
+	MsgTypeAchievement   MessageType = "achievement_unlocked"
+	MsgTypeError         MessageType = "error"
+	MsgTypeRoomState     MessageType = "room_state"
+	MsgTypeScoreUpdate   MessageType = "score_update"
+	MsgTypeTeamsUpdated  MessageType = "teams_updated"
+	MsgTypeRematch       MessageType = "rematch_available"
+	MsgTypeResumed       MessageType = "resumed"
+	MsgTypePlayerOnline  MessageType = "player_online"
+	MsgTypePlayerOffline MessageType = "player_offline"
+	MsgTypePlayerKicked  MessageType = "player_kicked"
+	MsgTypePlayerReady   MessageType = "player_ready"
+	MsgTypeRulesUpdated  MessageType = "rules_updated"
+
+	// Explainer disconnect handling
+	MsgTypeRoundPaused      MessageType = "round_paused"
+	MsgTypeRoundResumed     MessageType = "round_resumed"
+	MsgTypeExplainerTimeout MessageType = "explainer_timeout"
+	MsgTypeExplainerSkipped MessageType = "explainer_skipped"
+)
+
+// ErrorCode tells clients why a command failed.
+type ErrorCode string
+
+const (
+	ErrCodeBadRequest     ErrorCode = "bad_request"
+	ErrCodeNotFound       ErrorCode = "not_found"
+	ErrCodeNotHost        ErrorCode = "not_host"
+	ErrCodeGameInProgress ErrorCode = "game_in_progress"
+	ErrCodeInvalidTeam    ErrorCode = "invalid_team"
+	ErrCodeInvalidRules   ErrorCode = "invalid_rules"
+	ErrCodeNotExplainer   ErrorCode = "not_explainer"
+	ErrCodeNoCurrentWord  ErrorCode = "no_current_word"
+	ErrCodeGamePaused     ErrorCode = "game_paused"
+	ErrCodeRateLimited    ErrorCode = "rate_limited"
+	ErrCodeStateConflict  ErrorCode = "state_conflict"
+	ErrCodeInternal       ErrorCode = "internal"
+)
+
+// IncomingMessage is a client message. Version 1 clients put the payload
+// fields next to type instead of in payload; DecodeIncoming accepts both.
+// Every client message is answered with an ack or an error; RequestID is
+// chosen by the client and echoed in that reply.
+type IncomingMessage struct {
+	Type      MessageType     `json:"type"`
+	RequestID string          `json:"request_id,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+// OutgoingMessage is a server message. Room events carry a per-room seq;
+// direct replies and timer ticks have none.
+type OutgoingMessage struct {
+	Type    MessageType `json:"type"`
+	Seq     int64       `json:"seq,omitempty"`
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+// Client -> Server payloads
+
+type SwipePayload struct {
+	Action string `json:"action"`
+}
+
+type ResumePayload struct {
+	LastSeq int64 `json:"last_seq"`
+}
+
+// ChangeTeamPayload moves the sender to a team; an empty team leaves it.
+type ChangeTeamPayload struct {
+	Team string `json:"team"`
+}
+
+type KickPayload struct {
+	UserID int64 `json:"user_id"`
+}
+
+// SetRulesPayload changes the room rules. Omitted fields keep their value.
+type SetRulesPayload struct {
+	Category     string `json:"category,omitempty"`
+	Lang         string `json:"lang,omitempty"`
+	RoundSeconds int    `json:"round_seconds,omitempty"`
+	WinningScore int    `json:"winning_score,omitempty"`
+}
+
+type ReadyPayload struct {
+	Ready bool `json:"ready"`
+}
+
+// Server -> Client payloads
+
+// HelloPayload is the first message on every connection of HelloVersion or
+// later. ProtocolVersion is the version negotiated for it.
+type HelloPayload struct {
+	ProtocolVersion    int `json:"protocol_version"`
+	MinProtocolVersion int `json:"min_protocol_version"`
+	MaxProtocolVersion int `json:"max_protocol_version"`
+}
+
+// AckPayload confirms that the command with RequestID succeeded.
+type AckPayload struct {
+	RequestID string `json:"request_id,omitempty"`
+}
+
+type PlayerJoinedPayload struct {
+	Player *Player `json:"player"`
+}
+
+type PlayerLeftPayload struct {
+	UserID    int64 `json:"user_id"`
+	NewHostID int64 `json:"new_host_id,omitempty"`
+}
+
+type PlayerPresencePayload struct {
+	UserID int64 `json:"user_id"`
+}
+
+type PlayerKickedPayload struct {
+	UserID   int64 `json:"user_id"`
+	KickedBy int64 `json:"kicked_by"`
+}
+
+type PlayerReadyPayload struct {
+	UserID int64 `json:"user_id"`
+	Ready  bool  `json:"ready"`
+}
+
+type TeamChangedPayload struct {
+	UserID int64  `json:"user_id"`
+	Team   string `json:"team"`
+}
+
+type TeamsUpdatedPayload struct {
+	Teams      []Team   `json:"teams"`
+	TeamNames  []string `json:"team_names"`
+	Unassigned []int64  `json:"unassigned,omitempty"`
+}
+
+type GameStartedPayload struct {
+	ExplainerID int64 `json:"explainer_id"`
+	RoundEndAt  int64 `json:"round_end_at"`
+}
+
+type NewWordPayload struct {
+	WordID int    `json:"word_id"`
+	Word   string `json:"word"`
+}
+
+type WordResultPayload struct {
+	WordID  int    `json:"word_id"`
+	Word    string `json:"word"`
+	Guessed bool   `json:"guessed"`
+}
+
+type TimerPayload struct {
+	SecondsLeft int `json:"seconds_left"`
+}
+
+type RoundEndPayload struct {
+	Round         int            `json:"round"`
+	TeamScores    map[string]int `json:"team_scores"`
+	NextExplainer int64          `json:"next_explainer"`
+}
+
+type GameEndPayload struct {
+	Winner     string         `json:"winner"`
+	TeamScores map[string]int `json:"team_scores"`
+}
+
+// GameSummaryPayload follows game_end with the summary of the game.
+type GameSummaryPayload = GameSummary
+
+// AchievementUnlockedPayload tells the room that a player unlocked an
+// achievement.
+type AchievementUnlockedPayload struct {
+	UserID      int64       `json:"user_id"`
+	Achievement Achievement `json:"achievement"`
+}
+
+// RoomStatePayload is the full state of the room as seen by one player. It
+// is sent on connect and on get_state and replaces whatever the client had.
+type RoomStatePayload struct {
+	Room      *Room             `json:"room"`
+	Players   []*Player         `json:"players"`
+	Teams     []Team            `json:"teams"`
+	Rules     RulesPayload      `json:"rules"`
+	Game      *GameStatePayload `json:"game,omitempty"`
+	LastRound *RoundSummary     `json:"last_round,omitempty"`
+}
+
+type RulesPayload struct {
+	Category         string `json:"category"`
+	Lang             string `json:"lang"`
+	RoundSeconds     int    `json:"round_seconds"`
+	MaxWordsPerRound int    `json:"max_words_per_round"`
+	WinningScore     int    `json:"winning_score"`
+}
+
+// GameStatePayload is the running game. CurrentWord is only set for players
+// allowed to see it.
+type GameStatePayload struct {
+	Status         string          `json:"status"`
+	CurrentRound   int             `json:"current_round"`
+	ExplainerID    int64           `json:"explainer_id"`
+	RoundEndAt     int64           `json:"round_end_at"`
+	SecondsLeft    int             `json:"seconds_left"`
+	WordsThisRound int             `json:"words_this_round"`
+	TeamScores     map[string]int  `json:"team_scores"`
+	CurrentWord    *NewWordPayload `json:"current_word,omitempty"`
+	Paused         bool            `json:"paused"`
+	ResumeDeadline int64           `json:"resume_deadline,omitempty"`
+}
+
+type RematchPayload struct {
+	RoomID      uuid.UUID `json:"room_id"`
+	Code        string    `json:"code,omitempty"`
+	RequestedBy int64     `json:"requested_by"`
+}
+
+// ResumedPayload ends a resume: either the missed events were replayed or, if
+// they are no longer available, a full snapshot was sent. The client is up to
+// date as of LastSeq.
+type ResumedPayload struct {
+	LastSeq  int64 `json:"last_seq"`
+	Replayed int   `json:"replayed"`
+	Snapshot bool  `json:"snapshot"`
+}
+
+// RoundPausedPayload is sent when the explainer disconnects. The round waits
+// for them until ResumeDeadline; after that the host may skip them.
+type RoundPausedPayload struct {
+	ExplainerID    int64 `json:"explainer_id"`
+	SecondsLeft    int   `json:"seconds_left"`
+	ResumeDeadline int64 `json:"resume_deadline"`
+}
+
+type RoundResumedPayload struct {
+	ExplainerID int64 `json:"explainer_id"`
+	RoundEndAt  int64 `json:"round_end_at"`
+	SecondsLeft int   `json:"seconds_left"`
+}
+
+// ExplainerTimeoutPayload is sent when the reconnect grace window is over and
+// the host can skip the explainer.
+type ExplainerTimeoutPayload struct {
+	ExplainerID int64 `json:"explainer_id"`
+}
+
+type ExplainerSkippedPayload struct {
+	ExplainerID int64 `json:"explainer_id"`
+	SkippedBy   int64 `json:"skipped_by"`
+}
+
+type ScoreUpdatePayload struct {
+	TeamScores map[string]int `json:"team_scores"`
+}
+
+// ErrorPayload reports a failed command. RequestID is set when the command
+// carried one.
+type ErrorPayload struct {
+	RequestID string    `json:"request_id,omitempty"`
+	Code      ErrorCode `json:"code,omitempty"`
+	Message   string    `json:"message"`
+}
@@ -0,0 +1,250 @@
+package protocol
+
+//go:generate go run ../../cmd/protocol-schema -o ../../../frontend/src/types/protocol.schema.json
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+const (
+	// ProtocolVersion is the newest protocol version the server speaks.
+	// Version 2 wraps client payloads in "payload" and adds hello.
+	ProtocolVersion = 2
+	// MinProtocolVersion is the oldest version still accepted. Version 1
+	// clients send payload fields flat next to "type".
+	MinProtocolVersion = 1
+	// HelloVersion is the first version whose connections start with hello.
+	HelloVersion = 2
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported protocol version")
+	ErrUnknownMessageType = errors.New("unknown message type")
+	ErrPayloadMismatch    = errors.New("payload does not match message type")
+)
+
+// Direction tells who sends a message type.
+type Direction int
+
+const (
+	ClientToServer Direction = iota + 1
+	ServerToClient
+)
+
+func (d Direction) String() string {
+	if d == ClientToServer {
+		return "client"
+	}
+	return "server"
+}
+
+// Spec describes a message type. Payload is nil for messages without one.
+type Spec struct {
+	Type      MessageType
+	Direction Direction
+	Payload   reflect.Type
+}
+
+// registry maps every message type to its direction and payload struct.
+var registry = map[MessageType]Spec{}
+
+func register(t MessageType, d Direction, payload interface{}) {
+	spec := Spec{Type: t, Direction: d}
+	if payload != nil {
+		spec.Payload = reflect.TypeOf(payload)
+	}
+	registry[t] = spec
+}
+
+func init() {
+	register(MsgTypeSwipe, ClientToServer, SwipePayload{})
+	register(MsgTypeVoteStart, ClientToServer, nil)
+	register(MsgTypeVotePause, ClientToServer, nil)
+	register(MsgTypeResume, ClientToServer, ResumePayload{})
+	register(MsgTypeGetState, ClientToServer, nil)
+	register(MsgTypeChangeTeam, ClientToServer, ChangeTeamPayload{})
+	register(MsgTypeStartGame, ClientToServer, nil)
+	register(MsgTypeKick, ClientToServer, KickPayload{})
+	register(MsgTypeSetRules, ClientToServer, SetRulesPayload{})
+	register(MsgTypeReady, ClientToServer, ReadyPayload{})
+
+	register(MsgTypeHello, ServerToClient, HelloPayload{})
+	register(MsgTypeAck, ServerToClient, AckPayload{})
+	register(MsgTypePlayerJoined, ServerToClient, PlayerJoinedPayload{})
+	register(MsgTypePlayerLeft, ServerToClient, PlayerLeftPayload{})
+	register(MsgTypeTeamChanged, ServerToClient, TeamChangedPayload{})
+	register(MsgTypeGameStarted, ServerToClient, GameStartedPayload{})
+	register(MsgTypeNewWord, ServerToClient, NewWordPayload{})
+	register(MsgTypeWordResult, ServerToClient, WordResultPayload{})
+	register(MsgTypeTimer, ServerToClient, TimerPayload{})
+	register(MsgTypeRoundEnd, ServerToClient, RoundEndPayload{})
+	register(MsgTypeGameEnd, ServerToClient, GameEndPayload{})
+	register(MsgTypeGameSummary, ServerToClient, GameSummaryPayload{})
+	register(MsgTypeAchievement, ServerToClient, AchievementUnlockedPayload{})
+	register(MsgTypeError, ServerToClient, ErrorPayload{})
+	register(MsgTypeRoomState, ServerToClient, RoomStatePayload{})
+	register(MsgTypeScoreUpdate, ServerToClient, ScoreUpdatePayload{})
+	register(MsgTypeTeamsUpdated, ServerToClient, TeamsUpdatedPayload{})
+	register(MsgTypeRematch, ServerToClient, RematchPayload{})
+	register(MsgTypeResumed, ServerToClient, ResumedPayload{})
+	register(MsgTypePlayerOnline, ServerToClient, PlayerPresencePayload{})
+	register(MsgTypePlayerOffline, ServerToClient, PlayerPresencePayload{})
+	register(MsgTypeRoundPaused, ServerToClient, RoundPausedPayload{})
+	register(MsgTypeRoundResumed, ServerToClient, RoundResumedPayload{})
+	register(MsgTypeExplainerTimeout, ServerToClient, ExplainerTimeoutPayload{})
+	register(MsgTypeExplainerSkipped, ServerToClient, ExplainerSkippedPayload{})
+	register(MsgTypePlayerKicked, ServerToClient, PlayerKickedPayload{})
+	register(MsgTypePlayerReady, ServerToClient, PlayerReadyPayload{})
+	register(MsgTypeRulesUpdated, ServerToClient, RulesPayload{})
+}
+
+// Lookup returns the spec of a message type.
+func Lookup(t MessageType) (Spec, bool) {
+	spec, ok := registry[t]
+	return spec, ok
+}
+
+// Specs returns all message types sorted by direction and name.
+func Specs() []Spec {
+	specs := make([]Spec, 0, len(registry))
+	for _, spec := range registry {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		if specs[i].Direction != specs[j].Direction {
+			return specs[i].Direction < specs[j].Direction
+		}
+		return specs[i].Type < specs[j].Type
+	})
+	return specs
+}
+
+// Negotiate picks the protocol version for a connection from the one the
+// client asked for. 0 means the client did not ask and speaks version 1.
+func Negotiate(requested int) (int, error) {
+	if requested == 0 {
+		return MinProtocolVersion, nil
+	}
+	if requested < MinProtocolVersion {
+		return 0, ErrUnsupportedVersion
+	}
+	if requested > ProtocolVersion {
+		return ProtocolVersion, nil
+	}
+	return requested, nil
+}
+
+// Encode marshals a server message, checking that payload is the registered
+// payload of t.
+func Encode(t MessageType, payload interface{}) ([]byte, error) {
+	if _, err := lookupPayload(t, ServerToClient, payload); err != nil {
+		return nil, err
+	}
+	return json.Marshal(OutgoingMessage{Type: t, Payload: payload})
+}
+
+// EncodeIncoming marshals a client message in the current protocol version,
+// checking that payload is the registered payload of t. requestID may be
+// empty.
+func EncodeIncoming(t MessageType, requestID string, payload interface{}) ([]byte, error) {
+	spec, err := lookupPayload(t, ClientToServer, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := IncomingMessage{Type: t, RequestID: requestID}
+	if spec.Payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		msg.Payload = raw
+	}
+	return json.Marshal(msg)
+}
+
+func lookupPayload(t MessageType, d Direction, payload interface{}) (Spec, error) {
+	spec, ok := registry[t]
+	if !ok || spec.Direction != d {
+		return Spec{}, fmt.Errorf("%w: %s", ErrUnknownMessageType, t)
+	}
+	return spec, checkPayload(spec, payload)
+}
+
+// Command is a decoded client message.
+type Command struct {
+	Type      MessageType
+	RequestID string
+	// Payload points to the payload struct registered for Type; nil for
+	// messages without payload
+	Payload interface{}
+}
+
+// DecodeIncoming parses a client message. When the message is valid JSON but
+// cannot be decoded, Type and RequestID are still filled in so the error can
+// be reported back to the client.
+func DecodeIncoming(data []byte) (Command, error) {
+	env, payload, err := decode(data, ClientToServer)
+	return Command{Type: env.Type, RequestID: env.RequestID, Payload: payload}, err
+}
+
+// DecodeOutgoing parses a server message into its type, seq and a pointer to
+// its payload struct (nil for messages without payload).
+func DecodeOutgoing(data []byte) (MessageType, int64, interface{}, error) {
+	var env struct {
+		Seq int64 `json:"seq"`
+	}
+	if err := json.Unmarshal(data, &env); err != nil {
+		return "", 0, nil, err
+	}
+	msg, payload, err := decode(data, ServerToClient)
+	return msg.Type, env.Seq, payload, err
+}
+
+func decode(data []byte, d Direction) (IncomingMessage, interface{}, error) {
+	var env IncomingMessage
+	if err := json.Unmarshal(data, &env); err != nil {
+		return env, nil, err
+	}
+
+	spec, ok := registry[env.Type]
+	if !ok || spec.Direction != d {
+		return env, nil, fmt.Errorf("%w: %s", ErrUnknownMessageType, env.Type)
+	}
+	if spec.Payload == nil {
+		return env, nil, nil
+	}
+
+	raw := []byte(env.Payload)
+	if len(raw) == 0 {
+		// Version 1: payload fields are next to type
+		raw = data
+	}
+	payload := reflect.New(spec.Payload).Interface()
+	if err := json.Unmarshal(raw, payload); err != nil {
+		return env, nil, err
+	}
+	return env, payload, nil
+}
+
+func checkPayload(spec Spec, payload interface{}) error {
+	if spec.Payload == nil {
+		if payload != nil {
+			return fmt.Errorf("%w: %s has no payload", ErrPayloadMismatch, spec.Type)
+		}
+		return nil
+	}
+
+	got := reflect.TypeOf(payload)
+	if got != nil && got.Kind() == reflect.Pointer {
+		got = got.Elem()
+	}
+	if got != spec.Payload {
+		return fmt.Errorf("%w: %s wants %s, got %v", ErrPayloadMismatch, spec.Type, spec.Payload, got)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested int
+		want      int
+		wantErr   error
+	}{
+		{"Not specified is version 1", 0, 1, nil},
+		{"Minimum version", MinProtocolVersion, MinProtocolVersion, nil},
+		{"Current version", ProtocolVersion, ProtocolVersion, nil},
+		{"Newer client gets current version", ProtocolVersion + 1, ProtocolVersion, nil},
+		{"Too old", -1, 0, ErrUnsupportedVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Negotiate(tt.requested)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDecodeIncoming(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		want      MessageType
+		requestID string
+		action    string
+		wantErr   error
+	}{
+		{"Envelope", `{"type":"swipe","payload":{"action":"up"}}`, MsgTypeSwipe, "", "up", nil},
+		{"Version 1 flat form", `{"type":"swipe","action":"down"}`, MsgTypeSwipe, "", "down", nil},
+		{"Request id", `{"type":"swipe","request_id":"r1","payload":{"action":"up"}}`, MsgTypeSwipe, "r1", "up", nil},
+		{"Version 1 request id", `{"type":"swipe","request_id":"r2","action":"up"}`, MsgTypeSwipe, "r2", "up", nil},
+		{"No payload", `{"type":"get_state"}`, MsgTypeGetState, "", "", nil},
+		{"Unknown type keeps request id", `{"type":"nope","request_id":"r3"}`, "nope", "r3", "", ErrUnknownMessageType},
+		{"Server message", `{"type":"new_word","payload":{"word":"x"}}`, MsgTypeNewWord, "", "", ErrUnknownMessageType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := DecodeIncoming([]byte(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if cmd.Type != tt.want {
+				t.Errorf("Expected type %s, got %s", tt.want, cmd.Type)
+			}
+			if cmd.RequestID != tt.requestID {
+				t.Errorf("Expected request id %q, got %q", tt.requestID, cmd.RequestID)
+			}
+			if tt.action == "" {
+				return
+			}
+			swipe, ok := cmd.Payload.(*SwipePayload)
+			if !ok {
+				t.Fatalf("Expected *SwipePayload, got %T", cmd.Payload)
+			}
+			if swipe.Action != tt.action {
+				t.Errorf("Expected action %s, got %s", tt.action, swipe.Action)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	if _, err := Encode(MsgTypeTimer, TimerPayload{SecondsLeft: 5}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if _, err := Encode(MsgTypeTimer, &TimerPayload{SecondsLeft: 5}); err != nil {
+		t.Errorf("Expected pointer payload to be accepted, got %v", err)
+	}
+	if _, err := Encode(MsgTypeTimer, NewWordPayload{}); !errors.Is(err, ErrPayloadMismatch) {
+		t.Errorf("Expected ErrPayloadMismatch, got %v", err)
+	}
+	if _, err := Encode(MsgTypeSwipe, SwipePayload{}); !errors.Is(err, ErrUnknownMessageType) {
+		t.Errorf("Expected ErrUnknownMessageType for client message, got %v", err)
+	}
+
+	data, err := Encode(MsgTypeNewWord, NewWordPayload{WordID: 7, Word: "кот"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	msgType, _, payload, err := DecodeOutgoing(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	word, ok := payload.(*NewWordPayload)
+	if msgType != MsgTypeNewWord || !ok || word.WordID != 7 || word.Word != "кот" {
+		t.Errorf("Expected new_word round trip, got %s %+v", msgType, payload)
+	}
+}
+
+func TestEncodeIncoming(t *testing.T) {
+	data, err := EncodeIncoming(MsgTypeSwipe, "", SwipePayload{Action: "up"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(data) != `{"type":"swipe","payload":{"action":"up"}}` {
+		t.Errorf("Unexpected encoding %s", data)
+	}
+
+	data, err = EncodeIncoming(MsgTypeStartGame, "r1", nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(data) != `{"type":"start_game","request_id":"r1"}` {
+		t.Errorf("Unexpected encoding %s", data)
+	}
+
+	if _, err := EncodeIncoming(MsgTypeGetState, "", SwipePayload{}); !errors.Is(err, ErrPayloadMismatch) {
+		t.Errorf("Expected ErrPayloadMismatch, got %v", err)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var schema struct {
+		Defs map[string]json.RawMessage `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	for _, spec := range Specs() {
+		if spec.Payload == nil {
+			continue
+		}
+		if _, ok := schema.Defs[spec.Payload.Name()]; !ok {
+			t.Errorf("Expected $defs to contain %s", spec.Payload.Name())
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package protocol
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// schemaID identifies the generated schema document.
+const schemaID = "https://github.com/yaroslav/elias/protocol.schema.json"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type schema map[string]interface{}
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing every message
+// of the protocol. Client and server messages are separate oneOf lists under
+// $defs, and every payload struct is a named definition.
+func JSONSchema() ([]byte, error) {
+	g := &schemaGenerator{defs: map[string]schema{}}
+
+	var client, server []interface{}
+	for _, spec := range Specs() {
+		name := messageDefName(spec)
+		g.defs[name] = g.message(spec)
+		ref := schema{"$ref": "#/$defs/" + name}
+		if spec.Direction == ClientToServer {
+			client = append(client, ref)
+		} else {
+			server = append(server, ref)
+		}
+	}
+	g.defs["ClientMessage"] = schema{"oneOf": client}
+	g.defs["ServerMessage"] = schema{"oneOf": server}
+
+	return json.MarshalIndent(schema{
+		"$schema":            "https://json-schema.org/draft/2020-12/schema",
+		"$id":                schemaID,
+		"title":              "Elias WebSocket protocol",
+		"x-protocol-version": ProtocolVersion,
+		"oneOf": []interface{}{
+			schema{"$ref": "#/$defs/ClientMessage"},
+			schema{"$ref": "#/$defs/ServerMessage"},
+		},
+		"$defs": g.defs,
+	}, "", "  ")
+}
+
+func messageDefName(spec Spec) string {
+	var b strings.Builder
+	for _, part := range strings.Split(string(spec.Type), "_") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	b.WriteString("Message")
+	return b.String()
+}
+
+type schemaGenerator struct {
+	defs map[string]schema
+}
+
+func (g *schemaGenerator) message(spec Spec) schema {
+	properties := schema{
+		"type": schema{"const": string(spec.Type)},
+	}
+	required := []string{"type"}
+	if spec.Direction == ServerToClient {
+		properties["seq"] = schema{"type": "integer", "minimum": 1}
+	} else {
+		properties["request_id"] = schema{"type": "string"}
+	}
+	if spec.Payload != nil {
+		properties["payload"] = g.typeSchema(spec.Payload)
+		if spec.Direction == ServerToClient {
+			required = append(required, "payload")
+		}
+	}
+	return schema{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+func (g *schemaGenerator) typeSchema(t reflect.Type) schema {
+	switch {
+	case t == timeType:
+		return schema{"type": "string", "format": "date-time"}
+	case t == durationType:
+		return schema{"type": "integer", "description": "nanoseconds"}
+	case t == rawMessageType:
+		return schema{}
+	case t.Kind() != reflect.Pointer && t.Implements(textMarshalerType):
+		// uuid.UUID and similar types marshal as strings
+		return schema{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.typeSchema(t.Elem())
+	case reflect.Bool:
+		return schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return schema{"type": "number"}
+	case reflect.String:
+		return schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return schema{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return schema{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		return g.structRef(t)
+	}
+	// interface{} and anything else accepts any JSON value
+	return schema{}
+}
+
+// structRef adds t to $defs once and returns a reference to it.
+func (g *schemaGenerator) structRef(t reflect.Type) schema {
+	name := t.Name()
+	ref := schema{"$ref": "#/$defs/" + name}
+	if _, ok := g.defs[name]; ok {
+		return ref
+	}
+	// Placeholder first so recursive types terminate
+	g.defs[name] = schema{}
+
+	properties := schema{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		fieldName, opts, _ := strings.Cut(tag, ",")
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+		properties[fieldName] = g.typeSchema(field.Type)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			required = append(required, fieldName)
+		}
+	}
+
+	def := schema{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		def["required"] = required
+	}
+	g.defs[name] = def
+	return ref
+}
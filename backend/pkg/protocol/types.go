@@ -0,0 +1,124 @@
+package protocol
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Wire types shared by several payloads. They mirror the server's models but
+// are owned by the protocol, so importing this package does not pull in the
+// server.
+
+type Team struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Room struct {
+	ID                 uuid.UUID  `json:"id"`
+	Code               string     `json:"code,omitempty"`
+	Status             string     `json:"status"`
+	CurrentRound       int        `json:"current_round"`
+	CurrentExplainerID *int64     `json:"current_explainer_id,omitempty"`
+	RoundEndAt         *time.Time `json:"round_end_at,omitempty"`
+	Category           string     `json:"category"`
+	Lang               string     `json:"lang"`
+	IsPublic           bool       `json:"is_public"`
+	RoundSeconds       int        `json:"round_seconds"`
+	WinningScore       int        `json:"winning_score"`
+	NumTeams           int        `json:"num_teams"`
+	Teams              []Team     `json:"teams"`
+	TeamNames          []string   `json:"team_names"`
+	RematchRoomID      *uuid.UUID `json:"rematch_room_id,omitempty"`
+	ChatID             *int64     `json:"chat_id,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+type Player struct {
+	ID        int       `json:"id"`
+	RoomID    uuid.UUID `json:"room_id"`
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username,omitempty"`
+	FirstName string    `json:"first_name,omitempty"`
+	Team      string    `json:"team,omitempty"`
+	Score     int       `json:"score"`
+	IsHost    bool      `json:"is_host"`
+	Ready     bool      `json:"ready"`
+	Online    bool      `json:"online"`
+	JoinedAt  time.Time `json:"joined_at"`
+}
+
+// RoundSummary lists the words played in one round.
+type RoundSummary struct {
+	RoundNum     int           `json:"round_num"`
+	Words        []*RoundEntry `json:"words"`
+	WordsGuessed int           `json:"words_guessed"`
+	WordsMissed  int           `json:"words_missed"`
+}
+
+type RoundEntry struct {
+	WordID  int    `json:"word_id"`
+	Word    string `json:"word"`
+	Guessed bool   `json:"guessed"`
+}
+
+type Achievement struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Metric      string `json:"metric"`
+	Threshold   int    `json:"threshold"`
+}
+
+// GameSummary sums up a finished game. Word counts of a player are the words
+// they explained.
+type GameSummary struct {
+	RoomID    uuid.UUID        `json:"room_id"`
+	Winner    string           `json:"winner,omitempty"`
+	Standings []*TeamStanding  `json:"standings"`
+	Players   []*PlayerSummary `json:"players"`
+	// MVPID is 0 if nobody got any word guessed
+	MVPID         int64            `json:"mvp_id,omitempty"`
+	BestRound     *RoundHighlight  `json:"best_round,omitempty"`
+	LongestStreak *StreakHighlight `json:"longest_streak,omitempty"`
+	HardestWord   *WordHighlight   `json:"hardest_word,omitempty"`
+}
+
+type TeamStanding struct {
+	Team  string `json:"team"`
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+	Place int    `json:"place"`
+}
+
+type PlayerSummary struct {
+	UserID          int64   `json:"user_id"`
+	FirstName       string  `json:"first_name"`
+	Team            string  `json:"team"`
+	WordsGuessed    int     `json:"words_guessed"`
+	WordsMissed     int     `json:"words_missed"`
+	RoundsExplained int     `json:"rounds_explained"`
+	Efficiency      float64 `json:"efficiency"`
+	LongestStreak   int     `json:"longest_streak"`
+}
+
+type RoundHighlight struct {
+	RoundNum     int   `json:"round_num"`
+	ExplainerID  int64 `json:"explainer_id"`
+	WordsGuessed int   `json:"words_guessed"`
+}
+
+type StreakHighlight struct {
+	RoundNum    int   `json:"round_num"`
+	ExplainerID int64 `json:"explainer_id"`
+	Length      int   `json:"length"`
+}
+
+type WordHighlight struct {
+	WordID      int     `json:"word_id"`
+	Word        string  `json:"word"`
+	RoundNum    int     `json:"round_num"`
+	ExplainerID int64   `json:"explainer_id"`
+	GuessRate   float64 `json:"guess_rate"`
+}
@@ -1,23 +1,27 @@
 import { useEffect } from 'react'
 import { useGameStore } from './stores/gameStore'
 import { useWebSocket } from './hooks/useWebSocket'
-import { initTelegram, getUser, getStartParam } from './lib/telegram'
-import { getRoom, joinRoom, createRoom } from './lib/api'
+import { initTelegram, getUser, getStartParam, getChatToken } from './lib/telegram'
+import { getRoom, getRoomByCode, joinRoom, createRoom } from './lib/api'
 import Home from './pages/Home'
 import Lobby from './pages/Lobby'
 import Game from './pages/Game'
 import Stats from './pages/Stats'
 
 function App() {
-  const { screen, setScreen, setUser, setRoom, setPlayers, room, setSendSwipe } = useGameStore()
+  const { screen, setScreen, setUser, setRoom, setPlayers, room, setSendSwipe, setSendCommand } = useGameStore()
 
   // Single WebSocket connection for the entire app
-  const { sendSwipe } = useWebSocket(room?.id || null)
+  const { sendSwipe, command } = useWebSocket(room?.id || null)
 
   useEffect(() => {
     setSendSwipe(sendSwipe)
   }, [sendSwipe, setSendSwipe])
 
+  useEffect(() => {
+    setSendCommand(command)
+  }, [command, setSendCommand])
+
   useEffect(() => {
     initTelegram()
 
@@ -31,15 +35,17 @@ function App() {
     console.log('[App] Start param:', startParam)
 
     async function init() {
-      // Try to get room ID from startParam or localStorage
+      // Try to get room ID or short code from startParam, or room ID from localStorage
       const savedRoomId = localStorage.getItem('currentRoomId')
-      const roomId = startParam || savedRoomId
+      let roomId = startParam || savedRoomId
 
       if (roomId) {
         // Join/reconnect to room
         console.log('[App] Attempting to join room:', roomId)
         try {
-          const roomData = await getRoom(roomId)
+          const isCode = roomId.length <= 6
+          const roomData = isCode ? await getRoomByCode(roomId) : await getRoom(roomId)
+          roomId = roomData.room.id
           console.log('[App] Room data:', roomData)
           setRoom(roomData.room)
           setPlayers(roomData.players)
@@ -84,7 +90,7 @@ function App() {
 
   const handleCreateRoom = async (category: string = 'general', numTeams: number = 2) => {
     try {
-      const { room, player } = await createRoom(category, numTeams)
+      const { room, player } = await createRoom(category, numTeams, 'ru', getChatToken())
       setRoom(room)
       setPlayers([player])
       // Save room ID for reconnection
@@ -0,0 +1,36 @@
+import { useEffect } from 'react'
+import { useGameStore } from '../stores/gameStore'
+
+const TOAST_MS = 4000
+
+// Shows the achievements unlocked in the room one at a time
+export default function AchievementToast() {
+  const { achievements, shiftAchievement, players, user } = useGameStore()
+  const current = achievements[0]
+
+  useEffect(() => {
+    if (!current) return
+    const timer = setTimeout(shiftAchievement, TOAST_MS)
+    return () => clearTimeout(timer)
+  }, [current, shiftAchievement])
+
+  if (!current) return null
+
+  const name = current.user_id === user?.id
+    ? 'Ты'
+    : players.find(p => p.user_id === current.user_id)?.first_name || 'Игрок'
+
+  return (
+    <div
+      onClick={shiftAchievement}
+      className="fixed top-4 left-4 right-4 z-50 p-3 bg-tg-secondary rounded-xl shadow-lg flex items-center gap-3"
+    >
+      <div className="text-3xl">🏅</div>
+      <div>
+        <div className="text-xs text-tg-hint">{name}: новое достижение</div>
+        <div className="font-semibold">{current.achievement.title}</div>
+        <div className="text-xs text-tg-hint">{current.achievement.description}</div>
+      </div>
+    </div>
+  )
+}
@@ -2,9 +2,12 @@ import type { Player } from '../types'
 
 interface PlayerListProps {
   players: Player[]
+  // Set for the host to kick other players
+  onKick?: (userId: number) => void
+  myUserId?: number
 }
 
-export default function PlayerList({ players }: PlayerListProps) {
+export default function PlayerList({ players, onKick, myUserId }: PlayerListProps) {
   if (players.length === 0) {
     return (
       <div className="text-center py-4 text-tg-hint text-sm">
@@ -18,7 +21,7 @@ export default function PlayerList({ players }: PlayerListProps) {
       {players.map((player) => (
         <div
           key={player.user_id}
-          className="flex items-center gap-2 px-3 py-2 bg-tg-secondary rounded-full"
+          className={`flex items-center gap-2 px-3 py-2 bg-tg-secondary rounded-full ${player.online ? '' : 'opacity-50'}`}
         >
           <div className="w-6 h-6 rounded-full bg-tg-button flex items-center justify-center text-tg-buttonText text-xs font-bold">
             {player.first_name?.[0] || player.username?.[0] || '?'}
@@ -29,6 +32,17 @@ export default function PlayerList({ players }: PlayerListProps) {
           {player.is_host && (
             <span className="text-xs">👑</span>
           )}
+          {player.ready && (
+            <span className="text-xs">✅</span>
+          )}
+          {onKick && player.user_id !== myUserId && (
+            <button
+              onClick={() => onKick(player.user_id)}
+              className="text-xs text-tg-hint"
+            >
+              ✕
+            </button>
+          )}
         </div>
       ))}
     </div>
@@ -1,11 +1,15 @@
+import type { Team } from '../types'
+
 interface ScoreBoardProps {
   teamScores: Record<string, number>
+  teams?: Team[]
 }
 
 const TEAM_COLORS = ['text-blue-500', 'text-red-500', 'text-green-500', 'text-yellow-500', 'text-purple-500']
 
-export default function ScoreBoard({ teamScores }: ScoreBoardProps) {
+export default function ScoreBoard({ teamScores, teams: roomTeams = [] }: ScoreBoardProps) {
   const teams = Object.entries(teamScores).sort((a, b) => a[0].localeCompare(b[0]))
+  const teamName = (id: string) => roomTeams.find(t => t.id === id)?.name ?? id
 
   return (
     <div className="flex items-center justify-center gap-2 flex-wrap">
@@ -13,7 +17,7 @@ export default function ScoreBoard({ teamScores }: ScoreBoardProps) {
         <div key={team} className="flex items-center gap-2">
           <div className="text-center">
             <div className={`text-sm font-medium ${TEAM_COLORS[index % TEAM_COLORS.length]} mb-1`}>
-              {team}
+              {teamName(team)}
             </div>
             <div className={`text-3xl font-bold ${TEAM_COLORS[index % TEAM_COLORS.length]}`}>
               {score}
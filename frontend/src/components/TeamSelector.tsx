@@ -6,6 +6,9 @@ interface TeamSelectorProps {
   players: Player[]
   isSelected: boolean
   onSelect: () => void
+  // Set for the host to kick other players
+  onKick?: (userId: number) => void
+  myUserId?: number
 }
 
 const TEAM_COLORS = [
@@ -16,7 +19,7 @@ const TEAM_COLORS = [
   { bg: 'bg-purple-500', border: 'border-purple-500', text: 'text-purple-500' },
 ]
 
-export default function TeamSelector({ team, teamIndex, players, isSelected, onSelect }: TeamSelectorProps) {
+export default function TeamSelector({ team, teamIndex, players, isSelected, onSelect, onKick, myUserId }: TeamSelectorProps) {
   const colors = TEAM_COLORS[teamIndex % TEAM_COLORS.length]
   const bgColor = colors.bg
   const borderColor = colors.border
@@ -54,6 +57,19 @@ export default function TeamSelector({ team, teamIndex, players, isSelected, onS
                 {player.first_name || player.username || 'Игрок'}
               </span>
               {player.is_host && <span className="text-xs">👑</span>}
+              {player.ready && <span className="text-xs">✅</span>}
+              {onKick && player.user_id !== myUserId && (
+                <span
+                  role="button"
+                  className="ml-auto text-xs text-tg-hint"
+                  onClick={(e) => {
+                    e.stopPropagation()
+                    onKick(player.user_id)
+                  }}
+                >
+                  ✕
+                </span>
+              )}
             </div>
           ))
         )}
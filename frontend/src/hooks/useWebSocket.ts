@@ -1,33 +1,83 @@
 import { useEffect, useRef, useCallback, useState } from 'react'
 import { getInitData } from '../lib/telegram'
+import { getRoom } from '../lib/api'
 import { useGameStore } from '../stores/gameStore'
 import type {
   WSMessage,
   PlayerJoinedPayload,
   PlayerLeftPayload,
   TeamChangedPayload,
+  TeamsUpdatedPayload,
+  RematchPayload,
+  ResumedPayload,
+  RoomStatePayload,
+  PlayerPresencePayload,
+  PlayerKickedPayload,
+  PlayerReadyPayload,
+  RulesPayload,
+  AckPayload,
+  ErrorPayload,
+  WSCommandType,
+  WSErrorCode,
+  RoundPausedPayload,
+  RoundResumedPayload,
   GameStartedPayload,
   NewWordPayload,
   TimerPayload,
   RoundEndPayload,
   GameEndPayload,
+  GameSummary,
+  AchievementUnlockedPayload,
 } from '../types'
 
 const WS_URL = import.meta.env.VITE_WS_URL || 'ws://localhost:8080'
 
+// Version of the WebSocket protocol this client speaks (backend/pkg/protocol)
+const PROTOCOL_VERSION = 2
+
+// How long a command waits for its ack or error
+const COMMAND_TIMEOUT = 10000
+
+// CommandError is a command rejected by the server; code is machine-readable
+export class CommandError extends Error {
+  code: WSErrorCode
+
+  constructor(code: WSErrorCode, message: string) {
+    super(message)
+    this.code = code
+  }
+}
+
+interface PendingCommand {
+  resolve: () => void
+  reject: (error: Error) => void
+  timeout: number
+}
+
 export function useWebSocket(roomId: string | null) {
   const wsRef = useRef<WebSocket | null>(null)
   const [isConnected, setIsConnected] = useState(false)
   const reconnectTimeoutRef = useRef<number | null>(null)
+  // Last room event seen, sent on reconnect to replay what was missed
+  const lastSeqRef = useRef(0)
+  // Commands waiting for their ack or error, by request id
+  const pendingRef = useRef(new Map<string, PendingCommand>())
+  const nextRequestIdRef = useRef(0)
 
   const {
     addPlayer,
     removePlayer,
     updatePlayerTeam,
+    setPlayerOnline,
+    setPlayerReady,
     setRoom,
+    setPlayers,
     setCurrentWord,
     setSecondsLeft,
     setTeamScores,
+    setPause,
+    setSummary,
+    addAchievement,
     setScreen,
     room,
   } = useGameStore()
@@ -36,7 +86,8 @@ export function useWebSocket(roomId: string | null) {
     if (!roomId) return
 
     const initData = encodeURIComponent(getInitData())
-    const ws = new WebSocket(`${WS_URL}/ws/${roomId}?init_data=${initData}`)
+    const resume = lastSeqRef.current > 0 ? `&last_seq=${lastSeqRef.current}` : ''
+    const ws = new WebSocket(`${WS_URL}/ws/${roomId}?init_data=${initData}&v=${PROTOCOL_VERSION}${resume}`)
 
     ws.onopen = () => {
       console.log('WebSocket connected')
@@ -47,6 +98,13 @@ export function useWebSocket(roomId: string | null) {
       console.log('WebSocket disconnected')
       setIsConnected(false)
 
+      // Replies to pending commands will not arrive on a new connection
+      pendingRef.current.forEach((pending) => {
+        clearTimeout(pending.timeout)
+        pending.reject(new Error('disconnected'))
+      })
+      pendingRef.current.clear()
+
       // Reconnect after 3 seconds
       reconnectTimeoutRef.current = window.setTimeout(() => {
         connect()
@@ -69,8 +127,54 @@ export function useWebSocket(roomId: string | null) {
     wsRef.current = ws
   }, [roomId])
 
+  const settleCommand = useCallback((requestId: string | undefined, error?: Error) => {
+    if (!requestId) return
+    const pending = pendingRef.current.get(requestId)
+    if (!pending) return
+    pendingRef.current.delete(requestId)
+    clearTimeout(pending.timeout)
+    if (error) pending.reject(error)
+    else pending.resolve()
+  }, [])
+
   const handleMessage = useCallback((message: WSMessage) => {
+    if (message.seq) {
+      // Already applied (replayed and broadcast at the same time)
+      if (message.seq <= lastSeqRef.current) return
+      lastSeqRef.current = message.seq
+    }
+
     switch (message.type) {
+      case 'room_state': {
+        const payload = message.payload as RoomStatePayload
+        const game = payload.game
+        setRoom({
+          ...payload.room,
+          current_round: game?.current_round ?? payload.room.current_round,
+          current_explainer_id: game?.explainer_id ?? payload.room.current_explainer_id,
+        })
+        setPlayers(payload.players)
+        setTeamScores(game?.team_scores ?? {})
+        setCurrentWord(game?.current_word ? { id: game.current_word.word_id, word: game.current_word.word } : null)
+        setSecondsLeft(game?.seconds_left ?? 0)
+        setPause(game?.paused ? {
+          explainerId: game.explainer_id,
+          resumeDeadline: game.resume_deadline ?? 0,
+          canSkip: (game.resume_deadline ?? 0) * 1000 <= Date.now(),
+        } : null)
+        if (payload.room.status === 'playing') setScreen('game')
+        else if (payload.room.status === 'finished') setScreen('stats')
+        else setScreen('lobby')
+        break
+      }
+      case 'resumed': {
+        const payload = message.payload as ResumedPayload
+        // After a snapshot the server's seq is authoritative, even if lower
+        lastSeqRef.current = payload.snapshot
+          ? payload.last_seq
+          : Math.max(lastSeqRef.current, payload.last_seq)
+        break
+      }
       case 'player_joined': {
         const payload = message.payload as PlayerJoinedPayload
         addPlayer(payload.player)
@@ -78,14 +182,77 @@ export function useWebSocket(roomId: string | null) {
       }
       case 'player_left': {
         const payload = message.payload as PlayerLeftPayload
+        removePlayer(payload.user_id, payload.new_host_id)
+        break
+      }
+      case 'player_kicked': {
+        const payload = message.payload as PlayerKickedPayload
+        if (payload.user_id === useGameStore.getState().user?.id) {
+          localStorage.removeItem('currentRoomId')
+          window.location.reload()
+          break
+        }
         removePlayer(payload.user_id)
         break
       }
+      case 'player_ready': {
+        const payload = message.payload as PlayerReadyPayload
+        setPlayerReady(payload.user_id, payload.ready)
+        break
+      }
+      case 'rules_updated': {
+        const payload = message.payload as RulesPayload
+        const current = useGameStore.getState().room
+        if (current) {
+          setRoom({
+            ...current,
+            category: payload.category,
+            lang: payload.lang,
+            round_seconds: payload.round_seconds,
+            winning_score: payload.winning_score,
+          })
+        }
+        break
+      }
+      case 'player_online':
+      case 'player_offline': {
+        const payload = message.payload as PlayerPresencePayload
+        setPlayerOnline(payload.user_id, message.type === 'player_online')
+        break
+      }
       case 'team_changed': {
         const payload = message.payload as TeamChangedPayload
         updatePlayerTeam(payload.user_id, payload.team)
         break
       }
+      case 'teams_updated': {
+        const payload = message.payload as TeamsUpdatedPayload
+        if (room) {
+          setRoom({
+            ...room,
+            teams: payload.teams,
+            team_names: payload.team_names,
+            num_teams: payload.teams.length,
+          })
+        }
+        payload.unassigned?.forEach((userId) => updatePlayerTeam(userId, ''))
+        break
+      }
+      case 'rematch_available': {
+        const payload = message.payload as RematchPayload
+        getRoom(payload.room_id)
+          .then((roomData) => {
+            localStorage.setItem('currentRoomId', roomData.room.id)
+            setRoom(roomData.room)
+            setPlayers(roomData.players)
+            setTeamScores({})
+            setCurrentWord(null)
+            setSummary(null)
+            setScreen('lobby')
+          })
+          .catch((e) => console.error('Failed to load rematch room:', e))
+        break
+      }
       case 'game_started': {
         const payload = message.payload as GameStartedPayload
         if (room) {
@@ -113,6 +280,27 @@ export function useWebSocket(roomId: string | null) {
         setSecondsLeft(payload.seconds_left)
         break
       }
+      case 'round_paused': {
+        const payload = message.payload as RoundPausedPayload
+        setSecondsLeft(payload.seconds_left)
+        setPause({ explainerId: payload.explainer_id, resumeDeadline: payload.resume_deadline, canSkip: false })
+        break
+      }
+      case 'round_resumed': {
+        const payload = message.payload as RoundResumedPayload
+        setSecondsLeft(payload.seconds_left)
+        setPause(null)
+        break
+      }
+      case 'explainer_timeout': {
+        const pause = useGameStore.getState().pause
+        if (pause) setPause({ ...pause, canSkip: true })
+        break
+      }
+      case 'explainer_skipped': {
+        setPause(null)
+        break
+      }
       case 'round_end': {
         const payload = message.payload as RoundEndPayload
         setTeamScores(payload.team_scores)
@@ -125,6 +313,7 @@ export function useWebSocket(roomId: string | null) {
         }
         setSecondsLeft(60)
         setCurrentWord(null)
+        setPause(null)
         break
       }
       case 'game_end': {
@@ -136,30 +325,77 @@ export function useWebSocket(roomId: string | null) {
         setScreen('stats')
         break
       }
+      case 'game_summary': {
+        setSummary(message.payload as GameSummary)
+        break
+      }
+      case 'achievement_unlocked': {
+        addAchievement(message.payload as AchievementUnlockedPayload)
+        break
+      }
       case 'score_update': {
         const payload = message.payload as { team_scores: Record<string, number> }
         setTeamScores(payload.team_scores)
         break
       }
+      case 'ack': {
+        const payload = message.payload as AckPayload
+        settleCommand(payload.request_id)
+        break
+      }
       case 'error': {
-        const payload = message.payload as { message: string }
-        console.error('Server error:', payload.message)
+        const payload = message.payload as ErrorPayload
+        console.error('Server error:', payload.code, payload.message)
+        settleCommand(payload.request_id, new CommandError(payload.code ?? 'internal', payload.message))
         break
       }
     }
-  }, [room, addPlayer, removePlayer, updatePlayerTeam, setRoom, setCurrentWord, setSecondsLeft, setTeamScores, setScreen])
+  }, [room, settleCommand, addPlayer, removePlayer, updatePlayerTeam, setPlayerOnline, setPlayerReady, setRoom, setPlayers, setCurrentWord, setSecondsLeft, setTeamScores, setPause, setSummary, addAchievement, setScreen])
 
   const send = useCallback((type: string, payload?: Record<string, unknown>) => {
     if (wsRef.current?.readyState === WebSocket.OPEN) {
-      wsRef.current.send(JSON.stringify({ type, ...(payload || {}) }))
+      wsRef.current.send(JSON.stringify(payload ? { type, payload } : { type }))
     }
   }, [])
 
-  const sendSwipe = useCallback((action: 'up' | 'down' | 'left' | 'right') => {
-    send('swipe', { action })
+  // command sends a lobby or game command and resolves once the server acks it
+  const command = useCallback((type: WSCommandType, payload?: Record<string, unknown>) => {
+    return new Promise<void>((resolve, reject) => {
+      const ws = wsRef.current
+      if (ws?.readyState !== WebSocket.OPEN) {
+        reject(new Error('not connected'))
+        return
+      }
+
+      const requestId = `r${++nextRequestIdRef.current}`
+      const timeout = window.setTimeout(() => {
+        pendingRef.current.delete(requestId)
+        reject(new Error('command timed out'))
+      }, COMMAND_TIMEOUT)
+      pendingRef.current.set(requestId, { resolve, reject, timeout })
+
+      ws.send(JSON.stringify(payload ? { type, request_id: requestId, payload } : { type, request_id: requestId }))
+    })
+  }, [])
+
+  const requestState = useCallback(() => {
+    send('get_state')
   }, [send])
 
+  const sendSwipe = useCallback((action: 'up' | 'down' | 'left' | 'right') => {
+    command('swipe', { action }).catch((e) => {
+      // Our view of the round is stale: the explainer changed, the round paused
+      // or the state moved on under us
+      if (e instanceof CommandError && (e.code === 'not_explainer' || e.code === 'game_paused' || e.code === 'state_conflict')) {
+        requestState()
+        return
+      }
+      console.error('Swipe failed:', e)
+    })
+  }, [command, requestState])
+
   useEffect(() => {
+    lastSeqRef.current = 0
     connect()
 
     return () => {
@@ -174,5 +410,7 @@ export function useWebSocket(roomId: string | null) {
     isConnected,
     send,
     sendSwipe,
+    command,
+    requestState,
   }
 }
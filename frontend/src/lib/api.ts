@@ -1,5 +1,5 @@
 import { getInitData } from './telegram'
-import type { Room, Player, GameStats } from '../types'
+import type { Room, Player, GameStats, GameReplay, Team, PublicRoom, User, UserStats, RatingChange, LeaderboardEntry, LeaderboardWindow, ChatLeaderboard, HeadToHead, Achievement, UserAchievement } from '../types'
 
 const API_URL = import.meta.env.VITE_API_URL || 'http://localhost:8080'
 
@@ -30,10 +30,11 @@ async function request<T>(path: string, options: RequestInit = {}): Promise<T> {
   return response.json()
 }
 
-export async function createRoom(category: string = 'general', numTeams: number = 2): Promise<{ room: Room; player: Player }> {
+// chatToken links the room to the Telegram group the bot posted the link in
+export async function createRoom(category: string = 'general', numTeams: number = 2, lang: string = 'ru', chatToken?: string): Promise<{ room: Room; player: Player }> {
   return request('/api/rooms', {
     method: 'POST',
-    body: JSON.stringify({ category, num_teams: numTeams }),
+    body: JSON.stringify({ category, lang, num_teams: numTeams, chat_token: chatToken }),
   })
 }
 
@@ -45,6 +46,40 @@ export async function joinRoom(roomId: string): Promise<{ player: Player }> {
   return request(`/api/rooms/${roomId}/join`, { method: 'POST' })
 }
 
+export async function getRoomByCode(code: string): Promise<{ room: Room; players: Player[] }> {
+  return request(`/api/rooms/by-code/${encodeURIComponent(code)}`)
+}
+
+export async function joinRoomByCode(code: string): Promise<{ player: Player; room_id: string }> {
+  return request(`/api/rooms/by-code/${encodeURIComponent(code)}/join`, { method: 'POST' })
+}
+
+export async function leaveRoom(roomId: string): Promise<{ status: string }> {
+  return request(`/api/rooms/${roomId}/leave`, { method: 'POST' })
+}
+
+export async function setRoomVisibility(roomId: string, isPublic: boolean): Promise<{ is_public: boolean }> {
+  return request(`/api/rooms/${roomId}/visibility`, {
+    method: 'POST',
+    body: JSON.stringify({ is_public: isPublic }),
+  })
+}
+
+export async function listPublicRooms(filter: { lang?: string; category?: string; min_free_seats?: number } = {}): Promise<{ rooms: PublicRoom[] }> {
+  const params = new URLSearchParams()
+  Object.entries(filter).forEach(([key, value]) => {
+    if (value !== undefined && value !== '') params.set(key, String(value))
+  })
+  return request(`/api/rooms/public?${params}`)
+}
+
+export async function quickMatch(lang?: string, category?: string): Promise<{ room: Room; player: Player; created: boolean }> {
+  return request('/api/matchmaking/quick', {
+    method: 'POST',
+    body: JSON.stringify({ lang, category }),
+  })
+}
+
 export async function changeTeam(roomId: string, team: string): Promise<{ player: Player }> {
   return request(`/api/rooms/${roomId}/team`, {
     method: 'POST',
@@ -52,10 +87,83 @@ export async function changeTeam(roomId: string, team: string): Promise<{ player
   })
 }
 
+export async function setNumTeams(roomId: string, numTeams: number): Promise<{ teams: Team[]; unassigned: number[] | null }> {
+  return request(`/api/rooms/${roomId}/teams`, {
+    method: 'POST',
+    body: JSON.stringify({ num_teams: numTeams }),
+  })
+}
+
+export async function renameTeam(roomId: string, teamId: string, name: string): Promise<{ teams: Team[] }> {
+  return request(`/api/rooms/${roomId}/teams/${teamId}/name`, {
+    method: 'POST',
+    body: JSON.stringify({ name }),
+  })
+}
+
+export async function rerollTeamName(roomId: string, teamId: string): Promise<{ teams: Team[] }> {
+  return request(`/api/rooms/${roomId}/teams/${teamId}/reroll`, { method: 'POST' })
+}
+
 export async function startGame(roomId: string): Promise<{ status: string }> {
   return request(`/api/rooms/${roomId}/start`, { method: 'POST' })
 }
 
+export async function skipExplainer(roomId: string): Promise<{ status: string }> {
+  return request(`/api/rooms/${roomId}/skip-explainer`, { method: 'POST' })
+}
+
+export async function rematch(roomId: string): Promise<{ room: Room; created: boolean }> {
+  return request(`/api/rooms/${roomId}/rematch`, { method: 'POST' })
+}
+
 export async function getStats(roomId: string): Promise<GameStats> {
   return request(`/api/rooms/${roomId}/stats`)
 }
+
+export async function getReplay(roomId: string): Promise<GameReplay> {
+  return request(`/api/rooms/${roomId}/replay`)
+}
+
+export async function getMe(): Promise<{ user: User; stats: UserStats }> {
+  return request('/api/me')
+}
+
+export async function getUserStats(userId: number): Promise<UserStats> {
+  return request(`/api/users/${userId}/stats`)
+}
+
+export async function getLeaderboard(lang: string, window: LeaderboardWindow = 'all'): Promise<{ lang: string; window: LeaderboardWindow; entries: LeaderboardEntry[] }> {
+  return request(`/api/leaderboard?lang=${lang}&window=${window}`)
+}
+
+export async function getRatingHistory(userId: number, lang?: string): Promise<{ user_id: number; history: RatingChange[] }> {
+  return request(`/api/users/${userId}/ratings${lang ? `?lang=${lang}` : ''}`)
+}
+
+export async function balanceTeams(roomId: string): Promise<{ players: Player[]; moved: number }> {
+  return request(`/api/rooms/${roomId}/teams/balance`, { method: 'POST' })
+}
+
+export async function getChatLeaderboard(chatId: number): Promise<ChatLeaderboard> {
+  return request(`/api/chats/${chatId}/leaderboard`)
+}
+
+// season is like "2024-Q2"; "current" is the running one
+export async function getChatSeason(chatId: number, season: string = 'current'): Promise<ChatLeaderboard> {
+  return request(`/api/chats/${chatId}/seasons/${season}`)
+}
+
+export async function getHeadToHead(chatId: number, userB: number, userA?: number): Promise<HeadToHead> {
+  const params = new URLSearchParams({ user_b: String(userB) })
+  if (userA) params.set('user_a', String(userA))
+  return request(`/api/chats/${chatId}/head-to-head?${params}`)
+}
+
+export async function getAchievements(): Promise<{ achievements: Achievement[] }> {
+  return request('/api/achievements')
+}
+
+export async function getUserAchievements(userId: number): Promise<{ user_id: number; achievements: UserAchievement[] }> {
+  return request(`/api/users/${userId}/achievements`)
+}
@@ -128,7 +128,19 @@ export function getUser() {
 }
 
 export function getStartParam(): string | undefined {
-  return tg?.initDataUnsafe?.start_param
+  // startapp=<code> links fill start_param; the bot's /start <code> button opens ?room=<code>
+  const param = tg?.initDataUnsafe?.start_param || new URLSearchParams(window.location.search).get('room') || undefined
+  return param && !isChatToken(param) ? param : undefined
+}
+
+// The bot's link in a group chat carries a chat_ token instead of a room
+function isChatToken(param: string): boolean {
+  return param.startsWith('chat_')
+}
+
+export function getChatToken(): string | undefined {
+  const param = tg?.initDataUnsafe?.start_param
+  return param && isChatToken(param) ? param : undefined
 }
 
 export function shareRoom(roomId: string) {
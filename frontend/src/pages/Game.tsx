@@ -1,7 +1,9 @@
 import { useGameStore } from '../stores/gameStore'
+import { skipExplainer } from '../lib/api'
 import SwipeCard from '../components/SwipeCard'
 import CircularTimer from '../components/CircularTimer'
 import ScoreBoard from '../components/ScoreBoard'
+import AchievementToast from '../components/AchievementToast'
 
 export default function Game() {
   const {
@@ -9,13 +11,28 @@ export default function Game() {
     currentWord,
     secondsLeft,
     teamScores,
+    pause,
+    players,
     isExplainer,
+    isHost,
     sendSwipe,
   } = useGameStore()
 
   const amExplainer = isExplainer()
+  const pausedExplainer = pause ? players.find(p => p.user_id === pause.explainerId) : undefined
+
+  const handleSkip = async () => {
+    if (!room) return
+    try {
+      await skipExplainer(room.id)
+    } catch (e) {
+      console.error('Failed to skip explainer:', e)
+    }
+  }
 
   const handleSwipe = (direction: 'up' | 'down' | 'left' | 'right') => {
+    // Only up (guessed) and down (missed) count
+    if (direction !== 'up' && direction !== 'down') return
     if (amExplainer && sendSwipe) {
       sendSwipe(direction)
     }
@@ -30,6 +47,7 @@ export default function Game() {
 
   return (
     <div className="flex flex-col h-full safe-area-top safe-area-bottom">
+      <AchievementToast />
       {/* Header with exit button */}
       <div className="p-2 flex justify-end">
         <button
@@ -42,7 +60,7 @@ export default function Game() {
 
       {/* Score */}
       <div className="px-4 pb-4">
-        <ScoreBoard teamScores={teamScores} />
+        <ScoreBoard teamScores={teamScores} teams={room?.teams} />
       </div>
 
       {/* Timer */}
@@ -50,6 +68,23 @@ export default function Game() {
         <CircularTimer seconds={secondsLeft} total={60} />
       </div>
 
+      {/* Explainer disconnected */}
+      {pause && (
+        <div className="mx-4 mb-2 p-3 bg-yellow-500/10 rounded-lg text-center text-sm">
+          <p>
+            {pausedExplainer?.first_name || pausedExplainer?.username || 'Объясняющий'} отключился, раунд на паузе
+          </p>
+          {pause.canSkip && isHost() && (
+            <button
+              onClick={handleSkip}
+              className="mt-2 px-4 py-1 bg-tg-button text-tg-buttonText rounded-lg font-medium"
+            >
+              Передать ход
+            </button>
+          )}
+        </div>
+      )}
+
       {/* Role indicator */}
       <div className="text-center py-2">
         {amExplainer ? (
@@ -1,12 +1,12 @@
 import { useEffect } from 'react'
 import { useGameStore } from '../stores/gameStore'
-import { changeTeam, startGame } from '../lib/api'
+import { leaveRoom } from '../lib/api'
 import { shareRoom, showMainButton, hideMainButton } from '../lib/telegram'
 import PlayerList from '../components/PlayerList'
 import TeamSelector from '../components/TeamSelector'
 
 export default function Lobby() {
-  const { room, players, isHost, getMyPlayer } = useGameStore()
+  const { room, players, isHost, getMyPlayer, sendCommand } = useGameStore()
   const isConnected = true // WebSocket is managed in App.tsx
 
   const myPlayer = getMyPlayer()
@@ -25,30 +25,51 @@ export default function Lobby() {
   }, [canStart, room])
 
   const handleTeamChange = async (team: string) => {
-    if (!room) return
+    if (!sendCommand) return
     try {
-      await changeTeam(room.id, team)
+      await sendCommand('change_team', { team })
     } catch (e) {
       console.error('Failed to change team:', e)
     }
   }
 
   const handleStart = async () => {
-    if (!room) return
+    if (!sendCommand) return
     try {
-      await startGame(room.id)
+      await sendCommand('start_game')
     } catch (e) {
       console.error('Failed to start game:', e)
     }
   }
 
+  const handleReady = async () => {
+    if (!sendCommand || !myPlayer) return
+    try {
+      await sendCommand('ready', { ready: !myPlayer.ready })
+    } catch (e) {
+      console.error('Failed to change ready:', e)
+    }
+  }
+
+  const handleKick = async (userId: number) => {
+    if (!sendCommand) return
+    try {
+      await sendCommand('kick', { user_id: userId })
+    } catch (e) {
+      console.error('Failed to kick player:', e)
+    }
+  }
+
   const handleShare = () => {
     if (room) {
-      shareRoom(room.id)
+      shareRoom(room.code || room.id)
     }
   }
 
-  const handleLeave = () => {
+  const handleLeave = async () => {
+    if (room) {
+      await leaveRoom(room.id).catch((e) => console.error('Failed to leave room:', e))
+    }
     localStorage.removeItem('currentRoomId')
     window.location.reload()
   }
@@ -86,14 +107,16 @@ export default function Lobby() {
       {/* Teams */}
       <div className="flex-1 overflow-y-auto p-4">
         <div className={`grid gap-4 mb-6 ${room.num_teams <= 2 ? 'grid-cols-2' : room.num_teams === 3 ? 'grid-cols-3' : 'grid-cols-2'}`}>
-          {room.team_names?.map((teamName, i) => (
+          {room.teams?.map((team, i) => (
             <TeamSelector
-              key={teamName}
-              team={teamName}
+              key={team.id}
+              team={team.name}
               teamIndex={i}
-              players={players.filter(p => p.team === teamName)}
-              isSelected={myPlayer?.team === teamName}
-              onSelect={() => handleTeamChange(teamName)}
+              players={players.filter(p => p.team === team.id)}
+              isSelected={myPlayer?.team === team.id}
+              onSelect={() => handleTeamChange(team.id)}
+              onKick={isHost() ? handleKick : undefined}
+              myUserId={myPlayer?.user_id}
             />
           ))}
         </div>
@@ -101,13 +124,17 @@ export default function Lobby() {
         {/* Unassigned players */}
         <div className="mb-6">
           <h3 className="text-sm font-medium text-tg-hint mb-2">Без команды</h3>
-          <PlayerList players={players.filter(p => !p.team)} />
+          <PlayerList
+            players={players.filter(p => !p.team)}
+            onKick={isHost() ? handleKick : undefined}
+            myUserId={myPlayer?.user_id}
+          />
         </div>
 
         {/* Info */}
         <div className="bg-tg-secondary rounded-xl p-4 text-center">
           <p className="text-sm text-tg-hint">
-            {players.length} / 8 игроков
+            {players.length} / 8 игроков · раунд {room.round_seconds} с · до {room.winning_score} очков
           </p>
           {!canStart && (
             <p className="text-sm text-tg-hint mt-2">
@@ -117,6 +144,18 @@ export default function Lobby() {
         </div>
       </div>
 
+      {/* Ready toggle */}
+      {myPlayer && (
+        <div className="px-4 pt-4">
+          <button
+            onClick={handleReady}
+            className={`w-full py-3 rounded-xl font-medium ${myPlayer.ready ? 'bg-green-500/10 text-green-500' : 'bg-tg-secondary'}`}
+          >
+            {myPlayer.ready ? '✅ Готов' : 'Готов?'}
+          </button>
+        </div>
+      )}
+
       {/* Start button (for host) */}
       {isHost() && canStart && (
         <div className="p-4 border-t border-tg-secondary">
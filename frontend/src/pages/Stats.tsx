@@ -1,10 +1,11 @@
 import { useEffect, useState } from 'react'
 import { useGameStore } from '../stores/gameStore'
-import { getStats } from '../lib/api'
+import { getStats, rematch } from '../lib/api'
 import type { GameStats } from '../types'
+import AchievementToast from '../components/AchievementToast'
 
 export default function Stats() {
-  const { room, teamScores, setScreen } = useGameStore()
+  const { room, players, teamScores, summary: liveSummary, setScreen, getTeamName } = useGameStore()
   const [stats, setStats] = useState<GameStats | null>(null)
 
   useEffect(() => {
@@ -13,24 +14,43 @@ export default function Stats() {
     }
   }, [room])
 
+  // game_summary arrives right after game_end; the stats request covers a reload
+  const summary = liveSummary ?? stats?.summary ?? null
+  const playerName = (userId: number) =>
+    summary?.players.find(p => p.user_id === userId)?.first_name ||
+    players.find(p => p.user_id === userId)?.first_name ||
+    '?'
+
   const teams = Object.entries(teamScores).sort((a, b) => b[1] - a[1])
   const winner = teams.length > 0 && teams[0][1] > teams[1][1] ? teams[0][0] : null
   const TEAM_COLORS = ['bg-blue-500', 'bg-red-500', 'bg-green-500', 'bg-yellow-500', 'bg-purple-500']
   const TEAM_TEXT_COLORS = ['text-blue-500', 'text-red-500', 'text-green-500', 'text-yellow-500', 'text-purple-500']
+  const teamIndex = (teamId: string) => room?.teams?.findIndex(t => t.id === teamId) ?? -1
 
   const handleNewGame = () => {
     setScreen('home')
   }
 
+  // The server broadcasts rematch_available, which moves everyone into the new room
+  const handleRematch = async () => {
+    if (!room) return
+    try {
+      await rematch(room.id)
+    } catch (e) {
+      console.error('Failed to start rematch:', e)
+    }
+  }
+
   return (
     <div className="flex flex-col h-full safe-area-top safe-area-bottom">
+      <AchievementToast />
       {/* Winner banner */}
-      <div className={`p-8 text-center ${winner ? TEAM_COLORS[['A', 'B', 'C', 'D', 'E'].indexOf(winner)] : 'bg-tg-secondary'}`}>
+      <div className={`p-8 text-center ${winner ? TEAM_COLORS[teamIndex(winner)] : 'bg-tg-secondary'}`}>
         <h1 className="text-3xl font-bold text-white mb-2">
-          {winner ? `Команда ${winner} победила!` : 'Ничья!'}
+          {winner ? `Команда ${getTeamName(winner)} победила!` : 'Ничья!'}
         </h1>
         <p className="text-white/80 text-xl">
-          {teams.map(([team, score]) => `${team}: ${score}`).join(' | ')}
+          {teams.map(([team, score]) => `${getTeamName(team)}: ${score}`).join(' | ')}
         </p>
       </div>
 
@@ -40,12 +60,49 @@ export default function Stats() {
         <div className={`grid gap-4 mb-6 ${teams.length <= 2 ? 'grid-cols-2' : teams.length === 3 ? 'grid-cols-3' : 'grid-cols-2'}`}>
           {teams.map(([team, score], index) => (
             <div key={team} className={`p-4 rounded-xl text-center ${winner === team ? `${TEAM_COLORS[index]}/20 border-2 ${TEAM_COLORS[index].replace('bg-', 'border-')}` : 'bg-tg-secondary'}`}>
-              <div className="text-sm text-tg-hint mb-1">Команда {team}</div>
+              <div className="text-sm text-tg-hint mb-1">Команда {getTeamName(team)}</div>
               <div className={`text-3xl font-bold ${TEAM_TEXT_COLORS[index]}`}>{score}</div>
             </div>
           ))}
         </div>
 
+        {/* Highlights */}
+        {summary && (
+          <div className="mb-6">
+            <h3 className="text-lg font-semibold mb-3">Итоги</h3>
+            <div className="grid grid-cols-2 gap-2">
+              {summary.mvp_id && (
+                <div className="p-3 bg-tg-secondary rounded-lg">
+                  <div className="text-xs text-tg-hint">MVP</div>
+                  <div className="font-semibold">🏆 {playerName(summary.mvp_id)}</div>
+                </div>
+              )}
+              {summary.best_round && (
+                <div className="p-3 bg-tg-secondary rounded-lg">
+                  <div className="text-xs text-tg-hint">Лучший раунд</div>
+                  <div className="font-semibold">
+                    #{summary.best_round.round_num}: {summary.best_round.words_guessed} слов
+                  </div>
+                </div>
+              )}
+              {summary.longest_streak && (
+                <div className="p-3 bg-tg-secondary rounded-lg">
+                  <div className="text-xs text-tg-hint">Серия подряд</div>
+                  <div className="font-semibold">
+                    🔥 {summary.longest_streak.length} — {playerName(summary.longest_streak.explainer_id)}
+                  </div>
+                </div>
+              )}
+              {summary.hardest_word && (
+                <div className="p-3 bg-tg-secondary rounded-lg">
+                  <div className="text-xs text-tg-hint">Самое трудное слово</div>
+                  <div className="font-semibold">{summary.hardest_word.word}</div>
+                </div>
+              )}
+            </div>
+          </div>
+        )}
+
         {/* Player stats */}
         {stats && (
           <div className="mb-6">
@@ -59,17 +116,19 @@ export default function Stats() {
                     className="flex items-center justify-between p-3 bg-tg-secondary rounded-lg"
                   >
                     <div className="flex items-center gap-3">
-                      <div className={`w-8 h-8 rounded-full flex items-center justify-center text-white font-bold ${TEAM_COLORS[['A', 'B', 'C', 'D', 'E'].indexOf(player.team)]}`}>
+                      <div className={`w-8 h-8 rounded-full flex items-center justify-center text-white font-bold ${TEAM_COLORS[teamIndex(player.team)]}`}>
                         {player.first_name?.[0] || '?'}
                       </div>
                       <div>
                         <div className="font-medium">{player.first_name}</div>
-                        <div className="text-xs text-tg-hint">Команда {player.team}</div>
+                        <div className="text-xs text-tg-hint">Команда {getTeamName(player.team)}</div>
                       </div>
                     </div>
                     <div className="text-right">
                       <div className="font-bold">{player.score}</div>
-                      <div className="text-xs text-tg-hint">очков</div>
+                      <div className="text-xs text-tg-hint">
+                        {player.words_guessed}/{player.words_guessed + player.words_missed} объяснено
+                      </div>
                     </div>
                   </div>
                 ))}
@@ -78,11 +137,17 @@ export default function Stats() {
         )}
       </div>
 
-      {/* New game button */}
-      <div className="p-4 border-t border-tg-secondary">
+      {/* Rematch and new game buttons */}
+      <div className="p-4 border-t border-tg-secondary space-y-2">
         <button
-          onClick={handleNewGame}
+          onClick={handleRematch}
           className="w-full py-4 bg-tg-button text-tg-buttonText rounded-xl font-semibold text-lg"
+        >
+          Реванш
+        </button>
+        <button
+          onClick={handleNewGame}
+          className="w-full py-4 bg-tg-secondary rounded-xl font-semibold text-lg"
         >
           Новая игра
         </button>
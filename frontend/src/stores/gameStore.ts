@@ -1,5 +1,5 @@
 import { create } from 'zustand'
-import type { Room, Player, Word, TelegramUser } from '../types'
+import type { Room, Player, Word, TelegramUser, RoundPause, WSCommandType, GameSummary, AchievementUnlockedPayload } from '../types'
 
 interface GameStore {
   // User
@@ -12,8 +12,10 @@ interface GameStore {
   setRoom: (room: Room | null) => void
   setPlayers: (players: Player[]) => void
   addPlayer: (player: Player) => void
-  removePlayer: (userId: number) => void
+  removePlayer: (userId: number, newHostId?: number) => void
   updatePlayerTeam: (userId: number, team: string) => void
+  setPlayerOnline: (userId: number, online: boolean) => void
+  setPlayerReady: (userId: number, ready: boolean) => void
 
   // Game state
   currentWord: Word | null
@@ -22,6 +24,14 @@ interface GameStore {
   setCurrentWord: (word: Word | null) => void
   setSecondsLeft: (seconds: number) => void
   setTeamScores: (scores: Record<string, number>) => void
+  pause: RoundPause | null
+  setPause: (pause: RoundPause | null) => void
+  summary: GameSummary | null
+  setSummary: (summary: GameSummary | null) => void
+  // Achievements unlocked in the room, oldest first, until shown
+  achievements: AchievementUnlockedPayload[]
+  addAchievement: (unlocked: AchievementUnlockedPayload) => void
+  shiftAchievement: () => void
 
   // UI state
   screen: 'loading' | 'home' | 'lobby' | 'game' | 'stats'
@@ -30,12 +40,15 @@ interface GameStore {
   // WebSocket
   sendSwipe: ((action: 'up' | 'down' | 'left' | 'right') => void) | null
   setSendSwipe: (fn: ((action: 'up' | 'down' | 'left' | 'right') => void) | null) => void
+  sendCommand: ((type: WSCommandType, payload?: Record<string, unknown>) => Promise<void>) | null
+  setSendCommand: (fn: ((type: WSCommandType, payload?: Record<string, unknown>) => Promise<void>) | null) => void
 
   // Helpers
   isHost: () => boolean
   isExplainer: () => boolean
   getMyPlayer: () => Player | undefined
   getTeamPlayers: (team: string) => Player[]
+  getTeamName: (teamId: string) => string
 
   // Reset
   reset: () => void
@@ -48,8 +61,12 @@ const initialState = {
   currentWord: null,
   secondsLeft: 60,
   teamScores: {} as Record<string, number>,
+  pause: null as RoundPause | null,
+  summary: null as GameSummary | null,
+  achievements: [] as AchievementUnlockedPayload[],
   screen: 'loading' as const,
   sendSwipe: null,
+  sendCommand: null,
 }
 
 export const useGameStore = create<GameStore>((set, get) => ({
@@ -65,8 +82,10 @@ export const useGameStore = create<GameStore>((set, get) => ({
     players: [...state.players.filter(p => p.user_id !== player.user_id), player],
   })),
 
-  removePlayer: (userId) => set((state) => ({
-    players: state.players.filter(p => p.user_id !== userId),
+  removePlayer: (userId, newHostId) => set((state) => ({
+    players: state.players
+      .filter(p => p.user_id !== userId)
+      .map(p => (newHostId && p.user_id === newHostId ? { ...p, is_host: true } : p)),
   })),
 
   updatePlayerTeam: (userId, team) => set((state) => ({
@@ -75,16 +94,42 @@ export const useGameStore = create<GameStore>((set, get) => ({
     ),
   })),
 
+  setPlayerOnline: (userId, online) => set((state) => ({
+    players: state.players.map(p =>
+      p.user_id === userId ? { ...p, online } : p
+    ),
+  })),
+
+  setPlayerReady: (userId, ready) => set((state) => ({
+    players: state.players.map(p =>
+      p.user_id === userId ? { ...p, ready } : p
+    ),
+  })),
+
   setCurrentWord: (word) => set({ currentWord: word }),
 
   setSecondsLeft: (seconds) => set({ secondsLeft: seconds }),
 
   setTeamScores: (scores) => set({ teamScores: scores }),
 
+  setPause: (pause) => set({ pause }),
+
+  setSummary: (summary) => set({ summary }),
+
+  addAchievement: (unlocked) => set((state) => ({
+    achievements: [...state.achievements, unlocked],
+  })),
+
+  shiftAchievement: () => set((state) => ({
+    achievements: state.achievements.slice(1),
+  })),
+
   setScreen: (screen) => set({ screen }),
 
   setSendSwipe: (fn) => set({ sendSwipe: fn }),
 
+  setSendCommand: (fn) => set({ sendCommand: fn }),
+
   isHost: () => {
     const { user, players } = get()
     if (!user) return false
@@ -109,5 +154,10 @@ export const useGameStore = create<GameStore>((set, get) => ({
     return players.filter(p => p.team === team)
   },
 
+  getTeamName: (teamId) => {
+    const { room } = get()
+    return room?.teams?.find(t => t.id === teamId)?.name ?? teamId
+  },
+
   reset: () => set(initialState),
 }))
@@ -15,18 +15,45 @@ export interface Player {
   team?: string
   score: number
   is_host: boolean
+  ready: boolean
+  online: boolean
   joined_at: string
 }
 
+export interface Team {
+  id: string
+  name: string
+}
+
 export interface Room {
   id: string
+  code?: string
   status: 'lobby' | 'playing' | 'finished'
   current_round: number
   current_explainer_id?: number
   round_end_at?: string
   category: string
+  lang: string
+  is_public: boolean
+  round_seconds: number
+  winning_score: number
   num_teams: number
+  teams: Team[]
   team_names: string[]
+  rematch_room_id?: string
+  chat_id?: number
+  created_at: string
+}
+
+export interface PublicRoom {
+  id: string
+  code: string
+  category: string
+  lang: string
+  num_teams: number
+  players: number
+  free_seats: number
+  host_name?: string
   created_at: string
 }
 
@@ -45,6 +72,8 @@ export interface GameState {
 
 // WebSocket messages
 export type WSMessageType =
+  | 'hello'
+  | 'ack'
   | 'player_joined'
   | 'player_left'
   | 'team_changed'
@@ -54,13 +83,28 @@ export type WSMessageType =
   | 'timer'
   | 'round_end'
   | 'game_end'
+  | 'game_summary'
+  | 'achievement_unlocked'
   | 'error'
   | 'room_state'
   | 'score_update'
+  | 'teams_updated'
+  | 'rematch_available'
+  | 'resumed'
+  | 'player_online'
+  | 'player_offline'
+  | 'round_paused'
+  | 'round_resumed'
+  | 'explainer_timeout'
+  | 'explainer_skipped'
+  | 'player_kicked'
+  | 'player_ready'
+  | 'rules_updated'
   | 'swipe'
 
 export interface WSMessage {
   type: WSMessageType
+  seq?: number
   payload?: unknown
 }
 
@@ -70,6 +114,7 @@ export interface PlayerJoinedPayload {
 
 export interface PlayerLeftPayload {
   user_id: number
+  new_host_id?: number
 }
 
 export interface TeamChangedPayload {
@@ -77,6 +122,125 @@ export interface TeamChangedPayload {
   team: string
 }
 
+export interface TeamsUpdatedPayload {
+  teams: Team[]
+  team_names: string[]
+  unassigned?: number[]
+}
+
+export interface RoundEntry {
+  word_id: number
+  word: string
+  guessed: boolean
+}
+
+export interface RoundSummary {
+  round_num: number
+  words: RoundEntry[]
+  words_guessed: number
+  words_missed: number
+}
+
+export interface RoomStatePayload {
+  room: Room
+  players: Player[]
+  teams: Team[]
+  rules: RulesPayload
+  game?: {
+    status: 'playing' | 'finished'
+    current_round: number
+    explainer_id: number
+    round_end_at: number
+    seconds_left: number
+    words_this_round: number
+    team_scores: Record<string, number>
+    current_word?: NewWordPayload
+    paused: boolean
+    resume_deadline?: number
+  }
+  last_round?: RoundSummary
+}
+
+export interface RulesPayload {
+  category: string
+  lang: string
+  round_seconds: number
+  max_words_per_round: number
+  winning_score: number
+}
+
+// Commands sent over the WebSocket; each is answered with ack or error
+export type WSCommandType = 'swipe' | 'change_team' | 'start_game' | 'kick' | 'set_rules' | 'ready'
+
+export type WSErrorCode =
+  | 'bad_request'
+  | 'not_found'
+  | 'not_host'
+  | 'game_in_progress'
+  | 'invalid_team'
+  | 'invalid_rules'
+  | 'not_explainer'
+  | 'no_current_word'
+  | 'game_paused'
+  | 'rate_limited'
+  | 'state_conflict'
+  | 'internal'
+
+export interface AckPayload {
+  request_id?: string
+}
+
+export interface ErrorPayload {
+  request_id?: string
+  code?: WSErrorCode
+  message: string
+}
+
+export interface PlayerKickedPayload {
+  user_id: number
+  kicked_by: number
+}
+
+export interface PlayerReadyPayload {
+  user_id: number
+  ready: boolean
+}
+
+export interface PlayerPresencePayload {
+  user_id: number
+}
+
+export interface RoundPausedPayload {
+  explainer_id: number
+  seconds_left: number
+  resume_deadline: number
+}
+
+export interface RoundResumedPayload {
+  explainer_id: number
+  round_end_at: number
+  seconds_left: number
+}
+
+// Round paused for a disconnected explainer; canSkip once the grace window is over
+export interface RoundPause {
+  explainerId: number
+  resumeDeadline: number
+  canSkip: boolean
+}
+
+export interface ResumedPayload {
+  last_seq: number
+  replayed: number
+  snapshot: boolean
+}
+
+export interface RematchPayload {
+  room_id: string
+  code?: string
+  requested_by: number
+}
+
 export interface GameStartedPayload {
   explainer_id: number
   round_end_at: number
@@ -113,6 +277,57 @@ export interface GameStats {
   team_scores: Record<string, number>
   players: PlayerStats[]
   rounds: RoundStats[]
+  summary?: GameSummary
+}
+
+// Word counts of a player are the words they explained
+export interface GameSummary {
+  room_id: string
+  winner?: string
+  standings: TeamStanding[]
+  players: PlayerSummary[]
+  mvp_id?: number
+  best_round?: RoundHighlight
+  longest_streak?: StreakHighlight
+  hardest_word?: WordHighlight
+}
+
+export interface TeamStanding {
+  team: string
+  name: string
+  score: number
+  place: number
+}
+
+export interface PlayerSummary {
+  user_id: number
+  first_name: string
+  team: string
+  words_guessed: number
+  words_missed: number
+  rounds_explained: number
+  efficiency: number
+  longest_streak: number
+}
+
+export interface RoundHighlight {
+  round_num: number
+  explainer_id: number
+  words_guessed: number
+}
+
+export interface StreakHighlight {
+  round_num: number
+  explainer_id: number
+  length: number
+}
+
+export interface WordHighlight {
+  word_id: number
+  word: string
+  round_num: number
+  explainer_id: number
+  guess_rate: number
 }
 
 export interface PlayerStats {
@@ -130,3 +345,111 @@ export interface RoundStats {
   words_guessed: number
   words_missed: number
 }
+
+export type GameEventType =
+  | 'game_started'
+  | 'word_shown'
+  | 'swipe'
+  | 'round_paused'
+  | 'round_resumed'
+  | 'round_ended'
+  | 'team_changed'
+  | 'game_ended'
+
+export interface GameEvent {
+  id: number
+  room_id: string
+  type: GameEventType
+  payload: Record<string, unknown>
+  created_at: string
+}
+
+export interface GameReplay {
+  room_id: string
+  events: GameEvent[]
+}
+
+export interface User {
+  id: number
+  username?: string
+  first_name?: string
+  last_name?: string
+  photo_url?: string
+  created_at: string
+  last_seen_at: string
+}
+
+export interface UserStats {
+  user_id: number
+  games_played: number
+  wins: number
+  words_explained: number
+  words_guessed: number
+  guess_rate: number
+  favourite_category?: string
+}
+
+export interface RatingChange {
+  room_id: string
+  lang: string
+  rating_before: number
+  rating_after: number
+  created_at: string
+}
+
+export type LeaderboardWindow = 'all' | 'month' | 'week' | 'day'
+
+export interface LeaderboardEntry {
+  rank: number
+  user_id: number
+  username?: string
+  first_name?: string
+  rating: number
+  games: number
+  change: number
+}
+
+export interface ChatStanding {
+  rank: number
+  user_id: number
+  username?: string
+  first_name?: string
+  games: number
+  wins: number
+  words_guessed: number
+}
+
+export interface ChatLeaderboard {
+  chat_id: number
+  season?: string
+  standings: ChatStanding[]
+}
+
+export interface HeadToHead {
+  chat_id: number
+  user_a: number
+  user_b: number
+  wins_a: number
+  wins_b: number
+  draws: number
+  games_together: number
+  wins_together: number
+}
+
+export interface Achievement {
+  id: string
+  title: string
+  description: string
+  metric: string
+  threshold: number
+}
+
+export interface UserAchievement extends Achievement {
+  room_id?: string
+  unlocked_at: string
+}
+
+export interface AchievementUnlockedPayload {
+  user_id: number
+  achievement: Achievement
+}